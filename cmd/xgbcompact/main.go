@@ -0,0 +1,89 @@
+// Command xgbcompact converts a gbtree model into the compact format that
+// predictor.NewPredictorByMmap maps into memory.
+//
+// Usage:
+//
+//	xgbcompact -model model.bin -o model.flat [-verify 1000]
+//
+// With -verify, the compact file is mapped back and its margins and leaf
+// indices are compared with the original model on sampled rows.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+
+	"xgboost4go-predictor/codegen"
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/predictor"
+)
+
+func main() {
+	modelPath := flag.String("model", "", "model file")
+	outPath := flag.String("o", "", "output compact model file")
+	verifyRows := flag.Int("verify", 1000, "number of sampled rows to compare after conversion (0 to skip)")
+	flag.Parse()
+
+	err := run(*modelPath, *outPath, *verifyRows)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xgbcompact:", err)
+		os.Exit(1)
+	}
+}
+
+func run(modelPath, outPath string, verifyRows int) error {
+	if modelPath == "" || outPath == "" {
+		return fmt.Errorf("-model and -o are required")
+	}
+	file, err := os.Open(modelPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	original, err := predictor.NewPredictorByReader(*bufio.NewReader(file))
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	err = original.WriteCompact(out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if verifyRows <= 0 {
+		return nil
+	}
+
+	mapped, err := predictor.NewPredictorByMmap(outPath)
+	if err != nil {
+		return err
+	}
+	defer mapped.Close()
+	for i, row := range codegen.SampleRows(original, verifyRows, 1) {
+		expected := original.PredictArrayWithMargin(row, false, true)
+		actual := mapped.PredictArrayWithMargin(row, false, true)
+		for k := range expected {
+			if math.Float32bits(expected[k]) != math.Float32bits(actual[k]) {
+				return fmt.Errorf("row %d group %d: compact margin %v differs from %v", i, k, actual[k], expected[k])
+			}
+		}
+		expectedLeaves := original.Gbm.(*gbm.GBTree).PredictLeafFromArray(row, original.Missing, 0, 0)
+		actualLeaves := mapped.Gbm.(*gbm.GBTree).PredictLeafFromArray(row, mapped.Missing, 0, 0)
+		for tid := range expectedLeaves {
+			if expectedLeaves[tid] != actualLeaves[tid] {
+				return fmt.Errorf("row %d tree %d: compact leaf %d differs from %d", i, tid, actualLeaves[tid], expectedLeaves[tid])
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "xgbcompact: %d rows verified\n", verifyRows)
+	return nil
+}
@@ -0,0 +1,167 @@
+// Command xgbdiff compares two models: their parameters and trees, and,
+// given a sample file, their predictions.
+//
+// Usage:
+//
+//	xgbdiff -a old.bin -b new.bin [-tolerance 1e-6] [-sample test.libsvm -pred-tolerance 1e-4]
+//
+// The sample is read like xgbscore's input (-format, -header, -label,
+// -missing). The exit status is 0 when the models are equal within the
+// tolerances, 1 when they differ and 2 on errors.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/diff"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/util"
+)
+
+func main() {
+	var options diff.Options
+	var text data.TextOptions
+	var delimiter, missing string
+	var label int
+	pathA := flag.String("a", "", "first (old) model file")
+	pathB := flag.String("b", "", "second (new) model file")
+	fmapPath := flag.String("fmap", "", "feature map file")
+	samplePath := flag.String("sample", "", "sample file to compare predictions on")
+	maxRows := flag.Int("rows", 0, "maximum sample rows (0 for all)")
+	flag.Float64Var(&options.Tolerance, "tolerance", 0, "tolerance for base_score, thresholds, leaf values and weights")
+	flag.Float64Var(&options.PredictionTolerance, "pred-tolerance", 0, "tolerance for predictions")
+	flag.BoolVar(&options.OutputMargin, "margin", false, "compare margins instead of predictions")
+	flag.IntVar(&options.MaxDifferences, "max-diffs", diff.DEFAULT_MAX_DIFFERENCES, "maximum structural differences listed")
+	flag.IntVar(&options.NtreeLimit, "ntree-limit", 0, "number of trees to predict with (0 for all)")
+	flag.StringVar(&text.Format, "format", data.FORMAT_LIBSVM, "sample format: libsvm or csv")
+	flag.BoolVar(&text.Header, "header", false, "the first CSV line is a header")
+	flag.IntVar(&label, "label", -1, "CSV column of the label, counted from 0 (-1 for none)")
+	flag.StringVar(&delimiter, "delimiter", ",", "CSV field delimiter")
+	flag.StringVar(&missing, "missing", "", "value read as missing besides NaN")
+	flag.Parse()
+
+	if len(delimiter) != 1 {
+		fmt.Fprintln(os.Stderr, "xgbdiff: -delimiter must be a single byte")
+		os.Exit(2)
+	}
+	text.Delimiter = delimiter[0]
+	if label >= 0 {
+		text.LabelColumn = &label
+	}
+	if missing != "" {
+		value, err := strconv.ParseFloat(missing, 32)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "xgbdiff: invalid -missing:", missing)
+			os.Exit(2)
+		}
+		sentinel := float32(value)
+		text.Missing = &sentinel
+	}
+
+	equal, err := run(os.Stdout, *pathA, *pathB, *fmapPath, *samplePath, *maxRows, text, options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xgbdiff:", err)
+		os.Exit(2)
+	}
+	if !equal {
+		os.Exit(1)
+	}
+}
+
+func load(modelPath string, featureMap *util.FeatureMap) (*predictor.Predictor, error) {
+	file, err := os.Open(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	magic := make([]byte, len(predictor.COMPACT_MAGIC))
+	_, err = io.ReadFull(file, magic)
+	if err == nil && string(magic) == predictor.COMPACT_MAGIC {
+		p, err := predictor.NewPredictorByMmap(modelPath)
+		if err != nil {
+			return nil, err
+		}
+		p.FeatureMap = featureMap
+		return p, nil
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	configuration := *config.DEFAULT
+	configuration.FeatureMap = featureMap
+	return predictor.NewPredictorByConf(*bufio.NewReader(file), configuration)
+}
+
+func run(out io.Writer, pathA, pathB, fmapPath, samplePath string, maxRows int, text data.TextOptions, options diff.Options) (bool, error) {
+	if pathA == "" || pathB == "" {
+		return false, fmt.Errorf("-a and -b are required")
+	}
+	var featureMap *util.FeatureMap
+	var err error
+	if fmapPath != "" {
+		featureMap, err = util.NewFeatureMapByFile(fmapPath)
+		if err != nil {
+			return false, err
+		}
+	}
+	a, err := load(pathA, featureMap)
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", pathA, err)
+	}
+	defer a.Close()
+	b, err := load(pathB, featureMap)
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", pathB, err)
+	}
+	defer b.Close()
+
+	report := diff.Compare(a, b, options)
+	if samplePath != "" {
+		err = compareSample(report, a, b, samplePath, maxRows, text, options)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = report.WriteText(out)
+	return report.Equal(), err
+}
+
+func compareSample(report *diff.Report, a, b *predictor.Predictor, samplePath string, maxRows int, text data.TextOptions, options diff.Options) error {
+	file, err := os.Open(samplePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := data.NewTextReader(bufio.NewReader(file), text)
+	if err != nil {
+		return err
+	}
+	rows := 0
+	for maxRows <= 0 || rows < maxRows {
+		batch := 4096
+		if maxRows > 0 && maxRows-rows < batch {
+			batch = maxRows - rows
+		}
+		matrix, _, err := reader.ReadBatch(batch)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", samplePath, err)
+		}
+		err = report.CompareBatch(a, b, matrix, options)
+		if err != nil {
+			return err
+		}
+		rows += matrix.NumRow()
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/diff"
+	"xgboost4go-predictor/internal/testmodel"
+)
+
+func writeModel(t *testing.T, dir, name string, leaf float32) string {
+	t.Helper()
+	buf := testmodel.Encode(testmodel.Spec{
+		BaseScore:  0.5,
+		NumFeature: 2,
+		Objective:  "reg:linear",
+		Trees: []testmodel.Tree{{Nodes: []testmodel.Node{
+			{Parent: -1, Left: 1, Right: 2, Feature: 0, DefaultLeft: true, Value: 1},
+			{Parent: 0, Left: -1, Right: -1, Value: 0.5},
+			{Parent: 0, Left: -1, Right: -1, Value: leaf},
+		}, Depth: 1}},
+	})
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, buf, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	old := writeModel(t, dir, "old.bin", 1)
+	same := writeModel(t, dir, "same.bin", 1)
+	changed := writeModel(t, dir, "new.bin", 1.5)
+	sample := filepath.Join(dir, "sample.libsvm")
+	err := os.WriteFile(sample, []byte("0 0:0\n1 0:2\n1 1:3\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := data.TextOptions{Format: data.FORMAT_LIBSVM}
+
+	var out bytes.Buffer
+	equal, err := run(&out, old, same, "", sample, 0, text, diff.Options{})
+	if err != nil || !equal {
+		t.Fatalf("same models: equal = %v, err = %v\n%s", equal, err, out.String())
+	}
+	if !strings.Contains(out.String(), "no structural differences") || !strings.Contains(out.String(), "0 rows over tolerance") {
+		t.Errorf("same models:\n%s", out.String())
+	}
+
+	out.Reset()
+	equal, err = run(&out, old, changed, "", sample, 0, text, diff.Options{})
+	if err != nil || equal {
+		t.Fatalf("changed models: equal = %v, err = %v", equal, err)
+	}
+	for _, line := range []string{"tree 0 node 2: leaf 1 -> 1.5", "predictions on 3 rows:", "max |delta| 0.5 (row 1)", "1 rows over tolerance"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output lacks %q:\n%s", line, out.String())
+		}
+	}
+
+	out.Reset()
+	_, err = run(&out, old, changed, "", sample, 1, text, diff.Options{Tolerance: 1, PredictionTolerance: 1})
+	if err != nil || !strings.Contains(out.String(), "predictions on 1 rows:") {
+		t.Errorf("-rows 1: err = %v\n%s", err, out.String())
+	}
+
+	_, err = run(&out, old, "", "", "", 0, text, diff.Options{})
+	if err == nil {
+		t.Error("missing -b was accepted")
+	}
+	_, err = run(&out, old, filepath.Join(dir, "absent.bin"), "", "", 0, text, diff.Options{})
+	if err == nil {
+		t.Error("missing model file was accepted")
+	}
+}
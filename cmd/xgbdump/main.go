@@ -0,0 +1,110 @@
+// Command xgbdump writes the trees of a model as an XGBoost text or JSON
+// dump, or as Graphviz graphs.
+//
+// Usage:
+//
+//	xgbdump -model model.bin [-fmap model.fmap] [-format text|json|dot] [-stats] [-tree 3] [-o dump.txt]
+//
+// The text dump lists every tree after a "booster[i]:" line and the JSON
+// dump is an array of trees, as written by XGBoost's dump_model. The dot
+// format writes one digraph per tree; -tree selects a single tree.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/tree"
+	"xgboost4go-predictor/util"
+)
+
+func main() {
+	modelPath := flag.String("model", "", "model file")
+	fmapPath := flag.String("fmap", "", "feature map file")
+	format := flag.String("format", tree.DUMP_FORMAT_TEXT, "dump format: text, json or dot")
+	with_stats := flag.Bool("stats", false, "include the gain and cover of every node")
+	tid := flag.Int("tree", -1, "dump only this tree")
+	outPath := flag.String("o", "-", "output file, - for stdout")
+	flag.Parse()
+
+	err := run(*modelPath, *fmapPath, *format, *with_stats, *tid, *outPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xgbdump:", err)
+		os.Exit(1)
+	}
+}
+
+func run(modelPath, fmapPath, format string, with_stats bool, tid int, outPath string) error {
+	if modelPath == "" {
+		return fmt.Errorf("-model is required")
+	}
+	configuration := *config.DEFAULT
+	if fmapPath != "" {
+		featureMap, err := util.NewFeatureMapByFile(fmapPath)
+		if err != nil {
+			return err
+		}
+		configuration.FeatureMap = featureMap
+	}
+	file, err := os.Open(modelPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	p, err := predictor.NewPredictorByConf(*bufio.NewReader(file), configuration)
+	if err != nil {
+		return err
+	}
+
+	dumps, err := p.DumpModel(with_stats, format)
+	if err != nil {
+		return err
+	}
+	first := 0
+	if tid >= 0 {
+		if tid >= len(dumps) {
+			return fmt.Errorf("-tree %d is out of range, the model has %d trees", tid, len(dumps))
+		}
+		first = tid
+		dumps = dumps[tid : tid+1]
+	}
+
+	out := os.Stdout
+	if outPath != "-" {
+		out, err = os.Create(outPath)
+		if err != nil {
+			return err
+		}
+	}
+	writer := bufio.NewWriter(out)
+	switch format {
+	case tree.DUMP_FORMAT_TEXT:
+		for i, dump := range dumps {
+			fmt.Fprintf(writer, "booster[%d]:\n%s", first+i, dump)
+		}
+	case tree.DUMP_FORMAT_JSON:
+		writer.WriteString("[\n")
+		for i, dump := range dumps {
+			if i > 0 {
+				writer.WriteString(",\n")
+			}
+			writer.WriteString(dump[:len(dump)-1])
+		}
+		writer.WriteString("\n]\n")
+	default:
+		for _, dump := range dumps {
+			writer.WriteString(dump)
+		}
+	}
+	err = writer.Flush()
+	if out != os.Stdout {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testModel = "../../testdata/logistic.bin"
+
+func readGolden(t *testing.T, name string) string {
+	t.Helper()
+	buf, err := os.ReadFile(filepath.Join("..", "..", "testdata", "dump", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func dump(t *testing.T, fmapPath, format string, with_stats bool, tid int) string {
+	t.Helper()
+	outPath := filepath.Join(t.TempDir(), "dump")
+	err := run(testModel, fmapPath, format, with_stats, tid, outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func TestRun(t *testing.T) {
+	fmapPath := filepath.Join(t.TempDir(), "model.fmap")
+	err := os.WriteFile(fmapPath, []byte("0 present i\n1 count int\n2 ratio q\n3 score float\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The text dump is the golden dump with a booster line before every tree.
+	text := dump(t, fmapPath, "text", true, -1)
+	trees := strings.Split(text, "booster[")
+	if trees[0] != "" || !strings.HasPrefix(trees[1], "0]:\n") {
+		t.Fatalf("text dump does not start with booster[0]:\n%s", text)
+	}
+	var stripped strings.Builder
+	for _, tree := range trees[1:] {
+		stripped.WriteString(tree[strings.Index(tree, "\n")+1:])
+	}
+	if stripped.String() != readGolden(t, "logistic_fmap_stats.txt") {
+		t.Errorf("text dump differs from the golden dump:\n%s", text)
+	}
+
+	// The JSON dump is an array of the trees.
+	var parsed []map[string]interface{}
+	err = json.Unmarshal([]byte(dump(t, "", "json", false, -1)), &parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != len(trees)-1 || parsed[0]["nodeid"] != 0.0 {
+		t.Errorf("JSON dump has %d trees, want %d", len(parsed), len(trees)-1)
+	}
+
+	// -tree selects one tree and keeps its number.
+	single := dump(t, "", "text", false, 1)
+	if !strings.HasPrefix(single, "booster[1]:\n") || strings.Count(single, "booster[") != 1 {
+		t.Errorf("-tree 1:\n%s", single)
+	}
+	dot := dump(t, "", "dot", false, -1)
+	if dot != readGolden(t, "logistic.dot") {
+		t.Errorf("dot dump differs from the golden dump:\n%s", dot)
+	}
+
+	out := filepath.Join(t.TempDir(), "dump")
+	for _, c := range []struct {
+		modelPath, format string
+		tid               int
+	}{
+		{"", "text", -1},
+		{testModel, "yaml", -1},
+		{testModel, "text", len(trees) - 1},
+		{"absent.bin", "text", -1},
+	} {
+		err = run(c.modelPath, "", c.format, false, c.tid, out)
+		if err == nil {
+			t.Errorf("model %q format %s tree %d was accepted", c.modelPath, c.format, c.tid)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Command xgbgen compiles an XGBoost model into a standalone Go file.
+//
+// Usage:
+//
+//	xgbgen -model model.bin -package mymodel -o model.go [-test model_test.go]
+//
+// With -test, a test comparing the generated code to the interpreter is
+// written as well, using rows from -samples (CSV, empty or "nan" for
+// missing) or rows sampled from the model's thresholds.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"xgboost4go-predictor/codegen"
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/predictor"
+)
+
+func main() {
+	modelPath := flag.String("model", "", "model file")
+	packageName := flag.String("package", "model", "package name of the generated code")
+	outPath := flag.String("o", "", "output Go file (stdout when empty)")
+	testPath := flag.String("test", "", "output test file comparing the generated code to the interpreter")
+	samplesPath := flag.String("samples", "", "CSV file of rows for the generated test")
+	numRows := flag.Int("rows", 200, "number of sampled rows for the generated test when -samples is not set")
+	seed := flag.Int64("seed", 1, "seed for sampled rows")
+	missing := flag.String("missing", "nan", "value treated as missing in array inputs")
+	flag.Parse()
+
+	err := run(*modelPath, *packageName, *outPath, *testPath, *samplesPath, *numRows, *seed, *missing)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xgbgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(modelPath, packageName, outPath, testPath, samplesPath string, numRows int, seed int64, missing string) error {
+	if modelPath == "" {
+		return fmt.Errorf("-model is required")
+	}
+	missingValue, err := strconv.ParseFloat(missing, 32)
+	if err != nil {
+		return fmt.Errorf("invalid -missing: %v", err)
+	}
+	missingValue32 := float32(missingValue)
+
+	file, err := os.Open(modelPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	p, err := predictor.NewPredictorByConf(*bufio.NewReader(file), config.Configuration{Missing: &missingValue32})
+	if err != nil {
+		return err
+	}
+
+	options := codegen.Options{PackageName: packageName}
+	err = writeFile(outPath, func(w io.Writer) error {
+		return codegen.Generate(w, p, options)
+	})
+	if err != nil {
+		return err
+	}
+	if testPath == "" {
+		return nil
+	}
+
+	var rows [][]float32
+	if samplesPath != "" {
+		rows, err = readSamples(samplesPath)
+		if err != nil {
+			return err
+		}
+	} else {
+		rows = codegen.SampleRows(p, numRows, seed)
+	}
+	return writeFile(testPath, func(w io.Writer) error {
+		return codegen.GenerateTest(w, p, options, rows)
+	})
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func readSamples(path string) ([][]float32, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rows [][]float32
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		row := make([]float32, len(fields))
+		for i, field := range fields {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				field = "nan"
+			}
+			value, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, lineNo, err)
+			}
+			row[i] = float32(value)
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
@@ -0,0 +1,95 @@
+// Command xgbgrpc serves the gRPC Predictor service of package rpc, with
+// the standard gRPC health service.
+//
+// Usage:
+//
+//	xgbgrpc -addr :9090 -model ctr=ctr.bin -model ctr:2=ctr2.bin -fmap ctr=ctr.fmap
+//
+// Models are given as for xgbserve and are loaded before the server
+// listens. The health service reports NOT_SERVING once shutdown begins.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/rpc"
+	"xgboost4go-predictor/serving"
+)
+
+func main() {
+	var models, fmaps serving.ListFlag
+	addr := flag.String("addr", ":9090", "listen address")
+	evaluator := flag.String("evaluator", "", "tree evaluator: traversal, quickscorer or quantized")
+	maxRows := flag.Int("max-rows", rpc.DEFAULT_MAX_ROWS, "maximum rows per batch")
+	flag.Var(&models, "model", "model to serve, as name[:version]=path (repeatable)")
+	flag.Var(&fmaps, "fmap", "feature map of a model, as name=path (repeatable)")
+	flag.Parse()
+
+	var specs []serving.ModelSpec
+	for _, value := range models {
+		spec, err := serving.ParseModelSpec(value)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "xgbgrpc:", err)
+			os.Exit(2)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		fmt.Fprintln(os.Stderr, "xgbgrpc: at least one -model is required")
+		os.Exit(2)
+	}
+	featureMaps, err := serving.LoadFeatureMaps(fmaps)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xgbgrpc:", err)
+		os.Exit(2)
+	}
+
+	registry := serving.NewRegistry(serving.RegistryOptions{
+		Configuration: config.Configuration{Evaluator: *evaluator},
+	})
+	for _, spec := range specs {
+		model, err := registry.LoadFile(spec.Name, spec.Version, spec.Path)
+		if err != nil {
+			log.Fatalf("xgbgrpc: loading %s: %v", spec.Path, err)
+		}
+		model.Predictor.FeatureMap = featureMaps[spec.Name]
+		log.Printf("xgbgrpc: loaded %s:%s from %s", spec.Name, spec.Version, spec.Path)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("xgbgrpc: %v", err)
+	}
+	server := grpc.NewServer()
+	predictorServer := rpc.NewServer(registry)
+	predictorServer.MaxRows = *maxRows
+	rpc.RegisterPredictorServer(server, predictorServer)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stop
+		healthServer.Shutdown()
+		server.GracefulStop()
+	}()
+
+	log.Printf("xgbgrpc: listening on %s", *addr)
+	err = server.Serve(listener)
+	if err != nil {
+		log.Fatalf("xgbgrpc: %v", err)
+	}
+}
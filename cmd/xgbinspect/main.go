@@ -0,0 +1,264 @@
+// Command xgbinspect prints a summary of a model: its format, parameters,
+// tree counts and shapes, leaf value ranges, the features it uses and their
+// importance.
+//
+// Usage:
+//
+//	xgbinspect -model model.bin [-fmap model.fmap] [-importance gain] [-top 20]
+//
+// Compact models are detected by their magic and mapped; they keep only the
+// flattened forest, so just their tree and node counts are reported.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/util"
+)
+
+func main() {
+	modelPath := flag.String("model", "", "model file")
+	fmapPath := flag.String("fmap", "", "feature map file")
+	importance_type := flag.String("importance", gbm.IMPORTANCE_GAIN, "importance type: weight, gain, cover, total_gain or total_cover")
+	top := flag.Int("top", 20, "number of features listed by importance (0 for all)")
+	flag.Parse()
+
+	err := run(os.Stdout, *modelPath, *fmapPath, *importance_type, *top)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xgbinspect:", err)
+		os.Exit(1)
+	}
+}
+
+func load(modelPath string, featureMap *util.FeatureMap) (*predictor.Predictor, error) {
+	file, err := os.Open(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	magic := make([]byte, len(predictor.COMPACT_MAGIC))
+	_, err = io.ReadFull(file, magic)
+	if err == nil && string(magic) == predictor.COMPACT_MAGIC {
+		p, err := predictor.NewPredictorByMmap(modelPath)
+		if err != nil {
+			return nil, err
+		}
+		p.FeatureMap = featureMap
+		return p, nil
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	configuration := *config.DEFAULT
+	configuration.FeatureMap = featureMap
+	return predictor.NewPredictorByConf(*bufio.NewReader(file), configuration)
+}
+
+func run(out io.Writer, modelPath, fmapPath, importance_type string, top int) error {
+	if modelPath == "" {
+		return fmt.Errorf("-model is required")
+	}
+	var featureMap *util.FeatureMap
+	var err error
+	if fmapPath != "" {
+		featureMap, err = util.NewFeatureMapByFile(fmapPath)
+		if err != nil {
+			return err
+		}
+	}
+	p, err := load(modelPath, featureMap)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "file\t%s\n", modelPath)
+	fmt.Fprintf(w, "format\t%s\n", p.Format)
+	fmt.Fprintf(w, "objective\t%s\n", p.Name_obj)
+	fmt.Fprintf(w, "booster\t%s\n", p.Name_gbm)
+	fmt.Fprintf(w, "base_score\t%v\n", p.Mparam.BaseScore())
+	fmt.Fprintf(w, "num_feature\t%d\n", p.Mparam.NumFeature())
+	fmt.Fprintf(w, "num_class\t%d\n", p.Mparam.NumClass())
+	fmt.Fprintf(w, "output groups\t%d\n", p.Gbm.NumOutputGroup())
+	fmt.Fprintf(w, "size\t%d bytes\n", p.SizeBytes())
+
+	switch booster := p.Gbm.(type) {
+	case *gbm.GBTree:
+		err = inspectTrees(w, p, booster, importance_type, top)
+	case *gbm.GBLinear:
+		inspectLinear(w, p, booster, top)
+	}
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func featureName(p *predictor.Predictor, fid int) string {
+	if p.FeatureMap != nil && fid < p.FeatureMap.NumFeature() {
+		return p.FeatureMap.Name(fid)
+	}
+	return fmt.Sprintf("f%d", fid)
+}
+
+func inspectTrees(w io.Writer, p *predictor.Predictor, gbTree *gbm.GBTree, importance_type string, top int) error {
+	num_group := gbTree.NumOutputGroup()
+	treesPerGroup := make([]int, num_group)
+	for _, gid := range gbTree.TreeInfo() {
+		if gid >= 0 && gid < num_group {
+			treesPerGroup[gid]++
+		}
+	}
+	fmt.Fprintf(w, "trees\t%d\n", gbTree.Forest().NumTrees())
+	if num_group > 1 {
+		for gid, count := range treesPerGroup {
+			fmt.Fprintf(w, "  group %d\t%d\n", gid, count)
+		}
+	}
+
+	trees := gbTree.Trees()
+	if trees == nil {
+		fmt.Fprintf(w, "nodes\t%d\n", gbTree.Forest().NumNodes())
+		fmt.Fprintf(w, "tree details\tunavailable for %s models\n", p.Format)
+		return nil
+	}
+
+	depths := make(map[int]int)
+	numNodes, numLeaves := 0, 0
+	leafMin := make([]float64, num_group)
+	leafMax := make([]float64, num_group)
+	for gid := range leafMin {
+		leafMin[gid], leafMax[gid] = math.Inf(1), math.Inf(-1)
+	}
+	used := make(map[int]bool)
+	for tid, rt := range trees {
+		gid := gbTree.TreeInfo()[tid]
+		depths[rt.MaxDepth(0)]++
+		rt.Walk(func(nid, depth int) {
+			node := rt.Node(nid)
+			numNodes++
+			if !node.IsLeaf() {
+				used[node.SplitIndex()] = true
+				return
+			}
+			numLeaves++
+			if gid >= 0 && gid < num_group {
+				leafMin[gid] = math.Min(leafMin[gid], float64(node.LeafValue()))
+				leafMax[gid] = math.Max(leafMax[gid], float64(node.LeafValue()))
+			}
+		})
+	}
+
+	var depthKeys []int
+	for depth := range depths {
+		depthKeys = append(depthKeys, depth)
+	}
+	sort.Ints(depthKeys)
+	fmt.Fprintf(w, "depth\t")
+	for i, depth := range depthKeys {
+		if i > 0 {
+			fmt.Fprintf(w, ", ")
+		}
+		fmt.Fprintf(w, "%d: %d trees", depth, depths[depth])
+	}
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "nodes\t%d (%d splits, %d leaves)\n", numNodes, numNodes-numLeaves, numLeaves)
+	for gid := 0; gid < num_group; gid++ {
+		if treesPerGroup[gid] == 0 {
+			continue
+		}
+		label := "leaf values"
+		if num_group > 1 {
+			label = fmt.Sprintf("leaf values %d", gid)
+		}
+		fmt.Fprintf(w, "%s\t[%v, %v]\n", label, float32(leafMin[gid]), float32(leafMax[gid]))
+	}
+
+	var features []int
+	for fid := range used {
+		features = append(features, fid)
+	}
+	sort.Ints(features)
+	fmt.Fprintf(w, "features used\t%d", len(features))
+	if num_feature := p.Mparam.NumFeature(); num_feature > 0 {
+		fmt.Fprintf(w, " of %d", num_feature)
+	}
+	fmt.Fprintf(w, "\n")
+
+	importance, err := gbTree.FeatureImportance(importance_type)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "importance\t%s\n", importance_type)
+	sort.SliceStable(features, func(i, j int) bool {
+		return importance[features[i]] > importance[features[j]]
+	})
+	if top > 0 && len(features) > top {
+		features = features[:top]
+	}
+	for _, fid := range features {
+		fmt.Fprintf(w, "  %s\t%v\n", featureName(p, fid), importance[fid])
+	}
+	return nil
+}
+
+func inspectLinear(w io.Writer, p *predictor.Predictor, gbLinear *gbm.GBLinear, top int) {
+	num_group := gbLinear.NumOutputGroup()
+	for gid := 0; gid < num_group; gid++ {
+		suffix := ""
+		if num_group > 1 {
+			suffix = fmt.Sprintf(" %d", gid)
+		}
+		fmt.Fprintf(w, "bias%s\t%v\n", suffix, gbLinear.Bias(gid))
+	}
+
+	// Features are ranked by their largest absolute weight over the groups.
+	weights := make(map[int]float32)
+	for fid := 0; fid < gbLinear.NumFeature(); fid++ {
+		for gid := 0; gid < num_group; gid++ {
+			weight := float32(math.Abs(float64(gbLinear.Weight(fid, gid))))
+			if weight > weights[fid] {
+				weights[fid] = weight
+			}
+		}
+	}
+	var features []int
+	for fid, weight := range weights {
+		if weight != 0 {
+			features = append(features, fid)
+		}
+	}
+	fmt.Fprintf(w, "features used\t%d of %d\n", len(features), gbLinear.NumFeature())
+	sort.Slice(features, func(i, j int) bool {
+		if weights[features[i]] != weights[features[j]] {
+			return weights[features[i]] > weights[features[j]]
+		}
+		return features[i] < features[j]
+	})
+	if top > 0 && len(features) > top {
+		features = features[:top]
+	}
+	fmt.Fprintf(w, "weights\t\n")
+	for _, fid := range features {
+		fmt.Fprintf(w, "  %s\t", featureName(p, fid))
+		for gid := 0; gid < num_group; gid++ {
+			if gid > 0 {
+				fmt.Fprintf(w, " ")
+			}
+			fmt.Fprintf(w, "%v", gbLinear.Weight(fid, gid))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
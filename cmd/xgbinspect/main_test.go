@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/internal/testmodel"
+	"xgboost4go-predictor/predictor"
+)
+
+// writeModel writes a model with splits on f0 (gain 10), f1 (gains 4 and 6)
+// and f2 (gain 1).
+func writeModel(t *testing.T, dir string) string {
+	t.Helper()
+	buf := testmodel.Encode(testmodel.Spec{
+		BaseScore:  0.5,
+		NumFeature: 3,
+		Objective:  "reg:linear",
+		Trees: []testmodel.Tree{{Depth: 2, Nodes: []testmodel.Node{
+			{Parent: -1, Left: 1, Right: 2, Feature: 0, Gain: 10, Cover: 20},
+			{Parent: 0, Left: -1, Right: -1, Value: -1},
+			{Parent: 0, Left: 3, Right: 4, Feature: 1, Gain: 4, Cover: 8},
+			{Parent: 2, Left: -1, Right: -1, Value: 2},
+			{Parent: 2, Left: -1, Right: -1, Value: 3},
+		}}, {Depth: 2, Nodes: []testmodel.Node{
+			{Parent: -1, Left: 1, Right: 2, Feature: 1, Gain: 6, Cover: 12},
+			{Parent: 0, Left: 3, Right: 4, Feature: 2, Gain: 1, Cover: 5},
+			{Parent: 0, Left: -1, Right: -1, Value: 0.5},
+			{Parent: 1, Left: -1, Right: -1, Value: -0.5},
+			{Parent: 1, Left: -1, Right: -1, Value: 0.25},
+		}}},
+	})
+	path := filepath.Join(dir, "model.bin")
+	err := os.WriteFile(path, buf, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// lines returns the output with the tabwriter padding collapsed.
+func lines(out string) []string {
+	var result []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		result = append(result, strings.Join(strings.Fields(line), " "))
+	}
+	return result
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	model := writeModel(t, dir)
+	fmap := filepath.Join(dir, "model.fmap")
+	err := os.WriteFile(fmap, []byte("0 age q\n1 income q\n2 owner i\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = run(&out, model, fmap, "total_gain", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"file " + model,
+		"format binary",
+		"objective reg:linear",
+		"booster gbtree",
+		"base_score 0.5",
+		"num_feature 3",
+		"num_class 0",
+		"output groups 1",
+	}
+	got := lines(out.String())
+	if strings.Join(got[:len(expected)], "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got\n%s", out.String())
+	}
+	expected = []string{
+		"trees 2",
+		"depth 2: 2 trees",
+		"nodes 10 (4 splits, 6 leaves)",
+		"leaf values [-1, 3]",
+		"features used 3 of 3",
+		"importance total_gain",
+		"age 10",
+		"income 10",
+		"owner 1",
+	}
+	if strings.Join(got[len(got)-len(expected):], "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got\n%s", out.String())
+	}
+
+	cases := []struct {
+		importance_type string
+		top             int
+		expected        []string
+	}{
+		{"gain", 0, []string{"importance gain", "age 10", "income 5", "owner 1"}},
+		{"cover", 2, []string{"importance cover", "age 20", "income 10"}},
+		{"weight", 1, []string{"importance weight", "f1 2"}},
+		{"total_cover", 0, []string{"importance total_cover", "f0 20", "f1 20", "f2 5"}},
+	}
+	for _, c := range cases {
+		out.Reset()
+		path := fmap
+		if c.importance_type == "weight" || c.importance_type == "total_cover" {
+			path = ""
+		}
+		err = run(&out, model, path, c.importance_type, c.top)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := lines(out.String())
+		if strings.Join(got[len(got)-len(c.expected):], "\n") != strings.Join(c.expected, "\n") {
+			t.Errorf("%s -top %d: got\n%s", c.importance_type, c.top, out.String())
+		}
+	}
+
+	for _, args := range [][]string{{"", "", "gain"}, {model, "", "frequency"}, {model, filepath.Join(dir, "absent.fmap"), "gain"}, {filepath.Join(dir, "absent.bin"), "", "gain"}} {
+		err = run(&out, args[0], args[1], args[2], 0)
+		if err == nil {
+			t.Errorf("%v was accepted", args)
+		}
+	}
+}
+
+func TestRunCompact(t *testing.T) {
+	dir := t.TempDir()
+	file, err := os.Open(writeModel(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	p, err := predictor.NewPredictorByReader(*bufio.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = p.WriteCompact(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "model.flat")
+	err = os.WriteFile(path, buf.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = run(&out, path, "", "gain", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := lines(out.String())
+	expected := []string{"trees 2", "nodes 10", "tree details unavailable for compact models"}
+	if got[1] != "format compact" || strings.Join(got[len(got)-len(expected):], "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got\n%s", out.String())
+	}
+}
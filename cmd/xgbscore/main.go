@@ -0,0 +1,448 @@
+// Command xgbscore scores a LibSVM or CSV file with a model and writes one
+// output line per input row, as CSV or JSON lines.
+//
+// Usage:
+//
+//	xgbscore -model model.bin -format libsvm -i test.libsvm -o preds.csv
+//	xgbscore -model model.bin -format csv -header -label 0 -missing -999 \
+//	    -output contributions -out-format jsonl -fmap model.fmap < test.csv
+//
+// The input is read and scored in batches of -batch rows, so it may be
+// larger than memory. With -header and -fmap, CSV columns are matched to
+// features by name; columns unknown to the feature map are ignored.
+// Labels, when the input has them, are written in front of the outputs.
+// Leaf indices and contributions are always computed by tree traversal, so
+// -evaluator applies only to prediction and margin outputs.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/util"
+)
+
+const (
+	OUTPUT_PREDICTION    = "prediction"
+	OUTPUT_MARGIN        = "margin"
+	OUTPUT_LEAF          = "leaf"
+	OUTPUT_CONTRIBUTIONS = "contributions"
+
+	OUT_FORMAT_CSV   = "csv"
+	OUT_FORMAT_JSONL = "jsonl"
+)
+
+type options struct {
+	modelPath   string
+	fmapPath    string
+	inputPath   string
+	outPath     string
+	evaluator   string
+	output      string
+	outFormat   string
+	ntree_limit int
+	batch       int
+	parallelism int
+	text        data.TextOptions
+}
+
+func main() {
+	var opts options
+	var delimiter, missing string
+	var label int
+	flag.StringVar(&opts.modelPath, "model", "", "model file")
+	flag.StringVar(&opts.fmapPath, "fmap", "", "feature map file")
+	flag.StringVar(&opts.inputPath, "i", "-", "input file, - for stdin")
+	flag.StringVar(&opts.outPath, "o", "-", "output file, - for stdout")
+	flag.StringVar(&opts.evaluator, "evaluator", "", "tree evaluator: traversal, quickscorer or quantized")
+	flag.StringVar(&opts.text.Format, "format", data.FORMAT_LIBSVM, "input format: libsvm or csv")
+	flag.BoolVar(&opts.text.Header, "header", false, "the first CSV line is a header")
+	flag.IntVar(&label, "label", -1, "CSV column of the label, counted from 0 (-1 for none)")
+	flag.StringVar(&delimiter, "delimiter", ",", "CSV field delimiter")
+	flag.StringVar(&missing, "missing", "", "value read as missing besides NaN, like XGBoost's missing")
+	flag.StringVar(&opts.output, "output", OUTPUT_PREDICTION, "prediction, margin, leaf or contributions")
+	flag.StringVar(&opts.outFormat, "out-format", OUT_FORMAT_CSV, "output format: csv or jsonl")
+	flag.IntVar(&opts.ntree_limit, "ntree-limit", 0, "number of trees to use (0 for all)")
+	flag.IntVar(&opts.batch, "batch", 4096, "rows per batch")
+	flag.IntVar(&opts.parallelism, "parallelism", 0, "prediction workers (0 for GOMAXPROCS)")
+	flag.Parse()
+
+	if len(delimiter) != 1 {
+		fmt.Fprintln(os.Stderr, "xgbscore: -delimiter must be a single byte")
+		os.Exit(2)
+	}
+	opts.text.Delimiter = delimiter[0]
+	if label >= 0 {
+		opts.text.LabelColumn = &label
+	}
+	if missing != "" {
+		value, err := strconv.ParseFloat(missing, 32)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "xgbscore: invalid -missing:", missing)
+			os.Exit(2)
+		}
+		sentinel := float32(value)
+		opts.text.Missing = &sentinel
+	}
+
+	err := run(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xgbscore:", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts options) error {
+	if opts.modelPath == "" {
+		return fmt.Errorf("-model is required")
+	}
+	switch opts.output {
+	case OUTPUT_PREDICTION, OUTPUT_MARGIN, OUTPUT_LEAF, OUTPUT_CONTRIBUTIONS:
+	default:
+		return fmt.Errorf("unknown -output %q", opts.output)
+	}
+	if (opts.output == OUTPUT_LEAF || opts.output == OUTPUT_CONTRIBUTIONS) && opts.evaluator != "" && opts.evaluator != config.EVALUATOR_TRAVERSAL {
+		return fmt.Errorf("-evaluator %s does not apply to -output %s, which always traverses the trees", opts.evaluator, opts.output)
+	}
+	if opts.outFormat != OUT_FORMAT_CSV && opts.outFormat != OUT_FORMAT_JSONL {
+		return fmt.Errorf("unknown -out-format %q", opts.outFormat)
+	}
+	if opts.batch <= 0 {
+		return fmt.Errorf("-batch must be positive")
+	}
+
+	p, err := load(opts)
+	if err != nil {
+		return err
+	}
+	input := os.Stdin
+	if opts.inputPath != "-" {
+		input, err = os.Open(opts.inputPath)
+		if err != nil {
+			return err
+		}
+		defer input.Close()
+	}
+	output := os.Stdout
+	if opts.outPath != "-" {
+		output, err = os.Create(opts.outPath)
+		if err != nil {
+			return err
+		}
+	}
+	writer := bufio.NewWriter(output)
+
+	err = score(p, opts, input, writer)
+	if flushErr := writer.Flush(); err == nil {
+		err = flushErr
+	}
+	if output != os.Stdout {
+		if closeErr := output.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func load(opts options) (*predictor.Predictor, error) {
+	file, err := os.Open(opts.modelPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	configuration := *config.DEFAULT
+	configuration.Evaluator = opts.evaluator
+	if opts.fmapPath != "" {
+		configuration.FeatureMap, err = util.NewFeatureMapByFile(opts.fmapPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return predictor.NewPredictorByConf(*bufio.NewReader(file), configuration)
+}
+
+func score(p *predictor.Predictor, opts options, input io.Reader, writer *bufio.Writer) error {
+	reader, err := data.NewTextReader(bufio.NewReader(input), opts.text)
+	if err != nil {
+		return err
+	}
+	var columns []int
+	if reader.Header() != nil && p.FeatureMap != nil {
+		columns, err = p.ResolveFeatureNames(reader.Header())
+		if err != nil {
+			return err
+		}
+	}
+
+	row := 0
+	wroteHeader := false
+	for {
+		matrix, labels, err := reader.ReadBatch(opts.batch)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if columns != nil {
+			matrix, err = remapColumns(matrix, columns)
+			if err != nil {
+				return err
+			}
+		}
+		outputs, width, err := predictBatch(p, opts, matrix)
+		if err != nil {
+			return fmt.Errorf("rows %d-%d: %v", row, row+matrix.NumRow()-1, err)
+		}
+		if opts.outFormat == OUT_FORMAT_CSV && !wroteHeader {
+			writeCSVHeader(writer, p, opts.output, labels != nil, width)
+			wroteHeader = true
+		}
+		for i := 0; i < matrix.NumRow(); i++ {
+			values := outputs[i*width : (i+1)*width]
+			if opts.outFormat == OUT_FORMAT_CSV {
+				writeCSVRow(writer, labels, i, values)
+			} else {
+				writeJSONRow(writer, opts.output, row+i, labels, i, values)
+			}
+		}
+		row += matrix.NumRow()
+	}
+}
+
+// remapColumns moves the entries of CSV column i to feature columns[i],
+// dropping columns without a feature.
+func remapColumns(matrix *data.CSRMatrix, columns []int) (*data.CSRMatrix, error) {
+	indptr := make([]int, 1, len(matrix.Indptr))
+	var indices []int
+	var values []float32
+	ncol := 0
+	for rid := 0; rid < matrix.NumRow(); rid++ {
+		rowValues := matrix.RowValues(rid)
+		for k, column := range matrix.RowIndices(rid) {
+			if column >= len(columns) || columns[column] < 0 {
+				continue
+			}
+			fid := columns[column]
+			if fid >= ncol {
+				ncol = fid + 1
+			}
+			indices = append(indices, fid)
+			values = append(values, rowValues[k])
+		}
+		indptr = append(indptr, len(indices))
+	}
+	return data.NewCSRMatrix(indptr, indices, values, ncol)
+}
+
+// predictBatch returns the outputs of all rows of matrix and the number of
+// outputs per row.
+func predictBatch(p *predictor.Predictor, opts options, matrix *data.CSRMatrix) ([]float64, int, error) {
+	nrow := matrix.NumRow()
+	switch opts.output {
+	case OUTPUT_PREDICTION, OUTPUT_MARGIN:
+		output_margin := opts.output == OUTPUT_MARGIN
+		width := p.NumOutput(output_margin)
+		preds := make([]float32, nrow*width)
+		err := p.PredictCSRParallel(context.Background(), matrix, output_margin, preds, predictor.BatchOptions{
+			Parallelism: opts.parallelism,
+			NtreeLimit:  opts.ntree_limit,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return widen(preds), width, nil
+	}
+
+	var width int
+	var predictRow func(row []float32, outputs []float64) error
+	if opts.output == OUTPUT_LEAF {
+		leaves, err := p.PredictLeaf(nil, false, opts.ntree_limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		width = len(leaves)
+		predictRow = func(row []float32, outputs []float64) error {
+			leaves, err := p.PredictLeaf(row, false, opts.ntree_limit)
+			if err != nil {
+				return err
+			}
+			for k, leaf := range leaves {
+				outputs[k] = float64(leaf)
+			}
+			return nil
+		}
+	} else {
+		width = p.NumContributions()
+		predictRow = func(row []float32, outputs []float64) error {
+			contribs, err := p.PredictContributionsWithNtree(row, false, opts.ntree_limit)
+			if err != nil {
+				return err
+			}
+			for k, contrib := range contribs {
+				outputs[k] = float64(contrib)
+			}
+			return nil
+		}
+	}
+	outputs := make([]float64, nrow*width)
+	err := predictRows(matrix, opts.parallelism, func(rid int, row []float32) error {
+		return predictRow(row, outputs[rid*width:(rid+1)*width])
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return outputs, width, nil
+}
+
+// predictRows calls predictRow for every row of matrix as a dense row with
+// NaN for absent values. The rows are split between parallelism workers,
+// GOMAXPROCS when it is 0, each with its own row buffer.
+func predictRows(matrix *data.CSRMatrix, parallelism int, predictRow func(rid int, row []float32) error) error {
+	nrow := matrix.NumRow()
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	chunkSize := (nrow + parallelism - 1) / parallelism
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for begin := 0; begin < nrow; begin += chunkSize {
+		end := begin + chunkSize
+		if end > nrow {
+			end = nrow
+		}
+		wg.Add(1)
+		go func(begin, end int) {
+			defer wg.Done()
+			row := make([]float32, matrix.NumCol)
+			for i := range row {
+				row[i] = float32(math.NaN())
+			}
+			for rid := begin; rid < end; rid++ {
+				indices := matrix.RowIndices(rid)
+				for k, value := range matrix.RowValues(rid) {
+					row[indices[k]] = value
+				}
+				err := predictRow(rid, row)
+				for _, fid := range indices {
+					row[fid] = float32(math.NaN())
+				}
+				if err != nil {
+					once.Do(func() { firstErr = err })
+					return
+				}
+			}
+		}(begin, end)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func widen(values []float32) []float64 {
+	result := make([]float64, len(values))
+	for i, value := range values {
+		result[i] = float64(value)
+	}
+	return result
+}
+
+func writeCSVHeader(writer *bufio.Writer, p *predictor.Predictor, output string, hasLabel bool, width int) {
+	var names []string
+	if hasLabel {
+		names = append(names, "label")
+	}
+	switch output {
+	case OUTPUT_PREDICTION, OUTPUT_MARGIN:
+		for k := 0; k < width; k++ {
+			if width == 1 {
+				names = append(names, output)
+			} else {
+				names = append(names, fmt.Sprintf("%s_%d", output, k))
+			}
+		}
+	case OUTPUT_LEAF:
+		for tid := 0; tid < width; tid++ {
+			names = append(names, fmt.Sprintf("tree_%d", tid))
+		}
+	case OUTPUT_CONTRIBUTIONS:
+		groups := p.Gbm.NumOutputGroup()
+		per_group := width / groups
+		for gid := 0; gid < groups; gid++ {
+			for fid := 0; fid < per_group; fid++ {
+				name := "bias"
+				if fid < per_group-1 {
+					name = fmt.Sprintf("f%d", fid)
+					if p.FeatureMap != nil && fid < p.FeatureMap.NumFeature() {
+						name = p.FeatureMap.Name(fid)
+					}
+				}
+				if groups > 1 {
+					name = fmt.Sprintf("%s_%d", name, gid)
+				}
+				names = append(names, name)
+			}
+		}
+	}
+	for i, name := range names {
+		if i > 0 {
+			writer.WriteByte(',')
+		}
+		writer.WriteString(name)
+	}
+	writer.WriteByte('\n')
+}
+
+func writeCSVRow(writer *bufio.Writer, labels []float32, rid int, values []float64) {
+	if labels != nil {
+		writer.WriteString(strconv.FormatFloat(float64(labels[rid]), 'g', -1, 32))
+		if len(values) > 0 {
+			writer.WriteByte(',')
+		}
+	}
+	for i, value := range values {
+		if i > 0 {
+			writer.WriteByte(',')
+		}
+		writer.WriteString(strconv.FormatFloat(value, 'g', -1, 32))
+	}
+	writer.WriteByte('\n')
+}
+
+func writeJSONRow(writer *bufio.Writer, output string, row int, labels []float32, rid int, values []float64) {
+	fmt.Fprintf(writer, `{"row":%d`, row)
+	if labels != nil {
+		writer.WriteString(`,"label":`)
+		writeJSONNumber(writer, float64(labels[rid]))
+	}
+	key, _ := json.Marshal(output)
+	fmt.Fprintf(writer, ",%s:[", key)
+	for i, value := range values {
+		if i > 0 {
+			writer.WriteByte(',')
+		}
+		writeJSONNumber(writer, value)
+	}
+	writer.WriteString("]}\n")
+}
+
+// writeJSONNumber writes NaN and infinities, which JSON lacks, as null.
+func writeJSONNumber(writer *bufio.Writer, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		writer.WriteString("null")
+		return
+	}
+	writer.WriteString(strconv.FormatFloat(value, 'g', -1, 32))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/predictor"
+)
+
+const testInput = `1 0:0.5 2:-1 5:3
+0 1:2 3:0.25
+1
+0 0:-3 1:1 2:4 3:-0.5 4:2 5:-2
+1 4:1.5
+0 5:0.75 0:1
+`
+
+func loadTestModel(t *testing.T) *predictor.Predictor {
+	t.Helper()
+	file, err := os.Open("../../testdata/logistic.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	p, err := predictor.NewPredictorByConf(*bufio.NewReader(file), *config.DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func readTestInput(t *testing.T) *data.CSRMatrix {
+	t.Helper()
+	reader, err := data.NewTextReader(strings.NewReader(testInput), data.TextOptions{Format: data.FORMAT_LIBSVM})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matrix, _, err := reader.ReadBatch(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matrix
+}
+
+// TestPredictBatchRows checks the leaf and contribution outputs against
+// the map predictions of every row, for several numbers of workers.
+func TestPredictBatchRows(t *testing.T) {
+	p := loadTestModel(t)
+	matrix := readTestInput(t)
+	for _, parallelism := range []int{1, 3, 16} {
+		for _, output := range []string{OUTPUT_LEAF, OUTPUT_CONTRIBUTIONS} {
+			opts := options{output: output, parallelism: parallelism, ntree_limit: 2}
+			outputs, width, err := predictBatch(p, opts, matrix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(outputs) != matrix.NumRow()*width {
+				t.Fatalf("%s: %d outputs for %d rows of width %d", output, len(outputs), matrix.NumRow(), width)
+			}
+			for rid := 0; rid < matrix.NumRow(); rid++ {
+				values := make(map[int]float32)
+				for k, fid := range matrix.RowIndices(rid) {
+					values[fid] = matrix.RowValues(rid)[k]
+				}
+				var expected []float64
+				if output == OUTPUT_LEAF {
+					leaves, err := p.PredictMapLeaf(values, opts.ntree_limit)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, leaf := range leaves {
+						expected = append(expected, float64(leaf))
+					}
+				} else {
+					contribs, err := p.PredictMapContributionsWithNtree(values, opts.ntree_limit)
+					if err != nil {
+						t.Fatal(err)
+					}
+					expected = widen(contribs)
+				}
+				if len(expected) != width {
+					t.Fatalf("%s row %d: width %d, want %d", output, rid, width, len(expected))
+				}
+				for k := range expected {
+					if outputs[rid*width+k] != expected[k] {
+						t.Errorf("%s parallelism %d row %d: %v, want %v", output, parallelism, rid, outputs[rid*width:(rid+1)*width], expected)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRunRejectsEvaluatorForLeaves(t *testing.T) {
+	opts := options{
+		modelPath: "../../testdata/logistic.bin",
+		output:    OUTPUT_LEAF,
+		outFormat: OUT_FORMAT_CSV,
+		batch:     10,
+		evaluator: config.EVALUATOR_QUICKSCORER,
+	}
+	err := run(opts)
+	if err == nil || !strings.Contains(err.Error(), "-evaluator") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+// Endpoints:
+//
+//	GET  /healthz                                     liveness
+//	GET  /readyz                                      200 once all models are loaded, 503 before
+//	GET  /v1/models                                   every loaded model version
+//	GET  /v1/models/{name}[/versions/{version}]       metadata of one model
+//	POST /v1/models/{name}[/versions/{version}]/predict
+//
+// The version may be an alias; without one the default version is used.
+// A predict request gives its rows in exactly one of dense, sparse or
+// named; a single prediction is a request with one row.
+//
+//	{
+//	  "dense":  [[1.5, null, 3]],          null is missing
+//	  "sparse": [{"0": 1.5, "2": 3}],      feature index -> value
+//	  "named":  [{"age": 31, "income": 2}] feature name -> value, needs -fmap
+//	  "output": "prediction",              prediction, margin, leaf or contributions
+//	  "treat_zero_as_missing": false,      dense rows only
+//	  "ntree_limit": 0
+//	}
+//
+// The response has one entry per row: "predictions" for prediction,
+// margin and contributions outputs, "leaves" for leaf indices.
+//
+//	{"model": "ctr", "version": "2", "output": "prediction", "predictions": [[0.73]]}
+//
+// Contributions give num_feature values and then the bias for every output
+// group. Errors are returned as {"error": "..."} with a 4xx or 5xx status.
+//
+// The V2 inference protocol is served as well, see v2.go.
+
+const (
+	OUTPUT_PREDICTION    = "prediction"
+	OUTPUT_MARGIN        = "margin"
+	OUTPUT_LEAF          = "leaf"
+	OUTPUT_CONTRIBUTIONS = "contributions"
+)
+
+type PredictRequest struct {
+	Dense              [][]*float32         `json:"dense,omitempty"`
+	Sparse             []map[string]float32 `json:"sparse,omitempty"`
+	Named              []map[string]float32 `json:"named,omitempty"`
+	Output             string               `json:"output,omitempty"`
+	TreatZeroAsMissing bool                 `json:"treat_zero_as_missing,omitempty"`
+	NtreeLimit         int                  `json:"ntree_limit,omitempty"`
+}
+
+type PredictResponse struct {
+	Model       string      `json:"model"`
+	Version     string      `json:"version"`
+	Output      string      `json:"output"`
+	Predictions [][]float32 `json:"predictions,omitempty"`
+	Leaves      [][]int     `json:"leaves,omitempty"`
+}
+
+type ModelMetadata struct {
+	Name           string   `json:"name"`
+	Version        string   `json:"version"`
+	Aliases        []string `json:"aliases,omitempty"`
+	Source         string   `json:"source"`
+	LoadedAt       string   `json:"loaded_at"`
+	SizeBytes      int      `json:"size_bytes"`
+	Objective      string   `json:"objective"`
+	Booster        string   `json:"booster"`
+	NumFeature     int      `json:"num_feature"`
+	NumClass       int      `json:"num_class"`
+	NumOutputGroup int      `json:"num_output_group"`
+	NumTrees       int      `json:"num_trees,omitempty"`
+	BaseScore      float32  `json:"base_score"`
+	FeatureNames   []string `json:"feature_names,omitempty"`
+}
+
+type ModelList struct {
+	Models []ModelMetadata `json:"models"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,92 @@
+// Command xgbserve serves predictions of one or more models over HTTP.
+//
+// Usage:
+//
+//	xgbserve -addr :8080 -model ctr=ctr.bin -model ctr:2=ctr2.bin -fmap ctr=ctr.fmap
+//
+// Each -model flag loads a file as name or name:version (version "1" when
+// omitted); -fmap attaches a feature map, which enables named features.
+// The server listens before the models are loaded, so /healthz answers at
+// once and /readyz only when every model is ready. See api.go for the
+// endpoints and the JSON schemas.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/serving"
+)
+
+func main() {
+	var models, fmaps serving.ListFlag
+	addr := flag.String("addr", ":8080", "listen address")
+	evaluator := flag.String("evaluator", "", "tree evaluator: traversal, quickscorer or quantized")
+	maxBody := flag.Int64("max-body", 32<<20, "maximum request body size in bytes")
+	maxRows := flag.Int("max-rows", 100000, "maximum rows per request")
+	flag.Var(&models, "model", "model to serve, as name[:version]=path (repeatable)")
+	flag.Var(&fmaps, "fmap", "feature map of a model, as name=path (repeatable)")
+	flag.Parse()
+
+	var specs []serving.ModelSpec
+	for _, value := range models {
+		spec, err := serving.ParseModelSpec(value)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "xgbserve:", err)
+			os.Exit(2)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		fmt.Fprintln(os.Stderr, "xgbserve: at least one -model is required")
+		os.Exit(2)
+	}
+	featureMaps, err := serving.LoadFeatureMaps(fmaps)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xgbserve:", err)
+		os.Exit(2)
+	}
+
+	registry := serving.NewRegistry(serving.RegistryOptions{
+		Configuration: config.Configuration{Evaluator: *evaluator},
+	})
+	server := newServer(registry, *maxBody, *maxRows)
+	httpServer := &http.Server{Addr: *addr, Handler: server}
+
+	go func() {
+		for _, spec := range specs {
+			model, err := registry.LoadFile(spec.Name, spec.Version, spec.Path)
+			if err != nil {
+				log.Fatalf("xgbserve: loading %s: %v", spec.Path, err)
+			}
+			// The model is not served until ready is set, so the feature
+			// map can still be attached.
+			model.Predictor.FeatureMap = featureMaps[spec.Name]
+			log.Printf("xgbserve: loaded %s:%s from %s", spec.Name, spec.Version, spec.Path)
+		}
+		server.setReady()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+	}()
+
+	log.Printf("xgbserve: listening on %s", *addr)
+	err = httpServer.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		log.Fatalf("xgbserve: %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/serving"
+)
+
+type server struct {
+	registry *serving.Registry
+	maxBody  int64
+	maxRows  int
+	ready    int32
+}
+
+type httpError struct {
+	status  int
+	message string
+}
+
+func (err *httpError) Error() string {
+	return err.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+}
+
+func newServer(registry *serving.Registry, maxBody int64, maxRows int) *server {
+	return &server{registry: registry, maxBody: maxBody, maxRows: maxRows}
+}
+
+func (s *server) setReady() {
+	atomic.StoreInt32(&s.ready, 1)
+}
+
+func (s *server) isReady() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "healthz":
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	case path == "readyz":
+		if s.isReady() {
+			writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+		} else {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "loading"})
+		}
+	case path == "v1/models" || strings.HasPrefix(path, "v1/models/"):
+		if !s.isReady() {
+			writeError(w, &httpError{http.StatusServiceUnavailable, "Models are still loading."})
+			return
+		}
+		err := s.serveModels(w, r, strings.Split(path, "/")[2:])
+		if err != nil {
+			writeError(w, err)
+		}
+	case path == "v2" || strings.HasPrefix(path, "v2/"):
+		err := s.serveV2(w, r, strings.Split(path, "/")[1:])
+		if err != nil {
+			writeError(w, err)
+		}
+	default:
+		writeError(w, &httpError{http.StatusNotFound, "Not found."})
+	}
+}
+
+// serveModels handles the paths below v1/models, given as their segments.
+func (s *server) serveModels(w http.ResponseWriter, r *http.Request, segments []string) error {
+	if len(segments) == 0 {
+		if r.Method != http.MethodGet {
+			return &httpError{http.StatusMethodNotAllowed, "Method not allowed."}
+		}
+		list := ModelList{Models: []ModelMetadata{}}
+		for _, info := range s.registry.List() {
+			list.Models = append(list.Models, metadata(info))
+		}
+		writeJSON(w, http.StatusOK, list)
+		return nil
+	}
+
+	name, version := segments[0], ""
+	rest := segments[1:]
+	if len(rest) >= 2 && rest[0] == "versions" {
+		version = rest[1]
+		rest = rest[2:]
+	}
+	model, err := s.registry.Get(name, version)
+	if err != nil {
+		return &httpError{http.StatusNotFound, err.Error()}
+	}
+
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		for _, info := range s.registry.List() {
+			if info.Model == model {
+				writeJSON(w, http.StatusOK, metadata(info))
+				return nil
+			}
+		}
+		return &httpError{http.StatusNotFound, "Model was unloaded."}
+	case len(rest) == 1 && rest[0] == "predict" && r.Method == http.MethodPost:
+		var request PredictRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBody))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&request)
+		if err != nil {
+			return badRequest("Invalid request: %v", err)
+		}
+		response, err := s.predict(model, name, &request)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, response)
+		return nil
+	case len(rest) == 0 || (len(rest) == 1 && rest[0] == "predict"):
+		return &httpError{http.StatusMethodNotAllowed, "Method not allowed."}
+	}
+	return &httpError{http.StatusNotFound, "Not found."}
+}
+
+func metadata(info serving.ModelInfo) ModelMetadata {
+	p := info.Model.Predictor
+	meta := ModelMetadata{
+		Name:           info.Name,
+		Version:        info.Version,
+		Aliases:        info.Aliases,
+		Source:         info.Source,
+		LoadedAt:       info.Model.LoadedAt.UTC().Format(time.RFC3339),
+		SizeBytes:      info.SizeBytes,
+		Objective:      p.Name_obj,
+		Booster:        p.Name_gbm,
+		NumFeature:     p.Mparam.NumFeature(),
+		NumClass:       p.Mparam.NumClass(),
+		NumOutputGroup: p.Gbm.NumOutputGroup(),
+		BaseScore:      p.Mparam.BaseScore(),
+	}
+	if gbTree, ok := p.Gbm.(*gbm.GBTree); ok {
+		meta.NumTrees = gbTree.Forest().NumTrees()
+	}
+	if p.FeatureMap != nil {
+		for fid := 0; fid < p.FeatureMap.NumFeature(); fid++ {
+			meta.FeatureNames = append(meta.FeatureNames, p.FeatureMap.Name(fid))
+		}
+	}
+	return meta
+}
+
+func (s *server) predict(model *serving.Model, name string, request *PredictRequest) (*PredictResponse, error) {
+	p := model.Predictor
+	if request.Output == "" {
+		request.Output = OUTPUT_PREDICTION
+	}
+	switch request.Output {
+	case OUTPUT_PREDICTION, OUTPUT_MARGIN, OUTPUT_LEAF, OUTPUT_CONTRIBUTIONS:
+	default:
+		return nil, badRequest("Unknown output %q.", request.Output)
+	}
+	if request.NtreeLimit < 0 {
+		return nil, badRequest("ntree_limit must not be negative.")
+	}
+	inputs := 0
+	nrow := 0
+	for _, rows := range []int{len(request.Dense), len(request.Sparse), len(request.Named)} {
+		if rows != 0 {
+			inputs++
+			nrow = rows
+		}
+	}
+	if inputs != 1 {
+		return nil, badRequest("Exactly one of dense, sparse or named rows must be given.")
+	}
+	if nrow > s.maxRows {
+		return nil, badRequest("Too many rows: %d, the limit is %d.", nrow, s.maxRows)
+	}
+
+	response := &PredictResponse{Model: name, Version: model.Version, Output: request.Output}
+	if len(request.Dense) > 0 {
+		return response, s.predictDense(p, request, response)
+	}
+	rows := make([]map[int]float32, nrow)
+	for i := range rows {
+		var err error
+		if len(request.Sparse) > 0 {
+			rows[i], err = sparseRow(request.Sparse[i])
+		} else {
+			rows[i], err = p.NamedToMap(request.Named[i])
+		}
+		if err != nil {
+			return nil, badRequest("Row %d: %v", i, err)
+		}
+	}
+	for _, row := range rows {
+		err := predictMapRow(p, row, request, response)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+func sparseRow(values map[string]float32) (map[int]float32, error) {
+	row := make(map[int]float32, len(values))
+	for key, value := range values {
+		fid, err := strconv.Atoi(key)
+		if err != nil || fid < 0 {
+			return nil, fmt.Errorf("Invalid feature index %q.", key)
+		}
+		row[fid] = value
+	}
+	return row, nil
+}
+
+func (s *server) predictDense(p *predictor.Predictor, request *PredictRequest, response *PredictResponse) error {
+	ncol := 0
+	for _, row := range request.Dense {
+		if len(row) > ncol {
+			ncol = len(row)
+		}
+	}
+	nrow := len(request.Dense)
+	values := make([]float32, nrow*ncol)
+	for i, row := range request.Dense {
+		for j := 0; j < ncol; j++ {
+			if j < len(row) && row[j] != nil {
+				values[i*ncol+j] = *row[j]
+			} else {
+				values[i*ncol+j] = float32(math.NaN())
+			}
+		}
+	}
+
+	switch request.Output {
+	case OUTPUT_PREDICTION, OUTPUT_MARGIN:
+		missing := p.Missing
+		if request.TreatZeroAsMissing {
+			missing = 0
+		}
+		matrix, err := data.NewDenseMatrix(values, nrow, ncol, missing)
+		if err != nil {
+			return badRequest("%v", err)
+		}
+		output_margin := request.Output == OUTPUT_MARGIN
+		num_output := p.NumOutput(output_margin)
+		preds := make([]float32, nrow*num_output)
+		err = p.PredictDenseWithNtree(matrix, output_margin, request.NtreeLimit, preds)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < nrow; i++ {
+			response.Predictions = append(response.Predictions, preds[i*num_output:(i+1)*num_output])
+		}
+	case OUTPUT_LEAF:
+		for i := 0; i < nrow; i++ {
+			leaves, err := p.PredictLeaf(values[i*ncol:(i+1)*ncol], request.TreatZeroAsMissing, request.NtreeLimit)
+			if err != nil {
+				return badRequest("%v", err)
+			}
+			response.Leaves = append(response.Leaves, leaves)
+		}
+	case OUTPUT_CONTRIBUTIONS:
+		for i := 0; i < nrow; i++ {
+			contribs, err := p.PredictContributionsWithNtree(values[i*ncol:(i+1)*ncol], request.TreatZeroAsMissing, request.NtreeLimit)
+			if err != nil {
+				return badRequest("%v", err)
+			}
+			response.Predictions = append(response.Predictions, contribs)
+		}
+	}
+	return nil
+}
+
+func predictMapRow(p *predictor.Predictor, row map[int]float32, request *PredictRequest, response *PredictResponse) error {
+	switch request.Output {
+	case OUTPUT_PREDICTION, OUTPUT_MARGIN:
+		preds := p.PredictMapWithNtree(row, request.Output == OUTPUT_MARGIN, request.NtreeLimit)
+		response.Predictions = append(response.Predictions, preds)
+	case OUTPUT_LEAF:
+		leaves, err := p.PredictMapLeaf(row, request.NtreeLimit)
+		if err != nil {
+			return badRequest("%v", err)
+		}
+		response.Leaves = append(response.Leaves, leaves)
+	case OUTPUT_CONTRIBUTIONS:
+		contribs, err := p.PredictMapContributionsWithNtree(row, request.NtreeLimit)
+		if err != nil {
+			return badRequest("%v", err)
+		}
+		response.Predictions = append(response.Predictions, contribs)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		// JSON has no NaN or infinity, which a model may still produce.
+		status = http.StatusInternalServerError
+		encoded, _ = json.Marshal(ErrorResponse{Error: fmt.Sprintf("Cannot encode response: %v", err)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(encoded, '\n'))
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if httpErr, ok := err.(*httpError); ok {
+		status = httpErr.status
+	}
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
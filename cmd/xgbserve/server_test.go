@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/internal/testmodel"
+	"xgboost4go-predictor/serving"
+	"xgboost4go-predictor/util"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *serving.Model) {
+	t.Helper()
+	featureMap, err := util.NewFeatureMapByReader(strings.NewReader("0 a q\n1 b q\n2 c q\n3 d q\n4 e q\n5 f q\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configuration := *config.DEFAULT
+	configuration.FeatureMap = featureMap
+	registry := serving.NewRegistry(serving.RegistryOptions{Configuration: configuration})
+	model, err := registry.LoadFile("logistic", "1", "../../testdata/logistic.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newServer(registry, 1<<20, 100)
+	s.setReady()
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return ts, model
+}
+
+func postPredict(t *testing.T, ts *httptest.Server, body string) (int, PredictResponse) {
+	t.Helper()
+	response, err := http.Post(ts.URL+"/v1/models/logistic/predict", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var predictResponse PredictResponse
+	if response.StatusCode == http.StatusOK {
+		err = json.NewDecoder(response.Body).Decode(&predictResponse)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return response.StatusCode, predictResponse
+}
+
+func TestPredictInputs(t *testing.T) {
+	ts, model := newTestServer(t)
+	expected := model.Predictor.PredictMap(map[int]float32{0: 1})[0]
+
+	cases := []struct {
+		body   string
+		status int
+	}{
+		{`{"dense":[[1]]}`, http.StatusOK},
+		{`{"sparse":[{"0":1}]}`, http.StatusOK},
+		{`{"named":[{"a":1}]}`, http.StatusOK},
+		{`{"sparse":[],"named":[{"a":1}]}`, http.StatusOK},
+		{`{"dense":[],"sparse":[{"0":1}]}`, http.StatusOK},
+		{`{"named":[],"dense":[[1]]}`, http.StatusOK},
+		{`{"dense":[[1]],"sparse":[{"0":1}]}`, http.StatusBadRequest},
+		{`{"dense":[],"sparse":[]}`, http.StatusBadRequest},
+		{`{}`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		status, response := postPredict(t, ts, c.body)
+		if status != c.status {
+			t.Errorf("%s: status %d, want %d", c.body, status, c.status)
+			continue
+		}
+		if status != http.StatusOK {
+			continue
+		}
+		if len(response.Predictions) != 1 || len(response.Predictions[0]) != 1 {
+			t.Errorf("%s: predictions %v, want one row", c.body, response.Predictions)
+			continue
+		}
+		// Missing dense features are NaN, which the model treats like
+		// absent sparse ones.
+		if got := response.Predictions[0][0]; got != expected {
+			t.Errorf("%s: prediction %v, want %v", c.body, got, expected)
+		}
+	}
+}
+
+// newEndpointServer serves logistic.bin as versions 1 and 2 of "logistic",
+// with the alias "stable" for version 1, and a model that predicts NaN as
+// "nan".
+func newEndpointServer(t *testing.T, maxBody int64, maxRows int) (*server, *httptest.Server) {
+	t.Helper()
+	registry := serving.NewRegistry(serving.RegistryOptions{Configuration: *config.DEFAULT})
+	for _, version := range []string{"1", "2"} {
+		_, err := registry.LoadFile("logistic", version, "../../testdata/logistic.bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := registry.SetAlias("logistic", "stable", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nan := testmodel.Encode(testmodel.Spec{
+		NumFeature: 1,
+		Objective:  "reg:linear",
+		Trees:      []testmodel.Tree{{Nodes: []testmodel.Node{{Parent: -1, Left: -1, Right: -1, Value: float32(math.NaN())}}}},
+	})
+	_, err = registry.LoadReader("nan", "1", bytes.NewReader(nan), "nan.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newServer(registry, maxBody, maxRows)
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func TestEndpoints(t *testing.T) {
+	s, ts := newEndpointServer(t, 1024, 2)
+	row := `[0.5,-1,2,0,3,-0.25]`
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		// contains lists substrings of the response body.
+		contains []string
+	}{
+		{"healthz", "GET", "/healthz", "", 200, []string{`"status":"ok"`}},
+		{"readyz", "GET", "/readyz", "", 200, []string{`"status":"ready"`}},
+		{"list", "GET", "/v1/models", "", 200, []string{`"name":"logistic","version":"1","aliases":["stable"]`, `"name":"logistic","version":"2"`, `"name":"nan"`}},
+		{"list method", "POST", "/v1/models", "{}", 405, nil},
+		{"metadata", "GET", "/v1/models/logistic", "", 200, []string{`"version":"2"`, `"objective":"binary:logistic"`, `"booster":"gbtree"`, `"num_feature":`, `"num_trees":`}},
+		{"version metadata", "GET", "/v1/models/logistic/versions/1", "", 200, []string{`"version":"1"`, `"aliases":["stable"]`}},
+		{"alias metadata", "GET", "/v1/models/logistic/versions/stable", "", 200, []string{`"version":"1"`}},
+		{"unknown model", "GET", "/v1/models/absent", "", 404, []string{`"error":"Model absent is not loaded."`}},
+		{"unknown version", "GET", "/v1/models/logistic/versions/9", "", 404, nil},
+		{"unknown path", "GET", "/v1/models/logistic/explain", "", 404, nil},
+		{"unknown endpoint", "GET", "/metrics", "", 404, nil},
+		{"predict method", "GET", "/v1/models/logistic/predict", "", 405, nil},
+		{"predict default", "POST", "/v1/models/logistic/predict", `{"dense":[` + row + `]}`, 200, []string{`"version":"2"`, `"output":"prediction"`}},
+		{"predict version", "POST", "/v1/models/logistic/versions/1/predict", `{"dense":[` + row + `]}`, 200, []string{`"version":"1"`}},
+		{"predict alias", "POST", "/v1/models/logistic/versions/stable/predict", `{"sparse":[{"0":0.5}]}`, 200, []string{`"version":"1"`}},
+		{"leaf", "POST", "/v1/models/logistic/predict", `{"dense":[` + row + `],"output":"leaf"}`, 200, []string{`"output":"leaf","leaves":[[`}},
+		{"sparse leaf", "POST", "/v1/models/logistic/predict", `{"sparse":[{"1":2}],"output":"leaf","ntree_limit":2}`, 200, []string{`"leaves":[[`}},
+		{"contributions", "POST", "/v1/models/logistic/predict", `{"dense":[` + row + `],"output":"contributions"}`, 200, []string{`"output":"contributions","predictions":[[`}},
+		{"unknown output", "POST", "/v1/models/logistic/predict", `{"dense":[` + row + `],"output":"shap"}`, 400, []string{`Unknown output`}},
+		{"negative ntree_limit", "POST", "/v1/models/logistic/predict", `{"dense":[` + row + `],"ntree_limit":-1}`, 400, nil},
+		{"unknown field", "POST", "/v1/models/logistic/predict", `{"rows":[` + row + `]}`, 400, []string{`unknown field`}},
+		{"bad sparse index", "POST", "/v1/models/logistic/predict", `{"sparse":[{"x":1}]}`, 400, []string{`Invalid feature index`}},
+		{"named without fmap", "POST", "/v1/models/logistic/predict", `{"named":[{"a":1}]}`, 400, []string{`Feature map is not set.`}},
+		{"max rows", "POST", "/v1/models/logistic/predict", `{"dense":[` + row + `,` + row + `,` + row + `]}`, 400, []string{`Too many rows: 3, the limit is 2.`}},
+		{"max body", "POST", "/v1/models/logistic/predict", `{"dense":[[` + strings.Repeat("0,", 600) + `0]]}`, 400, []string{`request body too large`}},
+		{"NaN prediction", "POST", "/v1/models/nan/predict", `{"dense":[[1]]}`, 500, []string{`Cannot encode response`}},
+	}
+	s.setReady()
+	for _, c := range cases {
+		status, body := request(t, ts, c.method, c.path, c.body)
+		if status != c.status {
+			t.Errorf("%s: status %d, want %d: %s", c.name, status, c.status, body)
+			continue
+		}
+		for _, substring := range c.contains {
+			if !strings.Contains(body, substring) {
+				t.Errorf("%s: response lacks %s: %s", c.name, substring, body)
+			}
+		}
+	}
+}
+
+func TestNotReady(t *testing.T) {
+	_, ts := newEndpointServer(t, 1024, 2)
+	cases := []struct {
+		path   string
+		status int
+	}{
+		{"/healthz", 200},
+		{"/readyz", 503},
+		{"/v1/models", 503},
+		{"/v1/models/logistic", 503},
+	}
+	for _, c := range cases {
+		status, body := request(t, ts, "GET", c.path, "")
+		if status != c.status {
+			t.Errorf("%s: status %d, want %d: %s", c.path, status, c.status, body)
+		}
+	}
+}
+
+func request(t *testing.T, ts *httptest.Server, method, path, body string) (int, string) {
+	t.Helper()
+	r, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := response.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%s %s: Content-Type %q", method, path, ct)
+	}
+	return response.StatusCode, string(buf)
+}
+
+// TestPredictOutputs checks the leaf and contributions outputs against the
+// predictor, for dense and sparse rows.
+func TestPredictOutputs(t *testing.T) {
+	ts, model := newTestServer(t)
+	p := model.Predictor
+	row := []float32{0.5, -1, 2, 0, 3, -0.25}
+	sparse := map[int]float32{0: 0.5, 1: -1, 2: 2, 3: 0, 4: 3, 5: -0.25}
+	leaves, err := p.PredictLeaf(row, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contribs, err := p.PredictContributions(row, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapContribs, _ := p.PredictMapContributions(sparse); len(mapContribs) != len(contribs) {
+		t.Fatalf("map contributions %v, array contributions %v", mapContribs, contribs)
+	}
+
+	for _, input := range []string{`"dense":[[0.5,-1,2,0,3,-0.25]]`, `"sparse":[{"0":0.5,"1":-1,"2":2,"3":0,"4":3,"5":-0.25}]`} {
+		status, response := postPredict(t, ts, `{`+input+`,"output":"leaf"}`)
+		if status != http.StatusOK || len(response.Leaves) != 1 || len(response.Leaves[0]) != len(leaves) {
+			t.Fatalf("%s leaf: status %d, %v", input, status, response)
+		}
+		for k := range leaves {
+			if response.Leaves[0][k] != leaves[k] {
+				t.Errorf("%s leaf: %v, want %v", input, response.Leaves[0], leaves)
+				break
+			}
+		}
+
+		status, response = postPredict(t, ts, `{`+input+`,"output":"contributions"}`)
+		if status != http.StatusOK || len(response.Predictions) != 1 || len(response.Predictions[0]) != len(contribs) {
+			t.Fatalf("%s contributions: status %d, %v", input, status, response)
+		}
+		for k := range contribs {
+			if response.Predictions[0][k] != contribs[k] {
+				t.Errorf("%s contributions: %v, want %v", input, response.Predictions[0], contribs)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+// The Open Inference Protocol (V2, as used by KServe and Triton) over REST:
+//
+//	GET  /v2                                          server metadata
+//	GET  /v2/health/live                              liveness
+//	GET  /v2/health/ready                             200 once all models are loaded, 503 before
+//	GET  /v2/models/{name}[/versions/{version}]       model metadata
+//	GET  /v2/models/{name}[/versions/{version}]/ready model readiness
+//	POST /v2/models/{name}[/versions/{version}]/infer
+//
+// Models look like a Triton FIL deployment: one FP32 input "input__0" of
+// shape [-1, num_feature] and one FP32 output "output__0", of shape [-1]
+// for a single output group and [-1, groups] otherwise. "margin", "leaf"
+// (INT32) and "contributions" can be requested as further outputs.
+//
+//	{
+//	  "id": "42",
+//	  "parameters": {"ntree_limit": 0, "treat_zero_as_missing": false},
+//	  "inputs": [{"name": "input__0", "shape": [2, 3], "datatype": "FP32",
+//	              "data": [1.5, null, 3, 0.5, 2, 1]}],
+//	  "outputs": [{"name": "output__0"}]
+//	}
+//
+// data is row-major and may be flat or nested; null is missing. Any
+// numeric datatype is accepted and converted to FP32. Request parameters
+// other than ntree_limit and treat_zero_as_missing, such as sequence_id,
+// priority or timeout, are accepted and ignored. The binary data extension
+// is not supported.
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/serving"
+)
+
+const (
+	V2_INPUT  = "input__0"
+	V2_OUTPUT = "output__0"
+)
+
+type V2ServerMetadata struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	Extensions []string `json:"extensions"`
+}
+
+type V2TensorMetadata struct {
+	Name     string `json:"name"`
+	Datatype string `json:"datatype"`
+	Shape    []int  `json:"shape"`
+}
+
+type V2ModelMetadata struct {
+	Name     string             `json:"name"`
+	Versions []string           `json:"versions,omitempty"`
+	Platform string             `json:"platform"`
+	Inputs   []V2TensorMetadata `json:"inputs"`
+	Outputs  []V2TensorMetadata `json:"outputs"`
+}
+
+type V2ModelReady struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+}
+
+// V2InferParameters are the request parameters used for prediction.
+type V2InferParameters struct {
+	NtreeLimit         int
+	TreatZeroAsMissing bool
+}
+
+type V2InferInput struct {
+	Name       string                 `json:"name"`
+	Shape      []int                  `json:"shape"`
+	Datatype   string                 `json:"datatype"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Data       json.RawMessage        `json:"data"`
+}
+
+type V2InferRequestedOutput struct {
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type V2InferRequest struct {
+	ID         string                   `json:"id,omitempty"`
+	Parameters map[string]interface{}   `json:"parameters,omitempty"`
+	Inputs     []V2InferInput           `json:"inputs"`
+	Outputs    []V2InferRequestedOutput `json:"outputs,omitempty"`
+}
+
+type V2InferOutput struct {
+	Name     string      `json:"name"`
+	Shape    []int       `json:"shape"`
+	Datatype string      `json:"datatype"`
+	Data     interface{} `json:"data"`
+}
+
+type V2InferResponse struct {
+	ModelName    string          `json:"model_name"`
+	ModelVersion string          `json:"model_version"`
+	ID           string          `json:"id,omitempty"`
+	Outputs      []V2InferOutput `json:"outputs"`
+}
+
+// serveV2 handles the paths below v2, given as their segments.
+func (s *server) serveV2(w http.ResponseWriter, r *http.Request, segments []string) error {
+	if len(segments) == 0 {
+		if r.Method != http.MethodGet {
+			return &httpError{http.StatusMethodNotAllowed, "Method not allowed."}
+		}
+		writeJSON(w, http.StatusOK, V2ServerMetadata{Name: "xgbserve", Version: "1", Extensions: []string{}})
+		return nil
+	}
+	if segments[0] == "health" && len(segments) == 2 {
+		if r.Method != http.MethodGet {
+			return &httpError{http.StatusMethodNotAllowed, "Method not allowed."}
+		}
+		switch segments[1] {
+		case "live":
+			w.WriteHeader(http.StatusOK)
+			return nil
+		case "ready":
+			if s.isReady() {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return nil
+		}
+	}
+	if segments[0] != "models" || len(segments) < 2 {
+		return &httpError{http.StatusNotFound, "Not found."}
+	}
+
+	name, version := segments[1], ""
+	rest := segments[2:]
+	if len(rest) >= 2 && rest[0] == "versions" {
+		version = rest[1]
+		rest = rest[2:]
+	}
+	if !s.isReady() {
+		if len(rest) == 1 && rest[0] == "ready" && r.Method == http.MethodGet {
+			writeJSON(w, http.StatusServiceUnavailable, V2ModelReady{Name: name, Ready: false})
+			return nil
+		}
+		return &httpError{http.StatusServiceUnavailable, "Models are still loading."}
+	}
+	model, err := s.registry.Get(name, version)
+	if err != nil {
+		return &httpError{http.StatusNotFound, err.Error()}
+	}
+
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.v2Metadata(name, version, model))
+		return nil
+	case len(rest) == 1 && rest[0] == "ready" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, V2ModelReady{Name: name, Ready: true})
+		return nil
+	case len(rest) == 1 && rest[0] == "infer" && r.Method == http.MethodPost:
+		var request V2InferRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBody))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&request)
+		if err != nil {
+			return badRequest("Invalid request: %v", err)
+		}
+		response, err := s.infer(model, name, &request)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, response)
+		return nil
+	case len(rest) == 0 || (len(rest) == 1 && (rest[0] == "ready" || rest[0] == "infer")):
+		return &httpError{http.StatusMethodNotAllowed, "Method not allowed."}
+	}
+	return &httpError{http.StatusNotFound, "Not found."}
+}
+
+func (s *server) v2Metadata(name, version string, model *serving.Model) V2ModelMetadata {
+	p := model.Predictor
+	num_feature := p.Mparam.NumFeature()
+	if num_feature == 0 {
+		// Old models may not record it.
+		num_feature = -1
+	}
+	meta := V2ModelMetadata{
+		Name:     name,
+		Platform: "xgboost",
+		Inputs:   []V2TensorMetadata{{V2_INPUT, "FP32", []int{-1, num_feature}}},
+		Outputs:  []V2TensorMetadata{{V2_OUTPUT, "FP32", v2Shape(-1, p.NumOutput(false))}},
+	}
+	if version == "" {
+		for _, info := range s.registry.List() {
+			if info.Name == name {
+				meta.Versions = append(meta.Versions, info.Version)
+			}
+		}
+		sort.Slice(meta.Versions, func(i, j int) bool {
+			return serving.CompareVersions(meta.Versions[i], meta.Versions[j]) < 0
+		})
+	} else {
+		meta.Versions = []string{model.Version}
+	}
+	return meta
+}
+
+// v2Shape gives a single output per row as a vector, like Triton FIL.
+func v2Shape(nrow, width int) []int {
+	if width == 1 {
+		return []int{nrow}
+	}
+	return []int{nrow, width}
+}
+
+func (s *server) infer(model *serving.Model, name string, request *V2InferRequest) (*V2InferResponse, error) {
+	p := model.Predictor
+	if len(request.Inputs) != 1 {
+		return nil, badRequest("Expected exactly one input, got %d.", len(request.Inputs))
+	}
+	parameters, err := v2Parameters(request.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	input := request.Inputs[0]
+	if input.Name != V2_INPUT {
+		return nil, badRequest("Unknown input %q, expected %s.", input.Name, V2_INPUT)
+	}
+	if len(input.Shape) != 2 || input.Shape[0] < 0 || input.Shape[1] < 0 {
+		return nil, badRequest("Input %s must have a shape of [rows, features].", input.Name)
+	}
+	nrow, ncol := input.Shape[0], input.Shape[1]
+	if nrow > s.maxRows {
+		return nil, badRequest("Too many rows: %d, the limit is %d.", nrow, s.maxRows)
+	}
+	if !v2Numeric(input.Datatype) {
+		return nil, badRequest("Unsupported datatype %q of input %s.", input.Datatype, input.Name)
+	}
+	var raw interface{}
+	err = json.Unmarshal(input.Data, &raw)
+	if err != nil {
+		return nil, badRequest("Invalid data of input %s: %v", input.Name, err)
+	}
+	values, err := flattenV2Data(raw, nil)
+	if err != nil {
+		return nil, badRequest("Invalid data of input %s: %v", input.Name, err)
+	}
+	if int64(len(values)) != int64(nrow)*int64(ncol) {
+		return nil, badRequest("Input %s has %d values, its shape needs %d.", input.Name, len(values), nrow*ncol)
+	}
+
+	outputs := request.Outputs
+	if len(outputs) == 0 {
+		outputs = []V2InferRequestedOutput{{Name: V2_OUTPUT}}
+	}
+	response := &V2InferResponse{ModelName: name, ModelVersion: model.Version, ID: request.ID}
+	for _, output := range outputs {
+		tensor, err := inferOutput(p, output.Name, values, nrow, ncol, &parameters)
+		if err != nil {
+			return nil, err
+		}
+		response.Outputs = append(response.Outputs, tensor)
+	}
+	return response, nil
+}
+
+// v2Parameters picks the parameters used for prediction out of the request
+// parameters and ignores the others.
+func v2Parameters(values map[string]interface{}) (V2InferParameters, error) {
+	var parameters V2InferParameters
+	if value, ok := values["ntree_limit"]; ok {
+		number, ok := value.(float64)
+		if !ok || number < 0 || number != math.Trunc(number) || number > math.MaxInt32 {
+			return parameters, badRequest("ntree_limit must be a non-negative integer, got %v.", value)
+		}
+		parameters.NtreeLimit = int(number)
+	}
+	if value, ok := values["treat_zero_as_missing"]; ok {
+		flag, ok := value.(bool)
+		if !ok {
+			return parameters, badRequest("treat_zero_as_missing must be a boolean, got %v.", value)
+		}
+		parameters.TreatZeroAsMissing = flag
+	}
+	return parameters, nil
+}
+
+func v2Numeric(datatype string) bool {
+	switch datatype {
+	case "FP16", "FP32", "FP64", "INT8", "INT16", "INT32", "INT64", "UINT8", "UINT16", "UINT32", "UINT64":
+		return true
+	}
+	return false
+}
+
+func flattenV2Data(raw interface{}, values []float32) ([]float32, error) {
+	switch value := raw.(type) {
+	case []interface{}:
+		var err error
+		for _, element := range value {
+			values, err = flattenV2Data(element, values)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case float64:
+		return append(values, float32(value)), nil
+	case nil:
+		return append(values, float32(math.NaN())), nil
+	}
+	return nil, fmt.Errorf("Expected a number, got %v.", raw)
+}
+
+func inferOutput(p *predictor.Predictor, name string, values []float32, nrow, ncol int, parameters *V2InferParameters) (V2InferOutput, error) {
+	switch name {
+	case V2_OUTPUT, OUTPUT_MARGIN:
+		output_margin := name == OUTPUT_MARGIN
+		missing := p.Missing
+		if parameters.TreatZeroAsMissing {
+			missing = 0
+		}
+		matrix, err := data.NewDenseMatrix(values, nrow, ncol, missing)
+		if err != nil {
+			return V2InferOutput{}, badRequest("%v", err)
+		}
+		num_output := p.NumOutput(output_margin)
+		preds := make([]float32, nrow*num_output)
+		err = p.PredictDenseWithNtree(matrix, output_margin, parameters.NtreeLimit, preds)
+		if err != nil {
+			return V2InferOutput{}, err
+		}
+		return V2InferOutput{name, v2Shape(nrow, num_output), "FP32", preds}, nil
+	case OUTPUT_LEAF:
+		leaves := []int{}
+		width := 0
+		for i := 0; i < nrow; i++ {
+			row, err := p.PredictLeaf(values[i*ncol:(i+1)*ncol], parameters.TreatZeroAsMissing, parameters.NtreeLimit)
+			if err != nil {
+				return V2InferOutput{}, badRequest("%v", err)
+			}
+			width = len(row)
+			leaves = append(leaves, row...)
+		}
+		return V2InferOutput{name, []int{nrow, width}, "INT32", leaves}, nil
+	case OUTPUT_CONTRIBUTIONS:
+		contribs := []float32{}
+		for i := 0; i < nrow; i++ {
+			row, err := p.PredictContributionsWithNtree(values[i*ncol:(i+1)*ncol], parameters.TreatZeroAsMissing, parameters.NtreeLimit)
+			if err != nil {
+				return V2InferOutput{}, badRequest("%v", err)
+			}
+			contribs = append(contribs, row...)
+		}
+		return V2InferOutput{name, []int{nrow, p.NumContributions()}, "FP32", contribs}, nil
+	}
+	return V2InferOutput{}, badRequest("Unknown output %q.", name)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestV2Infer(t *testing.T) {
+	ts, model := newTestServer(t)
+	row := []float32{0.5, -1, 2, 0, 3, -0.25}
+	input := `"inputs": [{"name": "input__0", "shape": [1, 6], "datatype": "FP32", "data": [0.5, -1, 2, 0, 3, -0.25]}]`
+
+	cases := []struct {
+		body     string
+		status   int
+		expected float32
+	}{
+		{`{` + input + `}`, http.StatusOK, model.Predictor.PredictArray(row, false)[0]},
+		{`{"id": "1", "parameters": {"sequence_id": 7, "priority": 1, "timeout": 100, "sequence_start": true}, ` + input + `}`, http.StatusOK, model.Predictor.PredictArray(row, false)[0]},
+		{`{"parameters": {"ntree_limit": 1}, ` + input + `}`, http.StatusOK, model.Predictor.PredictArrayWithNtree(row, false, false, 1)[0]},
+		{`{"parameters": {"treat_zero_as_missing": true}, ` + input + `}`, http.StatusOK, model.Predictor.PredictArray(row, true)[0]},
+		{`{"parameters": {"ntree_limit": -1}, ` + input + `}`, http.StatusBadRequest, 0},
+		{`{"parameters": {"ntree_limit": 1.5}, ` + input + `}`, http.StatusBadRequest, 0},
+		{`{"parameters": {"treat_zero_as_missing": "yes"}, ` + input + `}`, http.StatusBadRequest, 0},
+		{`{` + strings.Replace(input, "input__0", "input__1", 1) + `}`, http.StatusBadRequest, 0},
+		{`{"unknown": 1, ` + input + `}`, http.StatusBadRequest, 0},
+	}
+	for _, c := range cases {
+		response, err := http.Post(ts.URL+"/v2/models/logistic/infer", "application/json", strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var inferResponse V2InferResponse
+		err = json.NewDecoder(response.Body).Decode(&inferResponse)
+		response.Body.Close()
+		if response.StatusCode != c.status {
+			t.Errorf("%s: status %d, want %d", c.body, response.StatusCode, c.status)
+			continue
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := inferResponse.Outputs[0].Data.([]interface{})
+		if len(data) != 1 || float32(data[0].(float64)) != c.expected {
+			t.Errorf("%s: output %v, want [%v]", c.body, inferResponse.Outputs[0].Data, c.expected)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// Package codegen turns a loaded Predictor into Go source. Trees become
+// nested if/else statements with constant thresholds and the objective
+// transform is inlined, so the generated package predicts without
+// interpreting the model. It imports only the math package of this module,
+// whose ExpFloat32 the transforms share with the interpreter.
+package codegen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/tree"
+)
+
+type Options struct {
+	PackageName string
+}
+
+type generator struct {
+	w          *bufio.Writer
+	predictor  *predictor.Predictor
+	numFeature int
+	numGroup   int
+}
+
+func Generate(w io.Writer, p *predictor.Predictor, options Options) error {
+	g := new(generator)
+	g.w = bufio.NewWriter(w)
+	g.predictor = p
+	g.numGroup = p.Gbm.NumOutputGroup()
+	g.numFeature = numFeature(p)
+	if options.PackageName == "" {
+		return fmt.Errorf("Package name is required.")
+	}
+
+	transform, transformSingle, err := objectiveSource(p.Name_obj)
+	if err != nil {
+		return err
+	}
+
+	g.printf("// Code generated by xgbgen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", options.PackageName)
+	g.printf("import (\n\t\"math\"\n\n\txgbmath \"xgboost4go-predictor/math\"\n)\n\n")
+	g.printf("var _ = xgbmath.ExpFloat32\n\n")
+	g.printf("const (\n")
+	g.printf("\tNumFeature     = %d\n", g.numFeature)
+	g.printf("\tNumOutputGroup = %d\n", g.numGroup)
+	g.printf(")\n\n")
+	// BaseScore is a variable like Missing, since it can be -0, e.g. the
+	// margin of a base_score of 0.5 for binary:logistic.
+	g.printf("var (\n")
+	g.printf("\tBaseScore = float32(%s)\n", floatLiteral(p.Mparam.BaseScore()))
+	g.printf("\tMissing   = float32(%s)\n", floatLiteral(p.Missing))
+	g.printf(")\n\n")
+	g.printf("%s", apiSource)
+	g.printf("%s\n%s", transform, transformSingle)
+
+	switch booster := p.Gbm.(type) {
+	case *gbm.GBTree:
+		if booster.Trees() == nil {
+			return fmt.Errorf("Code generation needs the trees of the model, not a compact forest.")
+		}
+		for tid, rt := range booster.Trees() {
+			if rt.NumRoots() != 1 {
+				return fmt.Errorf("Tree %d has %d roots, only single-root trees are supported for code generation.", tid, rt.NumRoots())
+			}
+		}
+		g.genTreeMargins(booster)
+	case *gbm.GBLinear:
+		g.genLinearMargins(booster)
+	default:
+		return fmt.Errorf("%s is not supported booster for code generation.", p.Name_gbm)
+	}
+	return g.w.Flush()
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(g.w, format, args...)
+}
+
+// numFeature returns the width of the generated feature array: the model's
+// num_feature, widened if a split refers to a larger index.
+func numFeature(p *predictor.Predictor) int {
+	num := p.Mparam.NumFeature()
+	switch booster := p.Gbm.(type) {
+	case *gbm.GBTree:
+		if booster.NumFeature() > num {
+			num = booster.NumFeature()
+		}
+		for _, rt := range booster.Trees() {
+			for nid := 0; nid < rt.NumNodes(); nid++ {
+				n := rt.Node(nid)
+				if !n.IsLeaf() && n.SplitIndex() >= num {
+					num = n.SplitIndex() + 1
+				}
+			}
+		}
+	case *gbm.GBLinear:
+		if booster.NumFeature() > num {
+			num = booster.NumFeature()
+		}
+	}
+	if num == 0 {
+		num = 1
+	}
+	return num
+}
+
+func (g *generator) genTreeMargins(booster *gbm.GBTree) {
+	trees := booster.Trees()
+	treeInfo := booster.TreeInfo()
+
+	g.printf("\nfunc margins(x *[NumFeature]float32, missing float32, preds []float32) {\n")
+	for gid := 0; gid < g.numGroup; gid++ {
+		g.printf("\tpsum%d := float32(0)\n", gid)
+		for tid := range trees {
+			if treeInfo[tid] == gid {
+				g.printf("\tpsum%d += tree%d(x, missing)\n", gid, tid)
+			}
+		}
+		g.printf("\tpreds[%d] = psum%d + BaseScore\n", gid, gid)
+	}
+	g.printf("}\n")
+
+	for tid, rt := range trees {
+		g.printf("\nfunc tree%d(x *[NumFeature]float32, missing float32) float32 {\n", tid)
+		g.genNode(rt, 0, 1)
+		g.printf("}\n")
+	}
+}
+
+func (g *generator) genNode(rt *tree.RegTree, nid int, depth int) {
+	indent := indentation(depth)
+	n := rt.Node(nid)
+	if n.IsLeaf() {
+		g.printf("%sreturn %s\n", indent, floatLiteral(n.LeafValue()))
+		return
+	}
+	cond := floatLiteral(n.SplitCond())
+	if n.DefaultLeft() {
+		g.printf("%sif v := x[%d]; v != v || v == missing || v < %s {\n", indent, n.SplitIndex(), cond)
+	} else {
+		g.printf("%sif v := x[%d]; v == v && v != missing && v < %s {\n", indent, n.SplitIndex(), cond)
+	}
+	g.genNode(rt, n.LeftChild(), depth+1)
+	g.printf("%s} else {\n", indent)
+	g.genNode(rt, n.RightChild(), depth+1)
+	g.printf("%s}\n", indent)
+}
+
+func (g *generator) genLinearMargins(booster *gbm.GBLinear) {
+	g.printf("\nfunc margins(x *[NumFeature]float32, missing float32, preds []float32) {\n")
+	for gid := 0; gid < g.numGroup; gid++ {
+		g.printf("\tpsum%d := float32(%s)\n", gid, floatLiteral(booster.Bias(gid)))
+		for fid := 0; fid < booster.NumFeature(); fid++ {
+			g.printf("\tif v := x[%d]; v == v && v != missing {\n", fid)
+			g.printf("\t\tpsum%d += v * %s\n", gid, floatLiteral(booster.Weight(fid, gid)))
+			g.printf("\t}\n")
+		}
+		g.printf("\tpreds[%d] = psum%d + BaseScore\n", gid, gid)
+	}
+	g.printf("}\n")
+}
+
+func indentation(depth int) string {
+	indent := make([]byte, depth)
+	for i := range indent {
+		indent[i] = '\t'
+	}
+	return string(indent)
+}
+
+// floatLiteral formats a float32 so that the Go compiler reads back the
+// same value; values without a literal form are written as their bits.
+func floatLiteral(value float32) string {
+	if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) || (value == 0 && math.Signbit(float64(value))) {
+		return fmt.Sprintf("math.Float32frombits(0x%08x)", math.Float32bits(value))
+	}
+	return strconv.FormatFloat(float64(value), 'g', -1, 32)
+}
+
+func objectiveSource(name string) (string, string, error) {
+	switch name {
+	case "rank:pairwise", "binary:logitraw", "reg:linear":
+		return identityTransform, identityTransformSingle, nil
+	case "binary:logistic":
+		return logisticTransform, logisticTransformSingle, nil
+	case "multi:softprob":
+		return softprobTransform, nanTransformSingle, nil
+	case "multi:softmax":
+		return softmaxTransform, nanTransformSingle, nil
+	default:
+		return "", "", fmt.Errorf("%s is not supported objective function for code generation.", name)
+	}
+}
+
+const apiSource = `func PredictArray(values []float32, treatsZeroAsNA bool) []float32 {
+	return PredictArrayWithMargin(values, treatsZeroAsNA, false)
+}
+
+func PredictArrayWithMargin(values []float32, treatsZeroAsNA, output_margin bool) []float32 {
+	missing := Missing
+	if treatsZeroAsNA {
+		missing = 0
+	}
+	preds := make([]float32, NumOutputGroup)
+	margins(fromArray(values), missing, preds)
+	if output_margin {
+		return preds
+	}
+	return transform(preds)
+}
+
+func PredictArraySingle(values []float32, treatsZeroAsNA bool) float32 {
+	return PredictArraySingleWithMargin(values, treatsZeroAsNA, false)
+}
+
+func PredictArraySingleWithMargin(values []float32, treatsZeroAsNA, output_margin bool) float32 {
+	if NumOutputGroup != 1 {
+		return float32(math.NaN())
+	}
+	pred := PredictArrayWithMargin(values, treatsZeroAsNA, true)[0]
+	if output_margin {
+		return pred
+	}
+	return transformSingle(pred)
+}
+
+func PredictMap(values map[int]float32) []float32 {
+	return PredictMapWithMargin(values, false)
+}
+
+func PredictMapWithMargin(values map[int]float32, output_margin bool) []float32 {
+	preds := make([]float32, NumOutputGroup)
+	margins(fromMap(values), float32(math.NaN()), preds)
+	if output_margin {
+		return preds
+	}
+	return transform(preds)
+}
+
+func PredictMapSingle(values map[int]float32) float32 {
+	return PredictMapSingleWithMargin(values, false)
+}
+
+func PredictMapSingleWithMargin(values map[int]float32, output_margin bool) float32 {
+	if NumOutputGroup != 1 {
+		return float32(math.NaN())
+	}
+	pred := PredictMapWithMargin(values, true)[0]
+	if output_margin {
+		return pred
+	}
+	return transformSingle(pred)
+}
+
+func fromArray(values []float32) *[NumFeature]float32 {
+	if len(values) >= NumFeature {
+		return (*[NumFeature]float32)(values[:NumFeature])
+	}
+	x := new([NumFeature]float32)
+	for i := range x {
+		x[i] = float32(math.NaN())
+	}
+	copy(x[:], values)
+	return x
+}
+
+func fromMap(values map[int]float32) *[NumFeature]float32 {
+	x := new([NumFeature]float32)
+	for i := range x {
+		x[i] = float32(math.NaN())
+	}
+	for fid, value := range values {
+		if fid >= 0 && fid < NumFeature {
+			x[fid] = value
+		}
+	}
+	return x
+}
+
+`
+
+const identityTransform = `func transform(preds []float32) []float32 {
+	return preds
+}
+`
+
+const identityTransformSingle = `func transformSingle(pred float32) float32 {
+	return pred
+}
+`
+
+const logisticTransform = `func transform(preds []float32) []float32 {
+	for i := range preds {
+		preds[i] = transformSingle(preds[i])
+	}
+	return preds
+}
+`
+
+const logisticTransformSingle = `func transformSingle(pred float32) float32 {
+	return 1.0 / (1.0 + xgbmath.ExpFloat32(-pred))
+}
+`
+
+const softprobTransform = `func transform(preds []float32) []float32 {
+	max := preds[0]
+	for _, pred := range preds[1:] {
+		max = xgbmath.MaxFloat32(pred, max)
+	}
+	sum := float32(0)
+	for i := range preds {
+		preds[i] = xgbmath.ExpFloat32(preds[i] - max)
+		sum += preds[i]
+	}
+	for i := range preds {
+		preds[i] /= sum
+	}
+	return preds
+}
+`
+
+const softmaxTransform = `func transform(preds []float32) []float32 {
+	maxIndex := 0
+	for i := range preds {
+		if preds[maxIndex] < preds[i] {
+			maxIndex = i
+		}
+	}
+	return []float32{0, float32(maxIndex)}
+}
+`
+
+const nanTransformSingle = `func transformSingle(pred float32) float32 {
+	return float32(math.NaN())
+}
+`
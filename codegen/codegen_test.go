@@ -0,0 +1,103 @@
+package codegen
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/internal/testmodel"
+	"xgboost4go-predictor/predictor"
+)
+
+func loadModel(t *testing.T, buf []byte) *predictor.Predictor {
+	t.Helper()
+	p, err := predictor.NewPredictorByConf(*bufio.NewReader(bytes.NewReader(buf)), *config.DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// TestGeneratedCodeMatchesInterpreter generates a package and its test for
+// each model in a module that replaces this one with the source tree, then
+// runs go test on it.
+func TestGeneratedCodeMatchesInterpreter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds generated packages")
+	}
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goSum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	goMod := "module gentest\n\ngo 1.21\n\nrequire xgboost4go-predictor v0.0.0\n\nreplace xgboost4go-predictor => " + root + "\n"
+	err = os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	models := map[string][]byte{
+		"random": testmodel.Model(testmodel.Options{Seed: 1, NumTrees: 50, NumGroups: 3, NumFeature: 12, Depth: 5, Objective: "multi:softprob"}),
+	}
+	for _, name := range []string{"linear", "logistic", "regression", "softmax"} {
+		models[name], err = os.ReadFile(filepath.Join("..", "testdata", name+".bin"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, buf := range models {
+		p := loadModel(t, buf)
+		options := Options{PackageName: name}
+		var source, test bytes.Buffer
+		err := Generate(&source, p, options)
+		if err == nil {
+			err = GenerateTest(&test, p, options, SampleRows(p, 200, 1))
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		err = os.Mkdir(filepath.Join(dir, name), 0755)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, name, "model.go"), source.Bytes(), 0644)
+		}
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, name, "model_test.go"), test.Bytes(), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), "test", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go test of the generated packages failed: %v\n%s", err, output)
+	}
+	if strings.Count(string(output), "ok ") != len(models) {
+		t.Errorf("not every generated package was tested:\n%s", output)
+	}
+}
+
+func TestGenerateRejectsMultiRootTrees(t *testing.T) {
+	buf := testmodel.Model(testmodel.Options{Seed: 1, NumTrees: 2, NumGroups: 1, NumFeature: 4, Depth: 3, NumRoots: 2, Objective: "reg:linear"})
+	p := loadModel(t, buf)
+	err := Generate(new(bytes.Buffer), p, Options{PackageName: "model"})
+	if err == nil || !strings.Contains(err.Error(), "2 roots") {
+		t.Errorf("got %v, want an error about 2 roots", err)
+	}
+}
@@ -0,0 +1,164 @@
+package codegen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/predictor"
+)
+
+// GenerateTest writes a test for the package produced by Generate which
+// checks that the generated code reproduces the interpreter's margins and
+// predictions for rows exactly.
+func GenerateTest(w io.Writer, p *predictor.Predictor, options Options, rows [][]float32) error {
+	if options.PackageName == "" {
+		return fmt.Errorf("Package name is required.")
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "// Code generated by xgbgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(bw, "package %s\n\n", options.PackageName)
+	fmt.Fprintf(bw, "import (\n\t\"math\"\n\t\"testing\"\n)\n\n")
+	fmt.Fprintf(bw, "var _ = math.NaN\n\n")
+
+	writeRows(bw, "testRows", rows)
+	margins := make([][]float32, len(rows))
+	mapMargins := make([][]float32, len(rows))
+	preds := make([][]float32, len(rows))
+	for i, row := range rows {
+		margins[i] = p.PredictArrayWithMargin(row, false, true)
+		mapMargins[i] = p.PredictMapWithMargin(toMap(row), true)
+		preds[i] = p.PredictArray(row, false)
+	}
+	writeRows(bw, "testMargins", margins)
+	writeRows(bw, "testMapMargins", mapMargins)
+	writeRows(bw, "testPreds", preds)
+	fmt.Fprintf(bw, "%s", testSource)
+	return bw.Flush()
+}
+
+func writeRows(w io.Writer, name string, rows [][]float32) {
+	fmt.Fprintf(w, "var %s = [][]float32{\n", name)
+	for _, row := range rows {
+		fmt.Fprintf(w, "\t{")
+		for i, value := range row {
+			if i > 0 {
+				fmt.Fprintf(w, ", ")
+			}
+			fmt.Fprintf(w, "%s", floatLiteral(value))
+		}
+		fmt.Fprintf(w, "},\n")
+	}
+	fmt.Fprintf(w, "}\n\n")
+}
+
+func toMap(row []float32) map[int]float32 {
+	values := make(map[int]float32)
+	for fid, value := range row {
+		if !math.IsNaN(float64(value)) {
+			values[fid] = value
+		}
+	}
+	return values
+}
+
+// SampleRows draws nrow rows whose values are taken from the split
+// thresholds of the model (so that ties with thresholds are exercised),
+// points between them, and missing values.
+func SampleRows(p *predictor.Predictor, nrow int, seed int64) [][]float32 {
+	r := rand.New(rand.NewSource(seed))
+	ncol := numFeature(p)
+	candidates := make([][]float32, ncol)
+	if booster, ok := p.Gbm.(*gbm.GBTree); ok {
+		for _, rt := range booster.Trees() {
+			for nid := 0; nid < rt.NumNodes(); nid++ {
+				n := rt.Node(nid)
+				if !n.IsLeaf() {
+					candidates[n.SplitIndex()] = append(candidates[n.SplitIndex()], n.SplitCond())
+				}
+			}
+		}
+	}
+	for fid := range candidates {
+		sort.Slice(candidates[fid], func(i, j int) bool { return candidates[fid][i] < candidates[fid][j] })
+	}
+
+	rows := make([][]float32, nrow)
+	for i := range rows {
+		row := make([]float32, ncol)
+		for fid := range row {
+			thresholds := candidates[fid]
+			switch {
+			case r.Intn(8) == 0:
+				row[fid] = float32(math.NaN())
+			case len(thresholds) == 0:
+				row[fid] = float32(r.NormFloat64())
+			case r.Intn(2) == 0:
+				row[fid] = thresholds[r.Intn(len(thresholds))]
+			default:
+				k := r.Intn(len(thresholds) + 1)
+				switch k {
+				case 0:
+					row[fid] = thresholds[0] - 1
+				case len(thresholds):
+					row[fid] = thresholds[k-1] + 1
+				default:
+					row[fid] = (thresholds[k-1] + thresholds[k]) / 2
+				}
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+const testSource = `func sameFloat(a, b float32) bool {
+	return a == b || (a != a && b != b)
+}
+
+func TestMarginsMatchInterpreter(t *testing.T) {
+	for i, row := range testRows {
+		margins := PredictArrayWithMargin(row, false, true)
+		for k := range testMargins[i] {
+			if !sameFloat(margins[k], testMargins[i][k]) {
+				t.Errorf("row %d group %d: margin = %v, interpreter = %v", i, k, margins[k], testMargins[i][k])
+			}
+		}
+	}
+}
+
+func TestMapMarginsMatchInterpreter(t *testing.T) {
+	for i, row := range testRows {
+		values := make(map[int]float32)
+		for fid, value := range row {
+			if value == value {
+				values[fid] = value
+			}
+		}
+		margins := PredictMapWithMargin(values, true)
+		for k := range testMapMargins[i] {
+			if !sameFloat(margins[k], testMapMargins[i][k]) {
+				t.Errorf("row %d group %d: map margin = %v, interpreter = %v", i, k, margins[k], testMapMargins[i][k])
+			}
+		}
+	}
+}
+
+func TestPredictionsMatchInterpreter(t *testing.T) {
+	for i, row := range testRows {
+		preds := PredictArray(row, false)
+		if len(preds) != len(testPreds[i]) {
+			t.Fatalf("row %d: %d predictions, interpreter = %d", i, len(preds), len(testPreds[i]))
+		}
+		for k := range testPreds[i] {
+			if !sameFloat(preds[k], testPreds[i][k]) {
+				t.Errorf("row %d output %d: prediction = %v, interpreter = %v", i, k, preds[k], testPreds[i][k])
+			}
+		}
+	}
+}
+`
@@ -1,9 +1,41 @@
 package config
 
-import "xgboost4go-predictor/learner"
+import (
+	"xgboost4go-predictor/learner"
+	"xgboost4go-predictor/math"
+	"xgboost4go-predictor/util"
+)
 
 var DEFAULT = new(Configuration)
 
+const (
+	EVALUATOR_TRAVERSAL   = "traversal"
+	EVALUATOR_QUICKSCORER = "quickscorer"
+	EVALUATOR_QUANTIZED   = "quantized"
+)
+
 type Configuration struct {
-	ObjFunction learner.ObjFunction
+	ObjFunction        learner.ObjFunction
+	FeatureMap         *util.FeatureMap
+	StrictFeatureNames bool
+	// Missing is the value treated as missing in array inputs, like
+	// XGBoost's missing parameter. NaN is used when it is nil; NaN inputs
+	// are always missing.
+	Missing *float32
+	// Evaluator selects how tree ensembles are evaluated. QuickScorer is
+	// used only for models whose trees have at most 64 leaves; other models
+	// fall back to traversal. The quantized evaluator compares bin ids of
+	// split thresholds instead of float32 values and gives identical
+	// results. Traversal is used when it is empty.
+	Evaluator string
+	// MaxModelBytes limits the size of binary models read by the
+	// predictor, 0 for no limit. Set it when loading untrusted models.
+	MaxModelBytes int64
+}
+
+func (configuration Configuration) MissingValue() float32 {
+	if configuration.Missing == nil {
+		return math.NAN
+	}
+	return *configuration.Missing
 }
@@ -0,0 +1,108 @@
+package data
+
+import (
+	"fmt"
+)
+
+// CSRMatrix is a sparse matrix in compressed sparse row format. Row i holds
+// the entries Indptr[i] to Indptr[i+1] of Indices and Values; absent entries
+// are treated as missing, as are explicit NaN values. A column appears at
+// most once per row.
+type CSRMatrix struct {
+	Indptr  []int
+	Indices []int
+	Values  []float32
+	NumCol  int
+}
+
+func NewCSRMatrix(indptr []int, indices []int, values []float32, ncol int) (*CSRMatrix, error) {
+	matrix := new(CSRMatrix)
+	matrix.Indptr = indptr
+	matrix.Indices = indices
+	matrix.Values = values
+	matrix.NumCol = ncol
+	err := matrix.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return matrix, nil
+}
+
+// Validate checks the structure of the matrix, for matrices built as struct
+// literals; the predict methods call it before reading any row.
+func (m *CSRMatrix) Validate() error {
+	if len(m.Indptr) == 0 {
+		return fmt.Errorf("Indptr must have at least one element.")
+	}
+	if len(m.Indices) != len(m.Values) {
+		return fmt.Errorf("Indices and values size mismatch: %d != %d", len(m.Indices), len(m.Values))
+	}
+	for i := 1; i < len(m.Indptr); i++ {
+		if m.Indptr[i] < m.Indptr[i-1] {
+			return fmt.Errorf("Indptr must be non-decreasing: indptr[%d] = %d < %d", i, m.Indptr[i], m.Indptr[i-1])
+		}
+	}
+	if m.Indptr[0] < 0 || m.Indptr[len(m.Indptr)-1] > len(m.Values) {
+		return fmt.Errorf("Indptr is out of range: [%d, %d] for %d values", m.Indptr[0], m.Indptr[len(m.Indptr)-1], len(m.Values))
+	}
+	for _, fid := range m.Indices[m.Indptr[0]:m.Indptr[len(m.Indptr)-1]] {
+		if fid < 0 || fid >= m.NumCol {
+			return fmt.Errorf("Column index is out of range: %d (num_col = %d)", fid, m.NumCol)
+		}
+	}
+	for rid := 0; rid < m.NumRow(); rid++ {
+		fid, ok := checkUnique(m.RowIndices(rid))
+		if !ok {
+			return fmt.Errorf("Duplicate column index in row %d: %d", rid, fid)
+		}
+	}
+	return nil
+}
+
+// checkUnique returns a repeated index, if any. Sorted rows, the usual case,
+// are checked without allocating.
+func checkUnique(indices []int) (int, bool) {
+	sorted := true
+	for i := 1; i < len(indices); i++ {
+		if indices[i] == indices[i-1] {
+			return indices[i], false
+		}
+		if indices[i] < indices[i-1] {
+			sorted = false
+		}
+	}
+	if sorted {
+		return 0, true
+	}
+	seen := make(map[int]bool, len(indices))
+	for _, fid := range indices {
+		if seen[fid] {
+			return fid, false
+		}
+		seen[fid] = true
+	}
+	return 0, true
+}
+
+func (m *CSRMatrix) NumRow() int {
+	return len(m.Indptr) - 1
+}
+
+func (m *CSRMatrix) RowIndices(rid int) []int {
+	return m.Indices[m.Indptr[rid]:m.Indptr[rid+1]]
+}
+
+func (m *CSRMatrix) RowValues(rid int) []float32 {
+	return m.Values[m.Indptr[rid]:m.Indptr[rid+1]]
+}
+
+// Slice returns the rows [begin, end) as a matrix sharing the same indices
+// and values.
+func (m *CSRMatrix) Slice(begin, end int) *CSRMatrix {
+	matrix := new(CSRMatrix)
+	matrix.Indptr = m.Indptr[begin : end+1]
+	matrix.Indices = m.Indices
+	matrix.Values = m.Values
+	matrix.NumCol = m.NumCol
+	return matrix
+}
@@ -0,0 +1,59 @@
+package data
+
+import (
+	"fmt"
+
+	"xgboost4go-predictor/math"
+)
+
+// DenseMatrix is a row-major matrix of feature values. Entries that are NaN
+// or equal to Missing are treated as missing.
+type DenseMatrix struct {
+	Values  []float32
+	NumRow  int
+	NumCol  int
+	Missing float32
+}
+
+func NewDenseMatrix(values []float32, nrow, ncol int, missing float32) (*DenseMatrix, error) {
+	matrix := new(DenseMatrix)
+	matrix.Values = values
+	matrix.NumRow = nrow
+	matrix.NumCol = ncol
+	matrix.Missing = missing
+	err := matrix.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return matrix, nil
+}
+
+// Validate checks that the shape matches the values, for matrices built as
+// struct literals; the predict methods call it before reading any row.
+func (m *DenseMatrix) Validate() error {
+	if m.NumRow < 0 || m.NumCol < 0 || (m.NumCol > 0 && m.NumRow > len(m.Values)/m.NumCol) {
+		return fmt.Errorf("Invalid matrix shape: %d x %d", m.NumRow, m.NumCol)
+	}
+	if len(m.Values) != m.NumRow*m.NumCol {
+		return fmt.Errorf("Matrix size mismatch: expected = %d, actual = %d", m.NumRow*m.NumCol, len(m.Values))
+	}
+	return nil
+}
+
+func (m *DenseMatrix) Row(rid int) []float32 {
+	return m.Values[rid*m.NumCol : (rid+1)*m.NumCol]
+}
+
+func (m *DenseMatrix) IsMissing(value float32) bool {
+	return math.IsNaN(value) || value == m.Missing
+}
+
+// Slice returns the rows [begin, end) as a matrix sharing the same values.
+func (m *DenseMatrix) Slice(begin, end int) *DenseMatrix {
+	matrix := new(DenseMatrix)
+	matrix.Values = m.Values[begin*m.NumCol : end*m.NumCol]
+	matrix.NumRow = end - begin
+	matrix.NumCol = m.NumCol
+	matrix.Missing = m.Missing
+	return matrix
+}
@@ -0,0 +1,220 @@
+package data
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"xgboost4go-predictor/math"
+)
+
+const (
+	FORMAT_LIBSVM = "libsvm"
+	FORMAT_CSV    = "csv"
+
+	MAX_TEXT_LINE = 256 << 20
+)
+
+// TextOptions describes a LibSVM or CSV input.
+//
+// LibSVM lines are "<label> [qid:<id>] <index>:<value> ...", with zero-based
+// feature indices; text after '#' is a comment. CSV lines hold one value per
+// column; empty fields and "NA" are missing.
+type TextOptions struct {
+	Format string
+	// Delimiter separates CSV fields, ',' when 0.
+	Delimiter byte
+	// Header skips the first CSV line; its names are kept as Header.
+	Header bool
+	// LabelColumn is the CSV column of the label, counted from 0. The other
+	// columns are the features in order. There is no label when it is nil.
+	LabelColumn *int
+	// Missing is a value read as missing, besides NaN, like XGBoost's
+	// missing parameter. Missing values are left out of the batches.
+	Missing *float32
+}
+
+// TextReader reads a LibSVM or CSV input in batches of rows, so that inputs
+// larger than memory can be scored.
+type TextReader struct {
+	options TextOptions
+	scanner *bufio.Scanner
+	line    int
+	header  []string
+	ncol    int
+}
+
+func NewTextReader(reader io.Reader, options TextOptions) (*TextReader, error) {
+	if options.Format != FORMAT_LIBSVM && options.Format != FORMAT_CSV {
+		return nil, fmt.Errorf("Unknown input format: %q", options.Format)
+	}
+	if options.Delimiter == 0 {
+		options.Delimiter = ','
+	}
+	textReader := new(TextReader)
+	textReader.options = options
+	textReader.scanner = bufio.NewScanner(reader)
+	textReader.scanner.Buffer(make([]byte, 64<<10), MAX_TEXT_LINE)
+	textReader.ncol = -1
+	if options.Format == FORMAT_CSV && options.Header {
+		if !textReader.scanner.Scan() {
+			if err := textReader.scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("Missing CSV header.")
+		}
+		textReader.line++
+		fields := strings.Split(strings.TrimRight(textReader.scanner.Text(), "\r"), string(options.Delimiter))
+		for i, field := range fields {
+			if options.LabelColumn != nil && i == *options.LabelColumn {
+				continue
+			}
+			textReader.header = append(textReader.header, strings.TrimSpace(field))
+		}
+		textReader.ncol = len(fields)
+	}
+	return textReader, nil
+}
+
+// Header returns the names of the feature columns of a CSV header.
+func (r *TextReader) Header() []string {
+	return r.header
+}
+
+// Line returns the number of the last line read.
+func (r *TextReader) Line() int {
+	return r.line
+}
+
+// ReadBatch reads up to maxRows rows. labels is nil when the input has no
+// labels. It returns io.EOF once no rows are left.
+func (r *TextReader) ReadBatch(maxRows int) (*CSRMatrix, []float32, error) {
+	indptr := []int{0}
+	var indices []int
+	var values []float32
+	var labels []float32
+	ncol := 0
+	for len(indptr)-1 < maxRows && r.scanner.Scan() {
+		r.line++
+		line := strings.TrimRight(r.scanner.Text(), "\r")
+		var label float32
+		var hasLabel bool
+		var err error
+		if r.options.Format == FORMAT_LIBSVM {
+			if hash := strings.IndexByte(line, '#'); hash >= 0 {
+				line = line[:hash]
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			label, indices, values, err = r.parseLibSVM(line, indices, values)
+			hasLabel = true
+		} else {
+			if line == "" {
+				continue
+			}
+			label, hasLabel, indices, values, err = r.parseCSV(line, indices, values)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("Line %d: %v", r.line, err)
+		}
+		for _, fid := range indices[indptr[len(indptr)-1]:] {
+			if fid >= ncol {
+				ncol = fid + 1
+			}
+		}
+		if hasLabel {
+			labels = append(labels, label)
+		}
+		indptr = append(indptr, len(indices))
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("Line %d: %v", r.line+1, err)
+	}
+	if len(indptr) == 1 {
+		return nil, nil, io.EOF
+	}
+	matrix, err := NewCSRMatrix(indptr, indices, values, ncol)
+	if err != nil {
+		return nil, nil, err
+	}
+	return matrix, labels, nil
+}
+
+func (r *TextReader) isMissing(value float32) bool {
+	return math.IsNaN(value) || (r.options.Missing != nil && value == *r.options.Missing)
+}
+
+func (r *TextReader) parseLibSVM(line string, indices []int, values []float32) (float32, []int, []float32, error) {
+	fields := strings.Fields(line)
+	label, err := strconv.ParseFloat(fields[0], 32)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("Invalid label %q.", fields[0])
+	}
+	for _, field := range fields[1:] {
+		colon := strings.IndexByte(field, ':')
+		if colon < 0 {
+			return 0, nil, nil, fmt.Errorf("Invalid entry %q.", field)
+		}
+		if field[:colon] == "qid" {
+			continue
+		}
+		fid, err := strconv.Atoi(field[:colon])
+		if err != nil || fid < 0 {
+			return 0, nil, nil, fmt.Errorf("Invalid feature index %q.", field[:colon])
+		}
+		value, err := strconv.ParseFloat(field[colon+1:], 32)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("Invalid value %q.", field[colon+1:])
+		}
+		if r.isMissing(float32(value)) {
+			continue
+		}
+		indices = append(indices, fid)
+		values = append(values, float32(value))
+	}
+	return float32(label), indices, values, nil
+}
+
+func (r *TextReader) parseCSV(line string, indices []int, values []float32) (float32, bool, []int, []float32, error) {
+	fields := strings.Split(line, string(r.options.Delimiter))
+	if r.ncol < 0 {
+		r.ncol = len(fields)
+	} else if len(fields) != r.ncol {
+		return 0, false, nil, nil, fmt.Errorf("Expected %d fields, got %d.", r.ncol, len(fields))
+	}
+	var label float32
+	hasLabel := false
+	fid := 0
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		isLabel := r.options.LabelColumn != nil && i == *r.options.LabelColumn
+		if field == "" || field == "NA" {
+			if isLabel {
+				return 0, false, nil, nil, fmt.Errorf("Missing label.")
+			}
+			fid++
+			continue
+		}
+		value, err := strconv.ParseFloat(field, 32)
+		if err != nil {
+			return 0, false, nil, nil, fmt.Errorf("Invalid value %q in column %d.", field, i)
+		}
+		if isLabel {
+			label = float32(value)
+			hasLabel = true
+			continue
+		}
+		if !r.isMissing(float32(value)) {
+			indices = append(indices, fid)
+			values = append(values, float32(value))
+		}
+		fid++
+	}
+	if r.options.LabelColumn != nil && !hasLabel {
+		return 0, false, nil, nil, fmt.Errorf("Label column %d is out of range.", *r.options.LabelColumn)
+	}
+	return label, hasLabel, indices, values, nil
+}
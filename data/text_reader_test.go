@@ -0,0 +1,144 @@
+package data
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type textRow struct {
+	indices []int
+	values  []float32
+}
+
+func readAll(t *testing.T, input string, options TextOptions, batch int) ([]textRow, []float32, *TextReader) {
+	t.Helper()
+	reader, err := NewTextReader(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []textRow
+	var labels []float32
+	for {
+		matrix, batchLabels, err := reader.ReadBatch(batch)
+		if err == io.EOF {
+			return rows, labels, reader
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matrix.NumRow() > batch {
+			t.Fatalf("batch of %d rows, want at most %d", matrix.NumRow(), batch)
+		}
+		for rid := 0; rid < matrix.NumRow(); rid++ {
+			rows = append(rows, textRow{
+				indices: append([]int{}, matrix.RowIndices(rid)...),
+				values:  append([]float32{}, matrix.RowValues(rid)...),
+			})
+		}
+		labels = append(labels, batchLabels...)
+	}
+}
+
+func checkRows(t *testing.T, got, expected []textRow) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("got %d rows, want %d", len(got), len(expected))
+	}
+	for i := range expected {
+		if len(got[i].indices) != len(expected[i].indices) || (len(expected[i].indices) > 0 && !reflect.DeepEqual(got[i], expected[i])) {
+			t.Errorf("row %d = %v, want %v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestTextReaderCSV(t *testing.T) {
+	label := 1
+	missing := float32(-999)
+	input := "a, y ,b,c\r\n1,0,NA,2.5\n,1,-999,3\n4,0,,NaN\n\n-1,1,0,-999\n"
+	rows, labels, reader := readAll(t, input, TextOptions{Format: FORMAT_CSV, Header: true, LabelColumn: &label, Missing: &missing}, 2)
+
+	if !reflect.DeepEqual(reader.Header(), []string{"a", "b", "c"}) {
+		t.Errorf("Header = %q", reader.Header())
+	}
+	if !reflect.DeepEqual(labels, []float32{0, 1, 0, 1}) {
+		t.Errorf("labels = %v", labels)
+	}
+	checkRows(t, rows, []textRow{
+		{[]int{0, 2}, []float32{1, 2.5}},
+		{[]int{2}, []float32{3}},
+		{[]int{0}, []float32{4}},
+		{[]int{0, 1}, []float32{-1, 0}},
+	})
+	if reader.Line() != 6 {
+		t.Errorf("Line = %d, want 6", reader.Line())
+	}
+}
+
+func TestTextReaderCSVWithoutLabel(t *testing.T) {
+	rows, labels, _ := readAll(t, "1;2;3\n4;;6\n", TextOptions{Format: FORMAT_CSV, Delimiter: ';'}, 10)
+	if labels != nil {
+		t.Errorf("labels = %v, want nil", labels)
+	}
+	checkRows(t, rows, []textRow{
+		{[]int{0, 1, 2}, []float32{1, 2, 3}},
+		{[]int{0, 2}, []float32{4, 6}},
+	})
+}
+
+func TestTextReaderLibSVM(t *testing.T) {
+	missing := float32(0)
+	input := "# a comment line\n1 qid:3 0:1.5 4:-2 # trailing comment\n0 qid:3 2:0 3:nan 7:1\n\n1\n0.5 1:1e3\n"
+	rows, labels, _ := readAll(t, input, TextOptions{Format: FORMAT_LIBSVM, Missing: &missing}, 3)
+	if !reflect.DeepEqual(labels, []float32{1, 0, 1, 0.5}) {
+		t.Errorf("labels = %v", labels)
+	}
+	checkRows(t, rows, []textRow{
+		{[]int{0, 4}, []float32{1.5, -2}},
+		{[]int{7}, []float32{1}},
+		{nil, nil},
+		{[]int{1}, []float32{1000}},
+	})
+}
+
+func TestTextReaderErrors(t *testing.T) {
+	label := 5
+	one := 1
+	cases := []struct {
+		input   string
+		options TextOptions
+		message string
+	}{
+		{"x 0:1\n", TextOptions{Format: FORMAT_LIBSVM}, "Line 1: Invalid label"},
+		{"1 0:1\n1 2\n", TextOptions{Format: FORMAT_LIBSVM}, "Line 2: Invalid entry"},
+		{"1 -1:1\n", TextOptions{Format: FORMAT_LIBSVM}, "Line 1: Invalid feature index"},
+		{"1 0:x\n", TextOptions{Format: FORMAT_LIBSVM}, "Line 1: Invalid value"},
+		{"1 2:1 2:3\n", TextOptions{Format: FORMAT_LIBSVM}, "Duplicate column index"},
+		{"1,2\n1,2,3\n", TextOptions{Format: FORMAT_CSV}, "Line 2: Expected 2 fields, got 3."},
+		{"1,x\n", TextOptions{Format: FORMAT_CSV}, "Line 1: Invalid value \"x\" in column 1."},
+		{"1,NA,3\n", TextOptions{Format: FORMAT_CSV, LabelColumn: &one}, "Line 1: Missing label."},
+		{"1,2\n", TextOptions{Format: FORMAT_CSV, LabelColumn: &label}, "Label column 5 is out of range."},
+	}
+	for _, c := range cases {
+		reader, err := NewTextReader(strings.NewReader(c.input), c.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for err == nil {
+			_, _, err = reader.ReadBatch(1)
+		}
+		if err == io.EOF || !strings.Contains(err.Error(), c.message) {
+			t.Errorf("%q: got %v, want %q", c.input, err, c.message)
+		}
+	}
+
+	_, err := NewTextReader(strings.NewReader(""), TextOptions{Format: FORMAT_CSV, Header: true})
+	if err == nil {
+		t.Error("empty input with a header was accepted")
+	}
+	_, err = NewTextReader(strings.NewReader(""), TextOptions{Format: "tsv"})
+	if err == nil {
+		t.Error("unknown format was accepted")
+	}
+}
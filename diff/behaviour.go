@@ -0,0 +1,126 @@
+package diff
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/predictor"
+)
+
+// Behaviour summarises how the predictions of two models differ on sample
+// rows. Decisions are compared for classification objectives only: the
+// predicted class, or the side of 0.5 (0 for margins) for binary outputs.
+type Behaviour struct {
+	Rows              int
+	Outputs           int
+	OutputMargin      bool
+	MaxAbsDelta       float64
+	MaxDeltaRow       int
+	SumAbsDelta       float64
+	RowsOverTolerance int
+	HasDecisions      bool
+	DecisionChanges   int
+}
+
+func (behaviour *Behaviour) MeanAbsDelta() float64 {
+	if behaviour.Rows == 0 || behaviour.Outputs == 0 {
+		return 0
+	}
+	return behaviour.SumAbsDelta / float64(behaviour.Rows*behaviour.Outputs)
+}
+
+// CompareBatch predicts the rows of matrix with both models and adds the
+// differences to report.Behaviour, so that a sample can be compared in
+// batches.
+func (report *Report) CompareBatch(a, b *predictor.Predictor, matrix *data.CSRMatrix, options Options) error {
+	num_output := a.NumOutput(options.OutputMargin)
+	if b.NumOutput(options.OutputMargin) != num_output {
+		return fmt.Errorf("Models have different numbers of outputs: %d != %d", num_output, b.NumOutput(options.OutputMargin))
+	}
+	if report.Behaviour == nil {
+		report.Behaviour = &Behaviour{Outputs: num_output, OutputMargin: options.OutputMargin, MaxDeltaRow: -1}
+	}
+	behaviour := report.Behaviour
+	nrow := matrix.NumRow()
+	predsA := make([]float32, nrow*num_output)
+	predsB := make([]float32, nrow*num_output)
+	err := a.PredictCSRWithNtree(matrix, options.OutputMargin, options.NtreeLimit, predsA)
+	if err != nil {
+		return err
+	}
+	err = b.PredictCSRWithNtree(matrix, options.OutputMargin, options.NtreeLimit, predsB)
+	if err != nil {
+		return err
+	}
+
+	decide := decider(a.Name_obj, options.OutputMargin, num_output)
+	behaviour.HasDecisions = decide != nil
+	for rid := 0; rid < nrow; rid++ {
+		rowA := predsA[rid*num_output : (rid+1)*num_output]
+		rowB := predsB[rid*num_output : (rid+1)*num_output]
+		over := false
+		for k := range rowA {
+			delta := math.Abs(float64(rowA[k]) - float64(rowB[k]))
+			if rowA[k] != rowA[k] || rowB[k] != rowB[k] {
+				if rowA[k] == rowA[k] || rowB[k] == rowB[k] {
+					delta = math.Inf(1)
+				} else {
+					delta = 0
+				}
+			}
+			behaviour.SumAbsDelta += delta
+			if delta > behaviour.MaxAbsDelta || behaviour.MaxDeltaRow < 0 {
+				behaviour.MaxAbsDelta = delta
+				behaviour.MaxDeltaRow = behaviour.Rows + rid
+			}
+			if delta > options.PredictionTolerance {
+				over = true
+			}
+		}
+		if over {
+			behaviour.RowsOverTolerance++
+		}
+		if decide != nil && decide(rowA) != decide(rowB) {
+			behaviour.DecisionChanges++
+		}
+	}
+	behaviour.Rows += nrow
+	return nil
+}
+
+// decider returns the decision made from one row of outputs, or nil when
+// the objective is not a classification.
+func decider(name_obj string, output_margin bool, num_output int) func(preds []float32) int {
+	// multi:softmax predicts [0, class].
+	if name_obj == "multi:softmax" && !output_margin {
+		return func(preds []float32) int {
+			return int(preds[1])
+		}
+	}
+	if num_output > 1 {
+		return func(preds []float32) int {
+			best := 0
+			for k := range preds {
+				if preds[k] > preds[best] {
+					best = k
+				}
+			}
+			return best
+		}
+	}
+	if strings.HasPrefix(name_obj, "binary:") {
+		threshold := float32(0.5)
+		if output_margin || name_obj == "binary:logitraw" {
+			threshold = 0
+		}
+		return func(preds []float32) int {
+			if preds[0] > threshold {
+				return 1
+			}
+			return 0
+		}
+	}
+	return nil
+}
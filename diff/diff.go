@@ -0,0 +1,299 @@
+// Package diff compares two models, structurally (parameters, tree counts,
+// topology, thresholds and leaf values) and by their predictions on sample
+// rows, to show what changed when a model is retrained.
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/tree"
+	"xgboost4go-predictor/util"
+)
+
+const DEFAULT_MAX_DIFFERENCES = 50
+
+// Kinds of structural differences.
+const (
+	DIFF_PARAM     = "param"
+	DIFF_TREES     = "trees"
+	DIFF_TOPOLOGY  = "topology"
+	DIFF_SPLIT     = "split"
+	DIFF_THRESHOLD = "threshold"
+	DIFF_DEFAULT   = "default"
+	DIFF_LEAF      = "leaf"
+	DIFF_WEIGHT    = "weight"
+)
+
+// Options sets the tolerances of a comparison. Values within Tolerance of
+// each other (base_score, thresholds, leaf values and linear weights) are
+// equal, as are predictions within PredictionTolerance.
+type Options struct {
+	Tolerance           float64
+	PredictionTolerance float64
+	// OutputMargin compares margins instead of transformed predictions.
+	OutputMargin bool
+	// MaxDifferences caps the structural differences kept in a report, the
+	// rest are only counted. DEFAULT_MAX_DIFFERENCES is used when it is 0.
+	MaxDifferences int
+	NtreeLimit     int
+}
+
+func (options Options) maxDifferences() int {
+	if options.MaxDifferences <= 0 {
+		return DEFAULT_MAX_DIFFERENCES
+	}
+	return options.MaxDifferences
+}
+
+// Difference is one structural difference. Tree and Node are -1 when they
+// do not apply; Node is the node id in the first model.
+type Difference struct {
+	Kind    string
+	Tree    int
+	Node    int
+	Message string
+}
+
+func (difference Difference) String() string {
+	switch {
+	case difference.Node >= 0:
+		return fmt.Sprintf("tree %d node %d: %s", difference.Tree, difference.Node, difference.Message)
+	case difference.Tree >= 0:
+		return fmt.Sprintf("tree %d: %s", difference.Tree, difference.Message)
+	}
+	return difference.Message
+}
+
+// Report is the result of a comparison. Behaviour is nil until predictions
+// are compared.
+type Report struct {
+	Differences []Difference
+	// Omitted counts the differences beyond Options.MaxDifferences.
+	Omitted       int
+	NumTrees      [2]int
+	ChangedTrees  int
+	ComparedTrees int
+	// TreesUnavailable is set when a compact model has no tree structures,
+	// so trees were not compared.
+	TreesUnavailable bool
+	Behaviour        *Behaviour
+	maxDifferences   int
+	featureMap       *util.FeatureMap
+}
+
+// Equal reports whether no structural difference was found and, when
+// predictions were compared, none differed by more than the tolerance.
+func (report *Report) Equal() bool {
+	if len(report.Differences) != 0 || report.Omitted != 0 {
+		return false
+	}
+	return report.Behaviour == nil || report.Behaviour.RowsOverTolerance == 0
+}
+
+func (report *Report) add(kind string, tid, nid int, format string, args ...interface{}) {
+	if len(report.Differences) >= report.maxDifferences {
+		report.Omitted++
+		return
+	}
+	report.Differences = append(report.Differences, Difference{kind, tid, nid, fmt.Sprintf(format, args...)})
+}
+
+// name names a feature from the first model's feature map.
+func (report *Report) name(fid int) string {
+	if report.featureMap != nil && fid < report.featureMap.NumFeature() {
+		return report.featureMap.Name(fid)
+	}
+	return fmt.Sprintf("f%d", fid)
+}
+
+func within(a, b float32, tolerance float64) bool {
+	if a != a || b != b {
+		return a != a && b != b
+	}
+	return math.Abs(float64(a)-float64(b)) <= tolerance
+}
+
+// Compare compares the structure of two models.
+func Compare(a, b *predictor.Predictor, options Options) *Report {
+	report := &Report{maxDifferences: options.maxDifferences(), featureMap: a.FeatureMap}
+	compareParams(report, a, b, options)
+
+	switch boosterA := a.Gbm.(type) {
+	case *gbm.GBTree:
+		boosterB, ok := b.Gbm.(*gbm.GBTree)
+		if ok {
+			compareForests(report, boosterA, boosterB, options)
+		}
+	case *gbm.GBLinear:
+		boosterB, ok := b.Gbm.(*gbm.GBLinear)
+		if ok {
+			compareLinear(report, boosterA, boosterB, options)
+		}
+	}
+	return report
+}
+
+func compareParams(report *Report, a, b *predictor.Predictor, options Options) {
+	if a.Name_obj != b.Name_obj {
+		report.add(DIFF_PARAM, -1, -1, "objective: %s -> %s", a.Name_obj, b.Name_obj)
+	}
+	if a.Name_gbm != b.Name_gbm {
+		report.add(DIFF_PARAM, -1, -1, "booster: %s -> %s", a.Name_gbm, b.Name_gbm)
+	}
+	if !within(a.Mparam.BaseScore(), b.Mparam.BaseScore(), options.Tolerance) {
+		report.add(DIFF_PARAM, -1, -1, "base_score: %v -> %v", a.Mparam.BaseScore(), b.Mparam.BaseScore())
+	}
+	if a.Mparam.NumFeature() != b.Mparam.NumFeature() {
+		report.add(DIFF_PARAM, -1, -1, "num_feature: %d -> %d", a.Mparam.NumFeature(), b.Mparam.NumFeature())
+	}
+	if a.Mparam.NumClass() != b.Mparam.NumClass() {
+		report.add(DIFF_PARAM, -1, -1, "num_class: %d -> %d", a.Mparam.NumClass(), b.Mparam.NumClass())
+	}
+	if a.Gbm.NumOutputGroup() != b.Gbm.NumOutputGroup() {
+		report.add(DIFF_PARAM, -1, -1, "num_output_group: %d -> %d", a.Gbm.NumOutputGroup(), b.Gbm.NumOutputGroup())
+	}
+}
+
+func compareForests(report *Report, a, b *gbm.GBTree, options Options) {
+	report.NumTrees = [2]int{a.Forest().NumTrees(), b.Forest().NumTrees()}
+	if report.NumTrees[0] != report.NumTrees[1] {
+		report.add(DIFF_TREES, -1, -1, "trees: %d -> %d", report.NumTrees[0], report.NumTrees[1])
+	}
+	countsA := groupCounts(a)
+	countsB := groupCounts(b)
+	for gid := 0; gid < len(countsA) || gid < len(countsB); gid++ {
+		var countA, countB int
+		if gid < len(countsA) {
+			countA = countsA[gid]
+		}
+		if gid < len(countsB) {
+			countB = countsB[gid]
+		}
+		if countA != countB {
+			report.add(DIFF_TREES, -1, -1, "trees in group %d: %d -> %d", gid, countA, countB)
+		}
+	}
+
+	treesA, treesB := a.Trees(), b.Trees()
+	if (treesA == nil && report.NumTrees[0] != 0) || (treesB == nil && report.NumTrees[1] != 0) {
+		report.TreesUnavailable = true
+		return
+	}
+	for tid := 0; tid < len(treesA) && tid < len(treesB); tid++ {
+		report.ComparedTrees++
+		before := len(report.Differences) + report.Omitted
+		if a.TreeInfo()[tid] != b.TreeInfo()[tid] {
+			report.add(DIFF_TREES, tid, -1, "group %d -> %d", a.TreeInfo()[tid], b.TreeInfo()[tid])
+		}
+		compareNodes(report, tid, treesA[tid], treesB[tid], 0, 0, options)
+		if len(report.Differences)+report.Omitted != before {
+			report.ChangedTrees++
+		}
+	}
+}
+
+func groupCounts(gbTree *gbm.GBTree) []int {
+	counts := make([]int, gbTree.NumOutputGroup())
+	for _, gid := range gbTree.TreeInfo() {
+		if gid >= 0 && gid < len(counts) {
+			counts[gid]++
+		}
+	}
+	return counts
+}
+
+// compareNodes compares the subtrees at nidA and nidB. Below a change of
+// topology or split feature the subtrees are not compared further.
+func compareNodes(report *Report, tid int, a, b *tree.RegTree, nidA, nidB int, options Options) {
+	nodeA, nodeB := a.Node(nidA), b.Node(nidB)
+	switch {
+	case nodeA.IsLeaf() && nodeB.IsLeaf():
+		if !within(nodeA.LeafValue(), nodeB.LeafValue(), options.Tolerance) {
+			report.add(DIFF_LEAF, tid, nidA, "leaf %v -> %v", nodeA.LeafValue(), nodeB.LeafValue())
+		}
+		return
+	case nodeA.IsLeaf():
+		report.add(DIFF_TOPOLOGY, tid, nidA, "leaf %v -> split on %s", nodeA.LeafValue(), report.name(nodeB.SplitIndex()))
+		return
+	case nodeB.IsLeaf():
+		report.add(DIFF_TOPOLOGY, tid, nidA, "split on %s -> leaf %v", report.name(nodeA.SplitIndex()), nodeB.LeafValue())
+		return
+	}
+	if nodeA.SplitIndex() != nodeB.SplitIndex() {
+		report.add(DIFF_SPLIT, tid, nidA, "split on %s -> %s", report.name(nodeA.SplitIndex()), report.name(nodeB.SplitIndex()))
+		return
+	}
+	if !within(nodeA.SplitCond(), nodeB.SplitCond(), options.Tolerance) {
+		report.add(DIFF_THRESHOLD, tid, nidA, "%s < %v -> %v", report.name(nodeA.SplitIndex()), nodeA.SplitCond(), nodeB.SplitCond())
+	}
+	if nodeA.DefaultLeft() != nodeB.DefaultLeft() {
+		report.add(DIFF_DEFAULT, tid, nidA, "missing goes %s -> %s", direction(nodeA.DefaultLeft()), direction(nodeB.DefaultLeft()))
+	}
+	compareNodes(report, tid, a, b, nodeA.LeftChild(), nodeB.LeftChild(), options)
+	compareNodes(report, tid, a, b, nodeA.RightChild(), nodeB.RightChild(), options)
+}
+
+func direction(left bool) string {
+	if left {
+		return "left"
+	}
+	return "right"
+}
+
+func compareLinear(report *Report, a, b *gbm.GBLinear, options Options) {
+	if a.NumFeature() != b.NumFeature() || a.NumOutputGroup() != b.NumOutputGroup() {
+		report.add(DIFF_WEIGHT, -1, -1, "weights: %d x %d -> %d x %d", a.NumFeature(), a.NumOutputGroup(), b.NumFeature(), b.NumOutputGroup())
+		return
+	}
+	for gid := 0; gid < a.NumOutputGroup(); gid++ {
+		if !within(a.Bias(gid), b.Bias(gid), options.Tolerance) {
+			report.add(DIFF_WEIGHT, -1, -1, "bias of group %d: %v -> %v", gid, a.Bias(gid), b.Bias(gid))
+		}
+		for fid := 0; fid < a.NumFeature(); fid++ {
+			if !within(a.Weight(fid, gid), b.Weight(fid, gid), options.Tolerance) {
+				report.add(DIFF_WEIGHT, -1, -1, "weight of %s in group %d: %v -> %v", report.name(fid), gid, a.Weight(fid, gid), b.Weight(fid, gid))
+			}
+		}
+	}
+}
+
+// WriteText writes the report for people to read.
+func (report *Report) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if report.NumTrees != [2]int{} {
+		fmt.Fprintf(bw, "trees: %d -> %d, %d of %d compared trees changed\n",
+			report.NumTrees[0], report.NumTrees[1], report.ChangedTrees, report.ComparedTrees)
+	}
+	if report.TreesUnavailable {
+		fmt.Fprintf(bw, "trees not compared, compact models keep no tree structures\n")
+	}
+	if len(report.Differences) == 0 {
+		fmt.Fprintf(bw, "no structural differences\n")
+	} else {
+		fmt.Fprintf(bw, "%d structural differences:\n", len(report.Differences)+report.Omitted)
+		for _, difference := range report.Differences {
+			fmt.Fprintf(bw, "  %s\n", difference)
+		}
+		if report.Omitted > 0 {
+			fmt.Fprintf(bw, "  ... and %d more\n", report.Omitted)
+		}
+	}
+	if behaviour := report.Behaviour; behaviour != nil {
+		output := "predictions"
+		if behaviour.OutputMargin {
+			output = "margins"
+		}
+		fmt.Fprintf(bw, "%s on %d rows:\n", output, behaviour.Rows)
+		fmt.Fprintf(bw, "  max |delta| %.6g (row %d), mean |delta| %.6g\n", behaviour.MaxAbsDelta, behaviour.MaxDeltaRow, behaviour.MeanAbsDelta())
+		fmt.Fprintf(bw, "  %d rows over tolerance\n", behaviour.RowsOverTolerance)
+		if behaviour.HasDecisions {
+			fmt.Fprintf(bw, "  %d decisions changed\n", behaviour.DecisionChanges)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,279 @@
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"testing"
+
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/internal/testmodel"
+	"xgboost4go-predictor/predictor"
+)
+
+// baseTree splits on f0 < 1 and then on f1 < 2:
+//
+//	0: f0 < 1, missing left
+//	1:   leaf 0.5
+//	2:   f1 < 2, missing right
+//	3:     leaf -0.25
+//	4:     leaf 1
+func baseTree() []testmodel.Node {
+	return []testmodel.Node{
+		{Parent: -1, Left: 1, Right: 2, Feature: 0, DefaultLeft: true, Value: 1},
+		{Parent: 0, Left: -1, Right: -1, Value: 0.5},
+		{Parent: 0, Left: 3, Right: 4, Feature: 1, Value: 2},
+		{Parent: 2, Left: -1, Right: -1, Value: -0.25},
+		{Parent: 2, Left: -1, Right: -1, Value: 1},
+	}
+}
+
+func loadNodes(t *testing.T, nodes []testmodel.Node) *predictor.Predictor {
+	t.Helper()
+	return loadSpec(t, testmodel.Spec{
+		BaseScore:  0.5,
+		NumFeature: 4,
+		Objective:  "reg:linear",
+		Trees:      []testmodel.Tree{{Nodes: nodes, Depth: 2}},
+	})
+}
+
+func loadSpec(t *testing.T, spec testmodel.Spec) *predictor.Predictor {
+	t.Helper()
+	p, err := predictor.NewPredictorByReader(*bufio.NewReader(bytes.NewReader(testmodel.Encode(spec))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func kinds(report *Report) []string {
+	var result []string
+	for _, difference := range report.Differences {
+		result = append(result, difference.Kind)
+	}
+	return result
+}
+
+func TestCompareSelf(t *testing.T) {
+	a := loadNodes(t, baseTree())
+	report := Compare(a, loadNodes(t, baseTree()), Options{})
+	if !report.Equal() || report.ComparedTrees != 1 || report.ChangedTrees != 0 {
+		t.Errorf("got %+v, want an equal report", report)
+	}
+	if report := Compare(a, a, Options{}); !report.Equal() {
+		t.Errorf("model differs from itself: %v", report.Differences)
+	}
+}
+
+func TestCompareNodes(t *testing.T) {
+	cases := []struct {
+		name      string
+		edit      func(nodes []testmodel.Node) []testmodel.Node
+		tolerance float64
+		kinds     []string
+		node      int
+	}{
+		{"leaf within tolerance", func(nodes []testmodel.Node) []testmodel.Node {
+			nodes[3].Value += 0.001
+			return nodes
+		}, 0.01, nil, -1},
+		{"leaf beyond tolerance", func(nodes []testmodel.Node) []testmodel.Node {
+			nodes[3].Value += 0.1
+			return nodes
+		}, 0.01, []string{DIFF_LEAF}, 3},
+		{"threshold within tolerance", func(nodes []testmodel.Node) []testmodel.Node {
+			nodes[2].Value += 0.001
+			return nodes
+		}, 0.01, nil, -1},
+		{"threshold beyond tolerance", func(nodes []testmodel.Node) []testmodel.Node {
+			nodes[2].Value += 0.1
+			return nodes
+		}, 0.01, []string{DIFF_THRESHOLD}, 2},
+		{"default direction", func(nodes []testmodel.Node) []testmodel.Node {
+			nodes[0].DefaultLeft = false
+			return nodes
+		}, 0, []string{DIFF_DEFAULT}, 0},
+		// The leaves below a changed split are not compared.
+		{"split feature", func(nodes []testmodel.Node) []testmodel.Node {
+			nodes[2].Feature = 3
+			nodes[3].Value = 7
+			return nodes
+		}, 0, []string{DIFF_SPLIT}, 2},
+		{"split to leaf", func(nodes []testmodel.Node) []testmodel.Node {
+			nodes[2] = testmodel.Node{Parent: 0, Left: -1, Right: -1, Value: 3}
+			return nodes[:3]
+		}, 0, []string{DIFF_TOPOLOGY}, 2},
+		{"leaf to split", func(nodes []testmodel.Node) []testmodel.Node {
+			nodes[1] = testmodel.Node{Parent: 0, Left: 5, Right: 6, Feature: 2, Value: 0}
+			return append(nodes,
+				testmodel.Node{Parent: 1, Left: -1, Right: -1, Value: 9},
+				testmodel.Node{Parent: 1, Left: -1, Right: -1, Value: 9})
+		}, 0, []string{DIFF_TOPOLOGY}, 1},
+	}
+	a := loadNodes(t, baseTree())
+	for _, c := range cases {
+		b := loadNodes(t, c.edit(baseTree()))
+		report := Compare(a, b, Options{Tolerance: c.tolerance})
+		got := kinds(report)
+		if len(got) != len(c.kinds) || (len(got) > 0 && got[0] != c.kinds[0]) {
+			t.Errorf("%s: differences %v, want %v", c.name, report.Differences, c.kinds)
+			continue
+		}
+		if len(got) > 0 {
+			if report.Differences[0].Tree != 0 || report.Differences[0].Node != c.node {
+				t.Errorf("%s: difference at %v, want node %d", c.name, report.Differences[0], c.node)
+			}
+			if report.Equal() || report.ChangedTrees != 1 {
+				t.Errorf("%s: Equal = %v, ChangedTrees = %d", c.name, report.Equal(), report.ChangedTrees)
+			}
+		} else if !report.Equal() {
+			t.Errorf("%s: report is not equal", c.name)
+		}
+	}
+}
+
+func TestCompareMaxDifferences(t *testing.T) {
+	nodes := baseTree()
+	for nid := range nodes {
+		nodes[nid].Value += 1
+	}
+	report := Compare(loadNodes(t, baseTree()), loadNodes(t, nodes), Options{MaxDifferences: 2})
+	if len(report.Differences) != 2 || report.Omitted != 3 {
+		t.Errorf("kept %d differences and omitted %d, want 2 and 3", len(report.Differences), report.Omitted)
+	}
+}
+
+func csrMatrix(t *testing.T, rows [][]float32) *data.CSRMatrix {
+	t.Helper()
+	indptr := []int{0}
+	var indices []int
+	var values []float32
+	for _, row := range rows {
+		for fid, value := range row {
+			if value == value {
+				indices = append(indices, fid)
+				values = append(values, value)
+			}
+		}
+		indptr = append(indptr, len(indices))
+	}
+	matrix, err := data.NewCSRMatrix(indptr, indices, values, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matrix
+}
+
+func TestCompareBatch(t *testing.T) {
+	nan := float32(math.NaN())
+	edited := baseTree()
+	edited[3].Value = 0      // delta 0.25
+	edited[4].Value = 1.0625 // delta 0.0625
+	a, b := loadNodes(t, baseTree()), loadNodes(t, edited)
+
+	// Leaves 1, 3, 4 and, with f0 missing, 1.
+	rows := [][]float32{{0, 0, 0, 0}, {2, 0, 0, 0}, {2, 3, 0, 0}, {nan, 5, 0, 0}}
+	report := Compare(a, b, Options{})
+	options := Options{PredictionTolerance: 0.1}
+	err := report.CompareBatch(a, b, csrMatrix(t, rows[:2]), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = report.CompareBatch(a, b, csrMatrix(t, rows[2:]), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	behaviour := report.Behaviour
+	if behaviour.Rows != 4 || behaviour.Outputs != 1 {
+		t.Fatalf("compared %d rows of %d outputs, want 4 of 1", behaviour.Rows, behaviour.Outputs)
+	}
+	if behaviour.MaxAbsDelta != 0.25 || behaviour.MaxDeltaRow != 1 {
+		t.Errorf("max delta %v at row %d, want 0.25 at row 1", behaviour.MaxAbsDelta, behaviour.MaxDeltaRow)
+	}
+	if behaviour.MeanAbsDelta() != (0.25+0.0625)/4 {
+		t.Errorf("mean delta %v, want %v", behaviour.MeanAbsDelta(), (0.25+0.0625)/4)
+	}
+	if behaviour.RowsOverTolerance != 1 || behaviour.HasDecisions {
+		t.Errorf("%d rows over tolerance, decisions %v; want 1, false", behaviour.RowsOverTolerance, behaviour.HasDecisions)
+	}
+	if report.Equal() {
+		t.Error("report is equal")
+	}
+}
+
+func TestCompareBatchNaN(t *testing.T) {
+	nan := float32(math.NaN())
+	withNaN := baseTree()
+	withNaN[1].Value = nan
+	rows := csrMatrix(t, [][]float32{{0, 0, 0, 0}, {2, 3, 0, 0}})
+
+	// NaN on both sides is no difference.
+	a := loadNodes(t, withNaN)
+	report := Compare(a, loadNodes(t, withNaN), Options{})
+	err := report.CompareBatch(a, loadNodes(t, withNaN), rows, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Equal() || report.Behaviour.MaxAbsDelta != 0 {
+		t.Errorf("NaN against NaN: %+v", report.Behaviour)
+	}
+
+	// NaN against a number is an infinite difference.
+	report = &Report{}
+	err = report.CompareBatch(loadNodes(t, baseTree()), a, rows, Options{PredictionTolerance: 1e6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsInf(report.Behaviour.MaxAbsDelta, 1) || report.Behaviour.MaxDeltaRow != 0 || report.Behaviour.RowsOverTolerance != 1 {
+		t.Errorf("number against NaN: %+v", report.Behaviour)
+	}
+}
+
+func leaves(values ...float32) []testmodel.Tree {
+	var trees []testmodel.Tree
+	for _, value := range values {
+		trees = append(trees, testmodel.Tree{Nodes: []testmodel.Node{{Parent: -1, Left: -1, Right: -1, Value: value}}})
+	}
+	return trees
+}
+
+// TestCompareBatchDecisions compares models that predict different classes,
+// neither of which is class 0.
+func TestCompareBatchDecisions(t *testing.T) {
+	softmax := func(values ...float32) *predictor.Predictor {
+		return loadSpec(t, testmodel.Spec{BaseScore: 0.5, NumFeature: 4, NumGroups: 3, Objective: "multi:softmax", Trees: leaves(values...)})
+	}
+	a, b := softmax(0, 1, 2), softmax(0, 2, 1)
+	rows := csrMatrix(t, [][]float32{{0, 0, 0, 0}})
+	for _, output_margin := range []bool{false, true} {
+		report := &Report{}
+		err := report.CompareBatch(a, b, rows, Options{OutputMargin: output_margin})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !report.Behaviour.HasDecisions || report.Behaviour.DecisionChanges != 1 {
+			t.Errorf("margin %v: %+v, want 1 decision change", output_margin, report.Behaviour)
+		}
+	}
+	report := &Report{}
+	err := report.CompareBatch(a, softmax(0, 1, 1.5), rows, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Behaviour.DecisionChanges != 0 {
+		t.Errorf("same class counted as %d changes", report.Behaviour.DecisionChanges)
+	}
+
+	logistic := func(value float32) *predictor.Predictor {
+		return loadSpec(t, testmodel.Spec{BaseScore: 0.5, NumFeature: 4, Objective: "binary:logistic", Trees: leaves(value)})
+	}
+	report = &Report{}
+	err = report.CompareBatch(logistic(-1), logistic(0.25), rows, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Behaviour.DecisionChanges != 1 {
+		t.Errorf("binary:logistic: %d decision changes, want 1", report.Behaviour.DecisionChanges)
+	}
+}
@@ -0,0 +1,82 @@
+package gbm_test
+
+import (
+	"bufio"
+	"os"
+	"testing"
+
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/predictor"
+)
+
+// TestPredictCSRInvalidMatrix checks that every booster rejects the same
+// malformed matrices, including struct literals that bypass NewCSRMatrix.
+func TestPredictCSRInvalidMatrix(t *testing.T) {
+	boosters := map[string]gbm.GradBooster{}
+	for _, evaluator := range evaluators {
+		boosters[evaluator] = loadGBTree(t, multiGroup, evaluator)
+	}
+	file, err := os.Open("../testdata/linear.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	p, err := predictor.NewPredictorByReader(*bufio.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	boosters["gblinear"] = p.Gbm
+
+	matrices := map[string]*data.CSRMatrix{
+		"duplicate":          {Indptr: []int{0, 3}, Indices: []int{1, 2, 1}, Values: []float32{1, 2, 3}, NumCol: 4},
+		"adjacent duplicate": {Indptr: []int{0, 2}, Indices: []int{2, 2}, Values: []float32{1, 2}, NumCol: 4},
+		"negative index":     {Indptr: []int{0, 2}, Indices: []int{-1, 2}, Values: []float32{1, 2}, NumCol: 4},
+		"index past num_col": {Indptr: []int{0, 1}, Indices: []int{4}, Values: []float32{1}, NumCol: 4},
+		"short values":       {Indptr: []int{0, 2}, Indices: []int{0, 1}, Values: []float32{1}, NumCol: 4},
+		"decreasing indptr":  {Indptr: []int{0, 2, 1}, Indices: []int{0, 1}, Values: []float32{1, 2}, NumCol: 4},
+		"empty indptr":       {NumCol: 4},
+	}
+	for name, matrix := range matrices {
+		_, err := data.NewCSRMatrix(matrix.Indptr, matrix.Indices, matrix.Values, matrix.NumCol)
+		if err == nil {
+			t.Errorf("NewCSRMatrix accepted %s", name)
+		}
+		for booster_name, booster := range boosters {
+			preds := make([]float32, 2*booster.NumOutputGroup())
+			err := booster.PredictCSR(matrix, 0, preds)
+			if err == nil {
+				t.Errorf("%s accepted %s", booster_name, name)
+			}
+		}
+	}
+}
+
+// TestPredictCSRUnsortedRow checks that the order of the entries in a row
+// does not matter.
+func TestPredictCSRUnsortedRow(t *testing.T) {
+	sorted, err := data.NewCSRMatrix([]int{0, 3}, []int{1, 4, 6}, []float32{0.5, -2, 3}, multiGroup.NumFeature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsorted, err := data.NewCSRMatrix([]int{0, 3}, []int{6, 1, 4}, []float32{3, 0.5, -2}, multiGroup.NumFeature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, evaluator := range evaluators {
+		gbTree := loadGBTree(t, multiGroup, evaluator)
+		expected := make([]float32, gbTree.NumOutputGroup())
+		got := make([]float32, gbTree.NumOutputGroup())
+		if err := gbTree.PredictCSR(sorted, 0, expected); err != nil {
+			t.Fatal(err)
+		}
+		if err := gbTree.PredictCSR(unsorted, 0, got); err != nil {
+			t.Fatal(err)
+		}
+		for gid := range expected {
+			if got[gid] != expected[gid] {
+				t.Errorf("%s group %d: unsorted = %v, sorted = %v", evaluator, gid, got[gid], expected[gid])
+			}
+		}
+	}
+}
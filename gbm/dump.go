@@ -0,0 +1,67 @@
+package gbm
+
+import (
+	"fmt"
+	"strings"
+
+	"xgboost4go-predictor/tree"
+	"xgboost4go-predictor/util"
+)
+
+// DumpModel returns every tree in the given tree.DUMP_FORMAT_*, like
+// XGBoost's get_dump. Compact models keep no trees to dump.
+func (gbTree *GBTree) DumpModel(fmap *util.FeatureMap, with_stats bool, format string) ([]string, error) {
+	if gbTree.trees == nil && gbTree._forest.NumTrees() != 0 {
+		return nil, fmt.Errorf("Model has no tree structures to dump.")
+	}
+	dumps := make([]string, len(gbTree.trees))
+	for tid, rt := range gbTree.trees {
+		var err error
+		dumps[tid], err = rt.Dump(fmap, with_stats, format)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dumps, nil
+}
+
+// DumpModel returns the bias and weights as a single dump, like XGBoost's
+// get_dump for gblinear. There is no Graphviz form.
+func (gbLinear *GBLinear) DumpModel(fmap *util.FeatureMap, with_stats bool, format string) ([]string, error) {
+	num_group := gbLinear.mparam.num_output_group
+	var builder strings.Builder
+	switch format {
+	case tree.DUMP_FORMAT_TEXT:
+		builder.WriteString("bias:\n")
+		for gid := 0; gid < num_group; gid++ {
+			fmt.Fprintf(&builder, "%v\n", gbLinear.Bias(gid))
+		}
+		builder.WriteString("weight:\n")
+		for fid := 0; fid < gbLinear.mparam.num_feature; fid++ {
+			for gid := 0; gid < num_group; gid++ {
+				fmt.Fprintf(&builder, "%v\n", gbLinear.Weight(fid, gid))
+			}
+		}
+	case tree.DUMP_FORMAT_JSON:
+		builder.WriteString("{\n  \"bias\": [")
+		for gid := 0; gid < num_group; gid++ {
+			if gid > 0 {
+				builder.WriteString(", ")
+			}
+			fmt.Fprintf(&builder, "%v", gbLinear.Bias(gid))
+		}
+		builder.WriteString("],\n  \"weight\": [")
+		for fid := 0; fid < gbLinear.mparam.num_feature; fid++ {
+			for gid := 0; gid < num_group; gid++ {
+				if fid > 0 || gid > 0 {
+					builder.WriteString(", ")
+				}
+				fmt.Fprintf(&builder, "%v", gbLinear.Weight(fid, gid))
+			}
+		}
+		builder.WriteString("]\n}\n")
+	default:
+		return nil, fmt.Errorf("gblinear cannot be dumped as %s.", format)
+	}
+	return []string{builder.String()}, nil
+}
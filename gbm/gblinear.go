@@ -1,6 +1,9 @@
 package gbm
 
 import (
+	"fmt"
+
+	"xgboost4go-predictor/data"
 	"xgboost4go-predictor/util"
 	"xgboost4go-predictor/math"
 )
@@ -17,37 +20,71 @@ func (gbLinear *GBLinear) LoadModel(reader *util.ModelReader, ignored_with_pbuff
 	if err != nil {
 		return err
 	}
-	_, err = reader.ReadInt()
+	// The weights are stored as a vector with a uint64 length.
+	stored, err := reader.ReadInt64()
 	if err != nil {
-		return err
+		return reader.FieldError("num_weights", err)
+	}
+	num_weights := int64(gbLinear.mparam.num_feature+1) * int64(gbLinear.mparam.num_output_group)
+	if num_weights > util.MAX_ARRAY_LENGTH {
+		return reader.FieldError("weights", fmt.Errorf("Invalid gblinear shape: num_feature = %d, num_output_group = %d", gbLinear.mparam.num_feature, gbLinear.mparam.num_output_group))
+	}
+	if stored != num_weights {
+		return reader.FieldError("num_weights", fmt.Errorf("gblinear has %d weights, expected %d for %d features and %d output groups.",
+			stored, num_weights, gbLinear.mparam.num_feature, gbLinear.mparam.num_output_group))
 	}
-	gbLinear.weights, err = reader.ReadFloatArray((gbLinear.mparam.num_feature + 1) * gbLinear.mparam.num_output_group)
-	return err
+	gbLinear.weights, err = reader.ReadFloatArray(int(num_weights))
+	if err != nil {
+		return reader.FieldError("weights", err)
+	}
+	return gbLinear.Validate()
+}
+
+func (gbLinear *GBLinear) SizeBytes() int {
+	return 4 * len(gbLinear.weights)
 }
 
-func (gbLinear *GBLinear) PredictArray(values []float32, treatsZeroAsNA bool, ntree_limit int) []float32 {
+func (gbLinear *GBLinear) PredictArray(values []float32, missing float32, ntree_limit int) []float32 {
 	preds := make([]float32, gbLinear.mparam.num_output_group)
+	gbLinear.PredictArrayInto(values, missing, ntree_limit, preds)
+	return preds
+}
+
+func (gbLinear *GBLinear) PredictArrayInto(values []float32, missing float32, ntree_limit int, preds []float32) error {
+	err := checkPredsSize(preds, 1, gbLinear.mparam.num_output_group)
+	if err != nil {
+		return err
+	}
 	for gid := 0; gid < gbLinear.mparam.num_output_group; gid++ {
-		preds[gid] = gbLinear.PredFromArray(values, treatsZeroAsNA, gid)
+		preds[gid] = gbLinear.PredFromArray(values, missing, gid)
 	}
 
-	return preds
+	return nil
 }
 
 func (gbLinear *GBLinear) PredictMap(values map[int]float32, ntree_limit int) []float32 {
 	preds := make([]float32, gbLinear.mparam.num_output_group)
+	gbLinear.PredictMapInto(values, ntree_limit, preds)
+	return preds
+}
+
+func (gbLinear *GBLinear) PredictMapInto(values map[int]float32, ntree_limit int, preds []float32) error {
+	err := checkPredsSize(preds, 1, gbLinear.mparam.num_output_group)
+	if err != nil {
+		return err
+	}
 	for gid := 0; gid < gbLinear.mparam.num_output_group; gid++ {
 		preds[gid] = gbLinear.PredFromMap(values, gid)
 	}
 
-	return preds
+	return nil
 }
 
-func (gbLinear *GBLinear) PredictSingleFromArray(values []float32, treatsZeroAsNA bool) float32 {
+func (gbLinear *GBLinear) PredictSingleFromArray(values []float32, missing float32) float32 {
 	if (gbLinear.mparam.num_output_group != 1) {
 		return math.NAN
 	} else {
-		return gbLinear.PredFromArray(values, treatsZeroAsNA, 0)
+		return gbLinear.PredFromArray(values, missing, 0)
 	}
 }
 
@@ -59,12 +96,65 @@ func (gbLinear *GBLinear) PredictSingleFromMap(values map[int]float32) float32 {
 	}
 }
 
-func (gbLinear *GBLinear) PredFromArray(value []float32, treatsZeroAsNA bool, gid int) float32 {
+func (gbLinear *GBLinear) NumOutputGroup() int {
+	return gbLinear.mparam.num_output_group
+}
+
+func (gbLinear *GBLinear) PredictDense(matrix *data.DenseMatrix, ntree_limit int, preds []float32) error {
+	num_output_group := gbLinear.mparam.num_output_group
+	err := matrix.Validate()
+	if err != nil {
+		return err
+	}
+	err = checkPredsSize(preds, matrix.NumRow, num_output_group)
+	if err != nil {
+		return err
+	}
+	for rid := 0; rid < matrix.NumRow; rid++ {
+		row := matrix.Row(rid)
+		for gid := 0; gid < num_output_group; gid++ {
+			preds[rid*num_output_group+gid] = gbLinear.PredFromArray(row, matrix.Missing, gid)
+		}
+	}
+
+	return nil
+}
+
+func (gbLinear *GBLinear) PredictCSR(matrix *data.CSRMatrix, ntree_limit int, preds []float32) error {
+	err := matrix.Validate()
+	if err != nil {
+		return err
+	}
+	num_output_group := gbLinear.mparam.num_output_group
+	nrow := matrix.NumRow()
+	err = checkPredsSize(preds, nrow, num_output_group)
+	if err != nil {
+		return err
+	}
+	for rid := 0; rid < nrow; rid++ {
+		indices := matrix.RowIndices(rid)
+		values := matrix.RowValues(rid)
+		for gid := 0; gid < num_output_group; gid++ {
+			psum := gbLinear.Bias(gid)
+			for i, fid := range indices {
+				featValue := values[i]
+				if fid < gbLinear.mparam.num_feature && featValue == featValue {
+					psum += featValue * gbLinear.Weight(fid, gid)
+				}
+			}
+			preds[rid*num_output_group+gid] = psum
+		}
+	}
+
+	return nil
+}
+
+func (gbLinear *GBLinear) PredFromArray(value []float32, missing float32, gid int) float32 {
 	psum := gbLinear.Bias(gid)
 	for fid := 0; fid < gbLinear.mparam.num_feature; fid++ {
 		if len(value) > fid {
 			featValue := value[fid]
-			if !treatsZeroAsNA || featValue != 0 {
+			if featValue == featValue && featValue != missing {
 				psum += featValue * gbLinear.Weight(fid, gid)
 			}
 		}
@@ -77,7 +167,7 @@ func (gbLinear *GBLinear) PredFromMap(values map[int]float32, gid int) float32 {
 	psum := gbLinear.Bias(gid)
 	for fid := 0; fid < gbLinear.mparam.num_feature; fid++ {
 		featValue, ok := values[fid]
-		if !ok {
+		if ok && featValue == featValue {
 			psum += featValue * gbLinear.Weight(fid, gid)
 		}
 	}
@@ -85,6 +175,32 @@ func (gbLinear *GBLinear) PredFromMap(values map[int]float32, gid int) float32 {
 	return psum
 }
 
+func (gbLinear *GBLinear) NumFeature() int {
+	return gbLinear.mparam.num_feature
+}
+
+func (gbLinear *GBLinear) ContributionWidth() int {
+	return gbLinear.mparam.num_feature
+}
+
+// PredictContributions returns value * weight for every feature and the
+// bias as the last value of each output group, laid out as in
+// GBTree.PredictContributions.
+func (gbLinear *GBLinear) PredictContributions(values []float32, missing float32, ntree_limit int) ([]float32, error) {
+	width := gbLinear.mparam.num_feature + 1
+	contribs := make([]float32, width*gbLinear.mparam.num_output_group)
+	for gid := 0; gid < gbLinear.mparam.num_output_group; gid++ {
+		for fid := 0; fid < gbLinear.mparam.num_feature && fid < len(values); fid++ {
+			value := values[fid]
+			if value == value && value != missing {
+				contribs[gid*width+fid] = value * gbLinear.Weight(fid, gid)
+			}
+		}
+		contribs[gid*width+width-1] = gbLinear.Bias(gid)
+	}
+	return contribs, nil
+}
+
 func (gbLinear *GBLinear) Weight(fid, gid int) float32 {
 	return gbLinear.weights[fid*gbLinear.mparam.num_output_group+gid]
 }
@@ -104,16 +220,18 @@ func newGBLinearParam(reader *util.ModelReader) (*GBLinearParam, error) {
 	var err error
 	gbLinearParam.num_feature, err = reader.ReadInt()
 	if err != nil {
-		return gbLinearParam, err
+		return gbLinearParam, reader.FieldError("num_feature", err)
+	}
+	if gbLinearParam.num_feature < 0 {
+		return gbLinearParam, reader.FieldError("num_feature", fmt.Errorf("Invalid num_feature: %d", gbLinearParam.num_feature))
 	}
 	gbLinearParam.num_output_group, err = reader.ReadInt()
 	if err != nil {
-		return gbLinearParam, err
+		return gbLinearParam, reader.FieldError("num_output_group", err)
 	}
-	gbLinearParam.reserved, err = reader.ReadIntArray(32)
-	if err != nil {
-		return gbLinearParam, err
+	if gbLinearParam.num_output_group < 1 || gbLinearParam.num_output_group > MAX_OUTPUT_GROUPS {
+		return gbLinearParam, reader.FieldError("num_output_group", fmt.Errorf("Invalid num_output_group: %d", gbLinearParam.num_output_group))
 	}
-	_, err = reader.ReadInt()
-	return gbLinearParam, err
+	gbLinearParam.reserved, err = reader.ReadIntArray(32)
+	return gbLinearParam, reader.FieldError("reserved", err)
 }
@@ -1,6 +1,10 @@
 package gbm
 
 import (
+	"fmt"
+	"sync"
+
+	"xgboost4go-predictor/data"
 	"xgboost4go-predictor/tree"
 	"xgboost4go-predictor/util"
 	"xgboost4go-predictor/math"
@@ -8,10 +12,17 @@ import (
 
 type GBTree struct {
 	GBBase
-	mparam      *GBTreeParam
-	trees       []*tree.RegTree
-	tree_info   []int
-	_groupTrees [][]*tree.RegTree
+	mparam        *GBTreeParam
+	trees         []*tree.RegTree
+	tree_info     []int
+	_forest       *tree.FlatForest
+	_groupTreeIds [][]int
+	_quickScorer  *tree.QuickScorer
+	_quantized    *tree.QuantizedForest
+	_meanValues   [][]float32
+	_meanOnce     sync.Once
+
+	_contributionWidth int
 }
 
 func (gbTree *GBTree) LoadModel(reader *util.ModelReader, with_pbuffer bool) error {
@@ -21,141 +32,486 @@ func (gbTree *GBTree) LoadModel(reader *util.ModelReader, with_pbuffer bool) err
 		return err
 	}
 
-	gbTree.trees = make([]*tree.RegTree, gbTree.mparam.num_trees)
 	for i := 0; i < gbTree.mparam.num_trees; i++ {
-		gbTree.trees[i] = new(tree.RegTree)
-		err = gbTree.trees[i].LoadModel(reader)
+		regTree := new(tree.RegTree)
+		err = regTree.LoadModel(reader)
 		if err != nil {
-			return err
+			return util.WithinField(fmt.Sprintf("tree %d", i), err)
 		}
+		gbTree.trees = append(gbTree.trees, regTree)
 	}
 
 	if gbTree.mparam.num_trees != 0 {
 		gbTree.tree_info, err = reader.ReadIntArray(gbTree.mparam.num_trees)
 		if err != nil {
-			return err
+			return reader.FieldError("tree_info", err)
 		}
 	}
 
 	if gbTree.mparam.num_pbuffer != 0 && with_pbuffer {
-		reader.Skip(4 * int(gbTree.mparam.PredBufferSize()))
-		reader.Skip(4 * int(gbTree.mparam.PredBufferSize()))
+		size := gbTree.mparam.PredBufferSize()
+		if size < 0 || size > util.MAX_ARRAY_LENGTH {
+			return reader.FieldError("pred_buffer", fmt.Errorf("Invalid pred buffer size: %d", size))
+		}
+		err = reader.Skip(4 * size)
+		if err != nil {
+			return reader.FieldError("pred_buffer", err)
+		}
+		err = reader.Skip(4 * size)
+		if err != nil {
+			return reader.FieldError("pred_counter", err)
+		}
 	}
 
-	gbTree._groupTrees = make([][]*tree.RegTree, gbTree.mparam.num_output_group)
+	err = gbTree.Validate()
+	if err != nil {
+		return err
+	}
+
+	gbTree._groupTreeIds = make([][]int, gbTree.mparam.num_output_group)
 	for i := 0; i < gbTree.mparam.num_output_group; i++ {
-		treeCount := 0
 		for j := 0; j < len(gbTree.tree_info); j++ {
 			if (gbTree.tree_info[j] == i) {
-				treeCount++
+				gbTree._groupTreeIds[i] = append(gbTree._groupTreeIds[i], j)
 			}
 		}
+	}
+	gbTree._forest = tree.NewFlatForest(gbTree.trees)
+	gbTree._contributionWidth = gbTree.contributionWidth()
+	return err
+}
 
-		gbTree._groupTrees[i] = make([]*tree.RegTree, treeCount)
-		treeCount = 0
-		for j := 0; j < len(gbTree.tree_info); j++ {
-			if (gbTree.tree_info[j] == i) {
-				gbTree._groupTrees[i][treeCount] = gbTree.trees[j]
-				treeCount++
-			}
+// NewGBTreeFromForest creates a GBTree that predicts from an already
+// compiled forest, such as one mapped from a compact model file. It has no
+// RegTree structures, so Trees returns nil and QuickScorer is unavailable.
+func NewGBTreeFromForest(num_feature, num_output_group int, tree_info []int, forest *tree.FlatForest) (*GBTree, error) {
+	if len(tree_info) != forest.NumTrees() {
+		return nil, fmt.Errorf("Tree info size mismatch: expected = %d, actual = %d", forest.NumTrees(), len(tree_info))
+	}
+	if num_output_group < 1 || num_output_group > MAX_OUTPUT_GROUPS {
+		return nil, fmt.Errorf("Invalid num_output_group: %d", num_output_group)
+	}
+	gbTree := new(GBTree)
+	gbTree.mparam = new(GBTreeParam)
+	gbTree.mparam.num_trees = forest.NumTrees()
+	gbTree.mparam.num_roots = 1
+	gbTree.mparam.num_feature = num_feature
+	gbTree.mparam.num_output_group = num_output_group
+	gbTree.tree_info = tree_info
+	gbTree._groupTreeIds = make([][]int, num_output_group)
+	for tid, gid := range tree_info {
+		if gid < 0 || gid >= num_output_group {
+			return nil, fmt.Errorf("Tree %d belongs to group %d, but num_output_group = %d", tid, gid, num_output_group)
 		}
+		gbTree._groupTreeIds[gid] = append(gbTree._groupTreeIds[gid], tid)
 	}
-	return err
+	gbTree._forest = forest
+	gbTree._contributionWidth = num_feature
+	return gbTree, nil
+}
+
+func (gbTree *GBTree) Forest() *tree.FlatForest {
+	return gbTree._forest
+}
+
+func (gbTree *GBTree) NumFeature() int {
+	return gbTree.mparam.num_feature
+}
+
+func (gbTree *GBTree) Trees() []*tree.RegTree {
+	return gbTree.trees
+}
+
+func (gbTree *GBTree) TreeInfo() []int {
+	return gbTree.tree_info
+}
+
+// UseQuickScorer switches value predictions to QuickScorer evaluation. It
+// reports false, and keeps tree traversal, when the model is not eligible.
+func (gbTree *GBTree) UseQuickScorer() bool {
+	if gbTree.trees == nil {
+		return false
+	}
+	quickScorer, ok := tree.NewQuickScorer(gbTree.trees)
+	if ok {
+		gbTree._quickScorer = quickScorer
+	}
+	return ok
+}
+
+// UseQuantized switches value predictions to a quantized forest, which
+// bins each row once and compares bin ids instead of thresholds. It reports
+// false, and keeps the current evaluation, when the model is not eligible.
+func (gbTree *GBTree) UseQuantized() bool {
+	quantized, ok := tree.NewQuantizedForest(gbTree._forest)
+	if ok {
+		gbTree._quantized = quantized
+	}
+	return ok
+}
+
+// SizeBytes estimates the memory held by the trees and the compiled forms
+// used for prediction.
+func (gbTree *GBTree) SizeBytes() int {
+	size := gbTree._forest.SizeBytes() + 8*len(gbTree.tree_info)
+	for _, rt := range gbTree.trees {
+		size += rt.SizeBytes()
+	}
+	if gbTree._quickScorer != nil {
+		size += gbTree._quickScorer.SizeBytes()
+	}
+	if gbTree._quantized != nil {
+		size += gbTree._quantized.SizeBytes()
+	}
+	return size
 }
 
-func (gbTree *GBTree) PredictArray(values []float32, treatsZeroAsNA bool, ntree_limit int) []float32 {
+func (gbTree *GBTree) PredictArray(values []float32, missing float32, ntree_limit int) []float32 {
 	preds := make([]float32, gbTree.mparam.num_output_group)
+	gbTree.PredictArrayInto(values, missing, ntree_limit, preds)
+	return preds
+}
+
+func (gbTree *GBTree) PredictArrayInto(values []float32, missing float32, ntree_limit int, preds []float32) error {
+	err := checkPredsSize(preds, 1, gbTree.mparam.num_output_group)
+	if err != nil {
+		return err
+	}
+	if gbTree._quickScorer != nil {
+		bitvectors := gbTree._quickScorer.AcquireBitvectors()
+		gbTree._quickScorer.EvalArray(values, missing, *bitvectors)
+		gbTree.sumQuickScorer(*bitvectors, ntree_limit, preds)
+		gbTree._quickScorer.ReleaseBitvectors(bitvectors)
+		return nil
+	}
+	if gbTree._quantized != nil {
+		bins := gbTree._quantized.AcquireBins()
+		gbTree._quantized.BinArray(values, missing, *bins)
+		gbTree.sumQuantized(*bins, ntree_limit, preds)
+		gbTree._quantized.ReleaseBins(bins)
+		return nil
+	}
 	for gid := 0; gid < gbTree.mparam.num_output_group; gid++ {
-		preds[gid] = gbTree.PredArray(values, treatsZeroAsNA)
+		preds[gid] = gbTree.PredArray(values, missing, gid, 0, ntree_limit)
 	}
 
-	return preds
+	return nil
 }
 
 func (gbTree *GBTree) PredictMap(values map[int]float32, ntree_limit int) []float32 {
 	preds := make([]float32, gbTree.mparam.num_output_group)
+	gbTree.PredictMapInto(values, ntree_limit, preds)
+	return preds
+}
+
+func (gbTree *GBTree) PredictMapInto(values map[int]float32, ntree_limit int, preds []float32) error {
+	err := checkPredsSize(preds, 1, gbTree.mparam.num_output_group)
+	if err != nil {
+		return err
+	}
+	if gbTree._quickScorer != nil {
+		bitvectors := gbTree._quickScorer.AcquireBitvectors()
+		gbTree._quickScorer.EvalMap(values, *bitvectors)
+		gbTree.sumQuickScorer(*bitvectors, ntree_limit, preds)
+		gbTree._quickScorer.ReleaseBitvectors(bitvectors)
+		return nil
+	}
+	if gbTree._quantized != nil {
+		bins := gbTree._quantized.AcquireBins()
+		gbTree._quantized.BinMap(values, *bins)
+		gbTree.sumQuantized(*bins, ntree_limit, preds)
+		gbTree._quantized.ReleaseBins(bins)
+		return nil
+	}
 	for gid := 0; gid < gbTree.mparam.num_output_group; gid++ {
 		preds[gid] = gbTree.PredMap(values, gid, 0, ntree_limit)
 	}
 
-	return preds
+	return nil
 }
 
-func (gbTree *GBTree) PredictSingleFromArray(values []float32, treatsZeroAsNA bool) float32 {
-	return gbTree.PredArray(values, treatsZeroAsNA)
+func (gbTree *GBTree) PredictSingleFromArray(values []float32, missing float32) float32 {
+	if (gbTree.mparam.num_output_group != 1) {
+		return math.NAN
+	} else if gbTree._quickScorer != nil || gbTree._quantized != nil {
+		var preds [1]float32
+		gbTree.PredictArrayInto(values, missing, 0, preds[:])
+		return preds[0]
+	} else {
+		return gbTree.PredArray(values, missing, 0, 0, 0)
+	}
 }
 
 func (gbTree *GBTree) PredictSingleFromMap(values map[int]float32) float32 {
 	if (gbTree.mparam.num_output_group != 1) {
 		return math.NAN
+	} else if gbTree._quickScorer != nil || gbTree._quantized != nil {
+		var preds [1]float32
+		gbTree.PredictMapInto(values, 0, preds[:])
+		return preds[0]
 	} else {
 		return gbTree.PredMap(values, 0, 0, 0)
 	}
 }
 
-func (gbTree *GBTree) PredMap(values map[int]float32, bst_group, root_index, ntree_limit int) float32 {
-	trees := gbTree._groupTrees[bst_group]
-	treeleft := ntree_limit
-	if ntree_limit == 0 {
-		treeleft = len(trees)
+func (gbTree *GBTree) NumOutputGroup() int {
+	return gbTree.mparam.num_output_group
+}
+
+func (gbTree *GBTree) PredictDense(matrix *data.DenseMatrix, ntree_limit int, preds []float32) error {
+	num_output_group := gbTree.mparam.num_output_group
+	err := matrix.Validate()
+	if err != nil {
+		return err
+	}
+	err = checkPredsSize(preds, matrix.NumRow, num_output_group)
+	if err != nil {
+		return err
+	}
+	if gbTree._quickScorer != nil {
+		bitvectors := gbTree._quickScorer.AcquireBitvectors()
+		for rid := 0; rid < matrix.NumRow; rid++ {
+			gbTree._quickScorer.EvalArray(matrix.Row(rid), matrix.Missing, *bitvectors)
+			gbTree.sumQuickScorer(*bitvectors, ntree_limit, preds[rid*num_output_group:(rid+1)*num_output_group])
+		}
+		gbTree._quickScorer.ReleaseBitvectors(bitvectors)
+		return nil
+	}
+	if gbTree._quantized != nil {
+		gbTree.predictDenseQuantized(matrix, ntree_limit, preds)
+		return nil
+	}
+	for i := 0; i < matrix.NumRow*num_output_group; i++ {
+		preds[i] = FLOAT_32_0
 	}
+	for gid := 0; gid < num_output_group; gid++ {
+		treeIds := gbTree.groupTreeIds(gid, ntree_limit)
+		for _, tid := range treeIds {
+			for rid := 0; rid < matrix.NumRow; rid++ {
+				preds[rid*num_output_group+gid] += gbTree._forest.GetLeafByArray(tid, matrix.Row(rid), matrix.Missing)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (gbTree *GBTree) PredictCSR(matrix *data.CSRMatrix, ntree_limit int, preds []float32) error {
+	err := matrix.Validate()
+	if err != nil {
+		return err
+	}
+	num_output_group := gbTree.mparam.num_output_group
+	nrow := matrix.NumRow()
+	err = checkPredsSize(preds, nrow, num_output_group)
+	if err != nil {
+		return err
+	}
+	// Only columns of the matrix used by a split are looked up, so neither
+	// a corrupt num_feature nor a huge split index sizes the buffer.
+	num_feats := gbTree._forest.NumFeature()
+	if num_feats > matrix.NumCol {
+		num_feats = matrix.NumCol
+	}
+	feats := make([]float32, num_feats)
+	for i := 0; i < len(feats); i++ {
+		feats[i] = math.NAN
+	}
+	for rid := 0; rid < nrow; rid++ {
+		indices := matrix.RowIndices(rid)
+		values := matrix.RowValues(rid)
+		for i, fid := range indices {
+			if fid < len(feats) {
+				feats[fid] = values[i]
+			}
+		}
+		if gbTree._quickScorer != nil {
+			bitvectors := gbTree._quickScorer.AcquireBitvectors()
+			gbTree._quickScorer.EvalArray(feats, math.NAN, *bitvectors)
+			gbTree.sumQuickScorer(*bitvectors, ntree_limit, preds[rid*num_output_group:(rid+1)*num_output_group])
+			gbTree._quickScorer.ReleaseBitvectors(bitvectors)
+		} else if gbTree._quantized != nil {
+			bins := gbTree._quantized.AcquireBins()
+			gbTree._quantized.BinArray(feats, math.NAN, *bins)
+			gbTree.sumQuantized(*bins, ntree_limit, preds[rid*num_output_group:(rid+1)*num_output_group])
+			gbTree._quantized.ReleaseBins(bins)
+		} else {
+			for gid := 0; gid < num_output_group; gid++ {
+				preds[rid*num_output_group+gid] = gbTree.PredArray(feats, math.NAN, gid, 0, ntree_limit)
+			}
+		}
+		for _, fid := range indices {
+			if fid < len(feats) {
+				feats[fid] = math.NAN
+			}
+		}
+	}
+
+	return nil
+}
+
+// sumQuickScorer adds up the exit leaves found by the QuickScorer for each
+// output group, in the same order as tree traversal does.
+func (gbTree *GBTree) sumQuickScorer(bitvectors []uint64, ntree_limit int, preds []float32) {
+	for gid := 0; gid < gbTree.mparam.num_output_group; gid++ {
+		psum := FLOAT_32_0
+		for _, tid := range gbTree.groupTreeIds(gid, ntree_limit) {
+			psum += gbTree._quickScorer.LeafValue(tid, bitvectors)
+		}
+		preds[gid] = psum
+	}
+}
+
+const QUANTIZED_BLOCK_ROWS = 64
+
+// predictDenseQuantized bins a block of rows at a time and then walks each
+// tree over the whole block, adding leaves in the same order as traversal.
+func (gbTree *GBTree) predictDenseQuantized(matrix *data.DenseMatrix, ntree_limit int, preds []float32) {
+	num_output_group := gbTree.mparam.num_output_group
+	numBins := gbTree._quantized.NumBins()
+	bins := make([]uint16, QUANTIZED_BLOCK_ROWS*numBins)
+	for begin := 0; begin < matrix.NumRow; begin += QUANTIZED_BLOCK_ROWS {
+		end := begin + QUANTIZED_BLOCK_ROWS
+		if end > matrix.NumRow {
+			end = matrix.NumRow
+		}
+		for rid := begin; rid < end; rid++ {
+			gbTree._quantized.BinArray(matrix.Row(rid), matrix.Missing, bins[(rid-begin)*numBins:(rid-begin+1)*numBins])
+			for gid := 0; gid < num_output_group; gid++ {
+				preds[rid*num_output_group+gid] = FLOAT_32_0
+			}
+		}
+		for gid := 0; gid < num_output_group; gid++ {
+			for _, tid := range gbTree.groupTreeIds(gid, ntree_limit) {
+				for rid := begin; rid < end; rid++ {
+					preds[rid*num_output_group+gid] += gbTree._quantized.GetLeafByBins(tid, bins[(rid-begin)*numBins:(rid-begin+1)*numBins])
+				}
+			}
+		}
+	}
+}
+
+// sumQuantized adds up the leaves of the quantized forest for each output
+// group, in the same order as tree traversal does.
+func (gbTree *GBTree) sumQuantized(bins []uint16, ntree_limit int, preds []float32) {
+	for gid := 0; gid < gbTree.mparam.num_output_group; gid++ {
+		psum := FLOAT_32_0
+		for _, tid := range gbTree.groupTreeIds(gid, ntree_limit) {
+			psum += gbTree._quantized.GetLeafByBins(tid, bins)
+		}
+		preds[gid] = psum
+	}
+}
+
+// groupTreeIds returns the ids of the first ntree_limit trees of the group,
+// or all of them when ntree_limit is 0.
+func (gbTree *GBTree) groupTreeIds(bst_group, ntree_limit int) []int {
+	treeIds := gbTree._groupTreeIds[bst_group]
+	if ntree_limit != 0 && ntree_limit < len(treeIds) {
+		return treeIds[:ntree_limit]
+	}
+	return treeIds
+}
+
+func (gbTree *GBTree) PredMap(values map[int]float32, bst_group, root_index, ntree_limit int) float32 {
 	psum := FLOAT_32_0
-	for i := 0; i < treeleft; i++ {
-		psum += trees[i].GetLeafByMap(values, root_index)
+	for _, tid := range gbTree.groupTreeIds(bst_group, ntree_limit) {
+		psum += gbTree._forest.GetLeafByMap(tid, values, root_index)
 	}
 
 	return psum
 }
 
-func (gbTree *GBTree) PredArray(values []float32, treatsZeroAsNA bool) float32 {
-	trees := gbTree._groupTrees[0]
-	treeleft := len(trees)
+func (gbTree *GBTree) PredArray(values []float32, missing float32, bst_group, root_index, ntree_limit int) float32 {
 	psum := FLOAT_32_0
-	for i := 0; i < treeleft; i++ {
-		psum += trees[i].GetLeafByArray(values, treatsZeroAsNA)
+	for _, tid := range gbTree.groupTreeIds(bst_group, ntree_limit) {
+		psum += gbTree._forest.GetLeafByArray(tid, values, missing)
 	}
 
 	return psum
 }
 
-func (gbTree *GBTree) PredictLeafFromArray(values []float32, treatsZeroAsNA bool, root_index, ntree_limit int) []int {
-	return gbTree.PredPathArray(values, treatsZeroAsNA, 0, ntree_limit)
+func (gbTree *GBTree) PredictLeafFromArray(values []float32, missing float32, root_index, ntree_limit int) []int {
+	return gbTree.PredPathArray(values, missing, 0, ntree_limit)
 }
 
 func (gbTree *GBTree) PredictLeafFromMap(values map[int]float32, ntree_limit int) []int {
 	return gbTree.PredPathMap(values, 0, ntree_limit)
 }
 
-func (gbTree *GBTree) PredPathArray(values []float32, treatsZeroAsNA bool, root_index, ntree_limit int) []int {
-	var treeleft int
-	if ntree_limit == 0 {
-		treeleft = len(gbTree.trees)
-	} else {
-		treeleft = ntree_limit
-	}
+func (gbTree *GBTree) PredPathArray(values []float32, missing float32, root_index, ntree_limit int) []int {
+	treeleft := gbTree.treeLeft(ntree_limit)
 	leafIndex := make([]int, treeleft)
 	for i := 0; i < treeleft; i++ {
-		leafIndex[i] = gbTree.trees[i].GetLeafIndexByArray(values, treatsZeroAsNA, root_index)
+		leafIndex[i] = gbTree._forest.GetLeafIndexByArray(i, values, missing, root_index)
 	}
 
 	return leafIndex
 }
 
 func (gbTree *GBTree) PredPathMap(values map[int]float32, root_index, ntree_limit int) []int {
-	var treeleft int
-	if ntree_limit == 0 {
-		treeleft = len(gbTree.trees)
-	} else {
-		treeleft = ntree_limit
-	}
+	treeleft := gbTree.treeLeft(ntree_limit)
 	leafIndex := make([]int, treeleft)
 	for i := 0; i < treeleft; i++ {
-		leafIndex[i] = gbTree.trees[i].GetLeafIndexByMap(values, root_index)
+		leafIndex[i] = gbTree._forest.GetLeafIndexByMap(i, values, root_index)
 	}
 
 	return leafIndex
 }
 
+// ContributionWidth returns the number of feature columns of
+// PredictContributions: num_feature, widened if a split refers to a larger
+// index.
+func (gbTree *GBTree) ContributionWidth() int {
+	return gbTree._contributionWidth
+}
+
+func (gbTree *GBTree) contributionWidth() int {
+	width := gbTree.mparam.num_feature
+	for _, rt := range gbTree.trees {
+		for nid := 0; nid < rt.NumNodes(); nid++ {
+			n := rt.Node(nid)
+			if !n.IsLeaf() && n.SplitIndex() >= width {
+				width = n.SplitIndex() + 1
+			}
+		}
+	}
+	return width
+}
+
+// PredictContributions returns the SHAP contribution of every feature to the
+// margin of every output group, computed with TreeSHAP. Each group takes
+// ContributionWidth()+1 values; the last one is the expected margin, without
+// base_score. It needs the node covers of the trees, so it fails for a
+// GBTree built from a forest.
+func (gbTree *GBTree) PredictContributions(values []float32, missing float32, ntree_limit int) ([]float32, error) {
+	if gbTree.trees == nil {
+		return nil, fmt.Errorf("Contributions need tree statistics, which this model does not have.")
+	}
+	gbTree._meanOnce.Do(func() {
+		gbTree._meanValues = make([][]float32, len(gbTree.trees))
+		for tid, rt := range gbTree.trees {
+			gbTree._meanValues[tid] = rt.NodeMeanValues()
+		}
+	})
+	width := gbTree.ContributionWidth() + 1
+	contribs := make([]float32, width*gbTree.mparam.num_output_group)
+	for gid := 0; gid < gbTree.mparam.num_output_group; gid++ {
+		phi := contribs[gid*width : (gid+1)*width]
+		for _, tid := range gbTree.groupTreeIds(gid, ntree_limit) {
+			gbTree.trees[tid].CalculateContributions(values, missing, gbTree._meanValues[tid], phi)
+		}
+	}
+	return contribs, nil
+}
+
+func (gbTree *GBTree) treeLeft(ntree_limit int) int {
+	if ntree_limit == 0 || ntree_limit > gbTree._forest.NumTrees() {
+		return gbTree._forest.NumTrees()
+	}
+	return ntree_limit
+}
+
 type GBTreeParam struct {
 	num_trees        int
 	num_roots        int
@@ -175,39 +531,48 @@ func newGBTreeParam(reader *util.ModelReader) (*GBTreeParam, error) {
 	var err error
 	gbTreeParam.num_trees, err = reader.ReadInt()
 	if err != nil {
-		return gbTreeParam, err
+		return gbTreeParam, reader.FieldError("num_trees", err)
+	}
+	if gbTreeParam.num_trees < 0 {
+		return gbTreeParam, reader.FieldError("num_trees", fmt.Errorf("Invalid num_trees: %d", gbTreeParam.num_trees))
 	}
 	gbTreeParam.num_roots, err = reader.ReadInt()
 	if err != nil {
-		return gbTreeParam, err
+		return gbTreeParam, reader.FieldError("num_roots", err)
 	}
 	gbTreeParam.num_feature, err = reader.ReadInt()
 	if err != nil {
-		return gbTreeParam, err
+		return gbTreeParam, reader.FieldError("num_feature", err)
+	}
+	if gbTreeParam.num_feature < 0 {
+		return gbTreeParam, reader.FieldError("num_feature", fmt.Errorf("Invalid num_feature: %d", gbTreeParam.num_feature))
 	}
 	_, err = reader.ReadInt()
 	if err != nil {
-		return gbTreeParam, err
+		return gbTreeParam, reader.FieldError("pad_32bit", err)
 	}
 	gbTreeParam.num_pbuffer, err = reader.ReadInt64()
 	if err != nil {
-		return gbTreeParam, err
+		return gbTreeParam, reader.FieldError("num_pbuffer", err)
 	}
 	gbTreeParam.num_output_group, err = reader.ReadInt()
 	if err != nil {
-		return gbTreeParam, err
+		return gbTreeParam, reader.FieldError("num_output_group", err)
+	}
+	if gbTreeParam.num_output_group < 1 || gbTreeParam.num_output_group > MAX_OUTPUT_GROUPS {
+		return gbTreeParam, reader.FieldError("num_output_group", fmt.Errorf("Invalid num_output_group: %d", gbTreeParam.num_output_group))
 	}
 	gbTreeParam.size_leaf_vector, err = reader.ReadInt()
 	if err != nil {
-		return gbTreeParam, err
+		return gbTreeParam, reader.FieldError("size_leaf_vector", err)
 	}
 	gbTreeParam.reserved, err = reader.ReadIntArray(31)
 	if err != nil {
-		return gbTreeParam, err
+		return gbTreeParam, reader.FieldError("reserved", err)
 	}
 	_, err = reader.ReadInt()
 	if err != nil {
-		return gbTreeParam, err
+		return gbTreeParam, reader.FieldError("reserved", err)
 	}
 	return gbTreeParam, nil
 }
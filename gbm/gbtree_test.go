@@ -0,0 +1,242 @@
+package gbm_test
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/internal/testmodel"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/tree"
+)
+
+var nan = float32(math.NaN())
+
+func loadGBTree(tb testing.TB, options testmodel.Options, evaluator string) *gbm.GBTree {
+	tb.Helper()
+	configuration := *config.DEFAULT
+	configuration.Evaluator = evaluator
+	p, err := predictor.NewPredictorByConf(*bufio.NewReader(bytes.NewReader(testmodel.Model(options))), configuration)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return p.Gbm.(*gbm.GBTree)
+}
+
+var multiGroup = testmodel.Options{Seed: 1, NumTrees: 20, NumGroups: 3, NumFeature: 8, Depth: 4, Objective: "multi:softprob"}
+
+// TestPredictArrayGroups sums every group's trees through RegTree traversal.
+// PredictArray used to return the sum of group 0 for every group.
+func TestPredictArrayGroups(t *testing.T) {
+	gbTree := loadGBTree(t, multiGroup, config.EVALUATOR_TRAVERSAL)
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 100; i++ {
+		row := testmodel.Row(r, multiGroup.NumFeature)
+		expected := make([]float32, gbTree.NumOutputGroup())
+		for tid, rt := range gbTree.Trees() {
+			expected[gbTree.TreeInfo()[tid]] += rt.GetLeafByArray(row, nan)
+		}
+		values := make(map[int]float32)
+		for fid, value := range row {
+			if value == value {
+				values[fid] = value
+			}
+		}
+		for name, got := range map[string][]float32{
+			"PredictArray": gbTree.PredictArray(row, nan, 0),
+			"PredictMap":   gbTree.PredictMap(values, 0),
+		} {
+			for gid := range expected {
+				if math.Float32bits(got[gid]) != math.Float32bits(expected[gid]) {
+					t.Fatalf("row %d: %s group %d = %v, want %v", i, name, gid, got[gid], expected[gid])
+				}
+			}
+		}
+	}
+}
+
+func TestPredictSingleMultiGroup(t *testing.T) {
+	row := testmodel.Row(rand.New(rand.NewSource(3)), multiGroup.NumFeature)
+	for _, evaluator := range evaluators {
+		gbTree := loadGBTree(t, multiGroup, evaluator)
+		if got := gbTree.PredictSingleFromArray(row, nan); got == got {
+			t.Errorf("%s: PredictSingleFromArray = %v, want NaN", evaluator, got)
+		}
+		if got := gbTree.PredictSingleFromMap(map[int]float32{0: row[0]}); got == got {
+			t.Errorf("%s: PredictSingleFromMap = %v, want NaN", evaluator, got)
+		}
+	}
+}
+
+var evaluators = []string{config.EVALUATOR_TRAVERSAL, config.EVALUATOR_QUICKSCORER, config.EVALUATOR_QUANTIZED}
+
+// TestEvaluatorsMatchTraversal requires every evaluator to return the
+// margins of traversal bit for bit.
+func TestEvaluatorsMatchTraversal(t *testing.T) {
+	options := multiGroup
+	options.Depth = 6
+	traversal := loadGBTree(t, options, config.EVALUATOR_TRAVERSAL)
+	rows := testRows(options.NumFeature)
+	matrix := denseMatrix(t, rows, options.NumFeature)
+	expected := make([]float32, len(rows)*options.NumGroups)
+	err := traversal.PredictDense(matrix, 0, expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, evaluator := range evaluators[1:] {
+		gbTree := loadGBTree(t, options, evaluator)
+		preds := make([]float32, len(expected))
+		err := gbTree.PredictDense(matrix, 0, preds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, row := range rows {
+			got := gbTree.PredictArray(row, nan, 0)
+			for gid := range got {
+				want := expected[i*options.NumGroups+gid]
+				if math.Float32bits(got[gid]) != math.Float32bits(want) {
+					t.Fatalf("%s row %d group %d: PredictArray = %v, traversal = %v", evaluator, i, gid, got[gid], want)
+				}
+				if math.Float32bits(preds[i*options.NumGroups+gid]) != math.Float32bits(want) {
+					t.Fatalf("%s row %d group %d: PredictDense = %v, traversal = %v", evaluator, i, gid, preds[i*options.NumGroups+gid], want)
+				}
+			}
+		}
+	}
+}
+
+func testRows(num_feature int) [][]float32 {
+	r := rand.New(rand.NewSource(4))
+	rows := make([][]float32, 1000)
+	for i := range rows {
+		rows[i] = testmodel.Row(r, num_feature)
+	}
+	return rows
+}
+
+func denseMatrix(tb testing.TB, rows [][]float32, num_feature int) *data.DenseMatrix {
+	tb.Helper()
+	var values []float32
+	for _, row := range rows {
+		values = append(values, row...)
+	}
+	matrix, err := data.NewDenseMatrix(values, len(rows), num_feature, nan)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return matrix
+}
+
+// benchModel is a model of the size where the layout of the trees matters,
+// with few enough leaves per tree for QuickScorer.
+var benchModel = testmodel.Options{Seed: 1, NumTrees: 1000, NumGroups: 1, NumFeature: 50, Depth: 6, Objective: "binary:logistic"}
+
+// The benchmarks run once per evaluator, e.g.
+//
+//	go test ./gbm -run '^$' -bench 'PredictArray/quantized'
+//
+// The regtree sub-benchmarks walk the pointer-based RegTree nodes instead,
+// as a baseline for the flattened forest.
+func BenchmarkPredictArray(b *testing.B) {
+	rows := testRows(benchModel.NumFeature)
+	b.Run("regtree", func(b *testing.B) {
+		trees := loadGBTree(b, benchModel, config.EVALUATOR_TRAVERSAL).Trees()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sumLeaves(trees, rows[i%len(rows)])
+		}
+	})
+	for _, evaluator := range evaluators {
+		b.Run(evaluator, func(b *testing.B) {
+			gbTree := loadGBTree(b, benchModel, evaluator)
+			preds := make([]float32, 1)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				gbTree.PredictArrayInto(rows[i%len(rows)], nan, 0, preds)
+			}
+		})
+	}
+}
+
+func BenchmarkPredictMap(b *testing.B) {
+	var rows []map[int]float32
+	for _, row := range testRows(benchModel.NumFeature) {
+		values := make(map[int]float32)
+		for fid, value := range row {
+			if value == value {
+				values[fid] = value
+			}
+		}
+		rows = append(rows, values)
+	}
+	b.Run("regtree", func(b *testing.B) {
+		trees := loadGBTree(b, benchModel, config.EVALUATOR_TRAVERSAL).Trees()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sumMapLeaves(trees, rows[i%len(rows)])
+		}
+	})
+	for _, evaluator := range evaluators {
+		b.Run(evaluator, func(b *testing.B) {
+			gbTree := loadGBTree(b, benchModel, evaluator)
+			preds := make([]float32, 1)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				gbTree.PredictMapInto(rows[i%len(rows)], 0, preds)
+			}
+		})
+	}
+}
+
+// BenchmarkPredictDense reports the time per 1000-row matrix.
+func BenchmarkPredictDense(b *testing.B) {
+	rows := testRows(benchModel.NumFeature)
+	matrix := denseMatrix(b, rows, benchModel.NumFeature)
+	b.Run("regtree", func(b *testing.B) {
+		trees := loadGBTree(b, benchModel, config.EVALUATOR_TRAVERSAL).Trees()
+		preds := make([]float32, len(rows))
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for rid := range preds {
+				preds[rid] = sumLeaves(trees, matrix.Row(rid))
+			}
+		}
+	})
+	for _, evaluator := range evaluators {
+		b.Run(evaluator, func(b *testing.B) {
+			gbTree := loadGBTree(b, benchModel, evaluator)
+			preds := make([]float32, len(rows))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				gbTree.PredictDense(matrix, 0, preds)
+			}
+		})
+	}
+}
+
+func sumLeaves(trees []*tree.RegTree, row []float32) float32 {
+	var psum float32
+	for _, rt := range trees {
+		psum += rt.GetLeafByArray(row, nan)
+	}
+	return psum
+}
+
+func sumMapLeaves(trees []*tree.RegTree, values map[int]float32) float32 {
+	var psum float32
+	for _, rt := range trees {
+		psum += rt.GetLeafByMap(values, 0)
+	}
+	return psum
+}
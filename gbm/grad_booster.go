@@ -2,18 +2,30 @@ package gbm
 
 import (
 	"xgboost4go-predictor/util"
+	"xgboost4go-predictor/data"
 	"fmt"
 )
 
 const FLOAT_32_0 = float32(0.0)
 
+// MAX_OUTPUT_GROUPS bounds num_output_group, which sizes per-group tables
+// and every prediction, so that a corrupt model cannot make them huge.
+const MAX_OUTPUT_GROUPS = 1 << 16
+
 type GradBooster interface {
 	SetNumClass(num_class int)
 	LoadModel(modelReader *util.ModelReader, with_pbuffer bool) error
-	PredictArray(values []float32, treatsZeroAsNA bool, ntree_limit int) []float32
+	PredictArray(values []float32, missing float32, ntree_limit int) []float32
 	PredictMap(values map[int]float32, ntree_limit int) []float32
-	PredictSingleFromArray(values []float32, treatsZeroAsNA bool) float32
+	PredictArrayInto(values []float32, missing float32, ntree_limit int, preds []float32) error
+	PredictMapInto(values map[int]float32, ntree_limit int, preds []float32) error
+	PredictSingleFromArray(values []float32, missing float32) float32
 	PredictSingleFromMap(values map[int]float32) float32
+	NumOutputGroup() int
+	PredictDense(matrix *data.DenseMatrix, ntree_limit int, preds []float32) error
+	PredictCSR(matrix *data.CSRMatrix, ntree_limit int, preds []float32) error
+	SizeBytes() int
+	DumpModel(fmap *util.FeatureMap, with_stats bool, format string) ([]string, error)
 }
 
 func CreateGradBooster(name string) (GradBooster, error) {
@@ -33,3 +45,10 @@ type GBBase struct {
 func (g GBBase) SetNumClass(num_class int) {
 	(&g).NumClass = num_class
 }
+
+func checkPredsSize(preds []float32, nrow, num_output_group int) error {
+	if len(preds) < nrow*num_output_group {
+		return fmt.Errorf("Prediction buffer is too small: expected = %d, actual = %d", nrow*num_output_group, len(preds))
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package gbm
+
+import (
+	"fmt"
+)
+
+// Importance types, as in XGBoost's get_score.
+const (
+	IMPORTANCE_WEIGHT      = "weight"
+	IMPORTANCE_GAIN        = "gain"
+	IMPORTANCE_COVER       = "cover"
+	IMPORTANCE_TOTAL_GAIN  = "total_gain"
+	IMPORTANCE_TOTAL_COVER = "total_cover"
+)
+
+// FeatureImportance returns the importance of every feature used in a split:
+// the number of splits for weight, the summed or (for gain and cover)
+// averaged loss change or hessian of the splits otherwise. It needs the
+// node statistics, which compact models do not keep.
+func (gbTree *GBTree) FeatureImportance(importance_type string) (map[int]float32, error) {
+	switch importance_type {
+	case IMPORTANCE_WEIGHT, IMPORTANCE_GAIN, IMPORTANCE_COVER, IMPORTANCE_TOTAL_GAIN, IMPORTANCE_TOTAL_COVER:
+	default:
+		return nil, fmt.Errorf("Unknown importance type: %s", importance_type)
+	}
+	if gbTree.trees == nil && gbTree._forest.NumTrees() != 0 {
+		return nil, fmt.Errorf("Model has no node statistics.")
+	}
+	splits := make(map[int]int)
+	totals := make(map[int]float32)
+	for _, rt := range gbTree.trees {
+		rt.Walk(func(nid, depth int) {
+			node := rt.Node(nid)
+			if node.IsLeaf() {
+				return
+			}
+			fid := node.SplitIndex()
+			splits[fid]++
+			switch importance_type {
+			case IMPORTANCE_GAIN, IMPORTANCE_TOTAL_GAIN:
+				totals[fid] += rt.Stat(nid).Loss_chg
+			case IMPORTANCE_COVER, IMPORTANCE_TOTAL_COVER:
+				totals[fid] += rt.Stat(nid).Sum_hess
+			}
+		})
+	}
+	importance := make(map[int]float32, len(splits))
+	for fid, count := range splits {
+		switch importance_type {
+		case IMPORTANCE_WEIGHT:
+			importance[fid] = float32(count)
+		case IMPORTANCE_GAIN, IMPORTANCE_COVER:
+			importance[fid] = totals[fid] / float32(count)
+		default:
+			importance[fid] = totals[fid]
+		}
+	}
+	return importance, nil
+}
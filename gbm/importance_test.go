@@ -0,0 +1,92 @@
+package gbm_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/internal/testmodel"
+	"xgboost4go-predictor/predictor"
+)
+
+// importanceModel has four splits: f0 with gain 10 and cover 20, f1 with
+// gains 4 and 6 and covers 8 and 12, and f2 with gain 1 and cover 5. The
+// statistics of the leaves must not count.
+func importanceModel() []byte {
+	return testmodel.Encode(testmodel.Spec{
+		BaseScore:  0.5,
+		NumFeature: 3,
+		Objective:  "reg:linear",
+		Trees: []testmodel.Tree{{Depth: 2, Nodes: []testmodel.Node{
+			{Parent: -1, Left: 1, Right: 2, Feature: 0, Gain: 10, Cover: 20},
+			{Parent: 0, Left: -1, Right: -1, Value: 1, Gain: 99, Cover: 12},
+			{Parent: 0, Left: 3, Right: 4, Feature: 1, Gain: 4, Cover: 8},
+			{Parent: 2, Left: -1, Right: -1, Value: 2, Gain: 99, Cover: 3},
+			{Parent: 2, Left: -1, Right: -1, Value: 3, Gain: 99, Cover: 5},
+		}}, {Depth: 2, Nodes: []testmodel.Node{
+			{Parent: -1, Left: 1, Right: 2, Feature: 1, Gain: 6, Cover: 12},
+			{Parent: 0, Left: 3, Right: 4, Feature: 2, Gain: 1, Cover: 5},
+			{Parent: 0, Left: -1, Right: -1, Value: 1, Gain: 99, Cover: 7},
+			{Parent: 1, Left: -1, Right: -1, Value: 2, Gain: 99, Cover: 2},
+			{Parent: 1, Left: -1, Right: -1, Value: 3, Gain: 99, Cover: 3},
+		}}},
+	})
+}
+
+func TestFeatureImportance(t *testing.T) {
+	p, err := predictor.NewPredictorByReader(*bufio.NewReader(bytes.NewReader(importanceModel())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gbTree := p.Gbm.(*gbm.GBTree)
+	cases := []struct {
+		importance_type string
+		expected        map[int]float32
+	}{
+		{gbm.IMPORTANCE_WEIGHT, map[int]float32{0: 1, 1: 2, 2: 1}},
+		{gbm.IMPORTANCE_GAIN, map[int]float32{0: 10, 1: 5, 2: 1}},
+		{gbm.IMPORTANCE_COVER, map[int]float32{0: 20, 1: 10, 2: 5}},
+		{gbm.IMPORTANCE_TOTAL_GAIN, map[int]float32{0: 10, 1: 10, 2: 1}},
+		{gbm.IMPORTANCE_TOTAL_COVER, map[int]float32{0: 20, 1: 20, 2: 5}},
+	}
+	for _, c := range cases {
+		importance, err := gbTree.FeatureImportance(c.importance_type)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(importance) != fmt.Sprint(c.expected) {
+			t.Errorf("%s: got %v, want %v", c.importance_type, importance, c.expected)
+		}
+	}
+	_, err = gbTree.FeatureImportance("frequency")
+	if err == nil {
+		t.Error("unknown importance type was accepted")
+	}
+
+	// Compact models keep no node statistics.
+	path := filepath.Join(t.TempDir(), "model.flat")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.WriteCompact(file)
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact, err := predictor.NewPredictorByMmap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer compact.Close()
+	_, err = compact.Gbm.(*gbm.GBTree).FeatureImportance(gbm.IMPORTANCE_WEIGHT)
+	if err == nil || err.Error() != "Model has no node statistics." {
+		t.Errorf("compact model: got %v", err)
+	}
+}
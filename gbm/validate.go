@@ -0,0 +1,42 @@
+package gbm
+
+import (
+	"fmt"
+)
+
+// Validate checks every tree with tree.RegTree.Validate and that every tree
+// belongs to an output group. LoadModel calls it, so a corrupt model fails
+// to load instead of panicking at prediction time.
+func (gbTree *GBTree) Validate() error {
+	num_group := gbTree.mparam.num_output_group
+	if num_group < 1 {
+		return fmt.Errorf("Invalid num_output_group: %d", num_group)
+	}
+	num_trees := len(gbTree.tree_info)
+	if gbTree.trees != nil && len(gbTree.trees) != num_trees {
+		return fmt.Errorf("Model has %d trees but tree_info for %d.", len(gbTree.trees), num_trees)
+	}
+	for tid, gid := range gbTree.tree_info {
+		if gid < 0 || gid >= num_group {
+			return fmt.Errorf("Tree %d belongs to group %d, num_output_group is %d.", tid, gid, num_group)
+		}
+	}
+	for tid, rt := range gbTree.trees {
+		err := rt.Validate(gbTree.mparam.num_feature)
+		if err != nil {
+			return fmt.Errorf("Tree %d: %v", tid, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that there is a weight for every feature and output group
+// and a bias for every group.
+func (gbLinear *GBLinear) Validate() error {
+	expected := (gbLinear.mparam.num_feature + 1) * gbLinear.mparam.num_output_group
+	if gbLinear.mparam.num_output_group < 1 || len(gbLinear.weights) != expected {
+		return fmt.Errorf("gblinear has %d weights, expected %d for %d features and %d output groups.",
+			len(gbLinear.weights), expected, gbLinear.mparam.num_feature, gbLinear.mparam.num_output_group)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package gbm_test
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/internal/testmodel"
+	"xgboost4go-predictor/predictor"
+)
+
+func validNodes() []testmodel.Node {
+	return []testmodel.Node{
+		{Parent: -1, Left: 1, Right: 2, Feature: 0, Value: 1},
+		{Parent: 0, Left: -1, Right: -1, Value: 0.5},
+		{Parent: 0, Left: 3, Right: 4, Feature: 1, Value: 2},
+		{Parent: 2, Left: -1, Right: -1, Value: -0.25},
+		{Parent: 2, Left: -1, Right: -1, Value: 1},
+	}
+}
+
+// TestValidate loads hand-corrupted models, which must fail with an error
+// instead of loading and panicking at prediction time.
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(spec *testmodel.Spec)
+		err    string
+	}{
+		{"valid", func(spec *testmodel.Spec) {}, ""},
+		{"child out of range", func(spec *testmodel.Spec) {
+			spec.Trees[0].Nodes[2].Right = 5
+		}, "Tree 0: Node 2 has child 5, out of range [0, 5)."},
+		{"negative child", func(spec *testmodel.Spec) {
+			spec.Trees[0].Nodes[0].Right = -2
+		}, "Tree 0: Node 0 has child -2, out of range [0, 5)."},
+		{"bad parent", func(spec *testmodel.Spec) {
+			spec.Trees[0].Nodes[3].Parent = 1
+		}, "Tree 0: Node 3 has parent 1, expected 2."},
+		{"cycle", func(spec *testmodel.Spec) {
+			nodes := spec.Trees[0].Nodes
+			nodes[4] = testmodel.Node{Parent: 2, Left: 2, Right: 1, Feature: 0}
+		}, "Tree 0: Node 2 has parent 0, expected 4."},
+		{"leaf with a right child", func(spec *testmodel.Spec) {
+			spec.Trees[0].Nodes[1].Right = 3
+		}, "Tree 0: Leaf node 1 has a right child 3."},
+		{"split index beyond num_feature", func(spec *testmodel.Spec) {
+			spec.Trees[0].Nodes[2].Feature = 4
+		}, "Tree 0: Node 2 splits on feature 4, num_feature is 4."},
+		{"tree group beyond num_output_group", func(spec *testmodel.Spec) {
+			spec.NumGroups = 2
+			spec.Objective = "multi:softprob"
+			spec.TreeInfo = []int{2}
+		}, "Tree 0 belongs to group 2, num_output_group is 2."},
+		{"negative tree group", func(spec *testmodel.Spec) {
+			spec.TreeInfo = []int{-1}
+		}, "Tree 0 belongs to group -1, num_output_group is 1."},
+		{"gblinear weight count", func(spec *testmodel.Spec) {
+			spec.Trees = nil
+			spec.Weights = []float32{1, 2, 3, 4}
+		}, "num_weights at offset 306: gblinear has 4 weights, expected 5 for 4 features and 1 output groups."},
+		{"gblinear weight count for groups", func(spec *testmodel.Spec) {
+			spec.Trees = nil
+			spec.NumGroups = 2
+			spec.Objective = "multi:softprob"
+			spec.Weights = []float32{1, 2, 3, 4, 5}
+		}, "gblinear has 5 weights, expected 10 for 4 features and 2 output groups."},
+	}
+	for _, c := range cases {
+		spec := testmodel.Spec{
+			BaseScore:  0.5,
+			NumFeature: 4,
+			Objective:  "reg:linear",
+			Trees:      []testmodel.Tree{{Nodes: validNodes(), Depth: 2}},
+		}
+		c.modify(&spec)
+		_, err := predictor.NewPredictorByReader(*bufio.NewReader(bytes.NewReader(testmodel.Encode(spec))))
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("%s: %v", c.name, err)
+		case c.err != "" && err == nil:
+			t.Errorf("%s: loaded", c.name)
+		case c.err != "" && !strings.Contains(err.Error(), c.err):
+			t.Errorf("%s: got %q, want %q", c.name, err, c.err)
+		}
+	}
+}
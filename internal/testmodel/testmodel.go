@@ -0,0 +1,204 @@
+// Package testmodel writes random or hand-written models in the legacy
+// binary format for tests and benchmarks. The thresholds of random trees are
+// multiples of 0.25 in [-5, 5], so rows drawn from Row often hit them
+// exactly, and default directions are random.
+package testmodel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+)
+
+type Options struct {
+	Seed       int64
+	NumTrees   int // per output group
+	NumGroups  int
+	NumFeature int
+	Depth      int
+	NumRoots   int // per tree, 1 when 0
+	Objective  string
+}
+
+// Node is a node of a hand-written tree. Leaves have Left and Right -1 and
+// their value in Value; splits compare Feature with Value. Gain and Cover
+// are stored as loss_chg and sum_hess.
+type Node struct {
+	Parent, Left, Right int32
+	Feature             int
+	DefaultLeft         bool
+	Value               float32
+	Gain, Cover         float32
+}
+
+type Tree struct {
+	Nodes    []Node
+	NumRoots int // 1 when 0
+	Depth    int
+}
+
+// Spec describes a model to encode. The booster is gblinear when Weights is
+// set, with the weights stored as they are, and gbtree otherwise. Trees
+// belong to group tid % NumGroups unless TreeInfo is set.
+type Spec struct {
+	BaseScore    float32
+	MajorVersion int
+	NumFeature   int
+	NumGroups    int
+	Objective    string
+	Trees        []Tree
+	TreeInfo     []int
+	Weights      []float32
+}
+
+type writer struct {
+	buf bytes.Buffer
+}
+
+func (w *writer) write(values ...interface{}) {
+	for _, v := range values {
+		binary.Write(&w.buf, binary.LittleEndian, v)
+	}
+}
+
+func (w *writer) str(s string) {
+	w.write(int64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// Encode writes spec in the legacy binary format.
+func Encode(spec Spec) []byte {
+	w := new(writer)
+	num_groups := spec.NumGroups
+	if num_groups < 1 {
+		num_groups = 1
+	}
+	num_class := int32(0)
+	if num_groups > 1 {
+		num_class = int32(num_groups)
+	}
+	reserved := make([]int32, 30)
+	reserved[1] = int32(spec.MajorVersion)
+	w.write(spec.BaseScore, int32(spec.NumFeature), num_class, int32(0), reserved)
+	w.str(spec.Objective)
+	if spec.Weights != nil {
+		w.str("gblinear")
+		w.write(int32(spec.NumFeature), int32(num_groups), make([]int32, 32), int64(len(spec.Weights)), spec.Weights)
+		return w.buf.Bytes()
+	}
+	w.str("gbtree")
+	w.write(int32(len(spec.Trees)), int32(1), int32(spec.NumFeature), int32(0), int64(0), int32(num_groups), int32(0), make([]int32, 32))
+	for _, t := range spec.Trees {
+		w.tree(t, spec.NumFeature)
+	}
+	for tid := range spec.Trees {
+		if spec.TreeInfo != nil {
+			w.write(int32(spec.TreeInfo[tid]))
+		} else {
+			w.write(int32(tid % num_groups))
+		}
+	}
+	return w.buf.Bytes()
+}
+
+func (w *writer) tree(t Tree, num_feature int) {
+	num_roots := t.NumRoots
+	if num_roots < 1 {
+		num_roots = 1
+	}
+	w.write(int32(num_roots), int32(len(t.Nodes)), int32(0), int32(t.Depth), int32(num_feature), int32(0), make([]int32, 31))
+	for _, n := range t.Nodes {
+		sindex := uint32(n.Feature)
+		if n.DefaultLeft {
+			sindex |= 1 << 31
+		}
+		w.write(n.Parent, n.Left, n.Right, sindex, n.Value)
+	}
+	for _, n := range t.Nodes {
+		w.write(n.Gain, n.Cover, float32(0), int32(0))
+	}
+}
+
+// Model returns a random model saved as by XGBoost 1.0, with base_score 0.5.
+func Model(options Options) []byte {
+	r := rand.New(rand.NewSource(options.Seed))
+	spec := Spec{
+		BaseScore:    0.5,
+		MajorVersion: 1,
+		NumFeature:   options.NumFeature,
+		NumGroups:    options.NumGroups,
+		Objective:    options.Objective,
+	}
+	for tid := 0; tid < options.NumTrees*options.NumGroups; tid++ {
+		spec.Trees = append(spec.Trees, randomTree(r, options.NumFeature, options.Depth, options.NumRoots))
+	}
+	return Encode(spec)
+}
+
+// Trees returns num_trees random trees as stored in a model, one after
+// another.
+func Trees(seed int64, num_trees, num_feature, depth int) []byte {
+	r := rand.New(rand.NewSource(seed))
+	trees := make([]Tree, num_trees)
+	for tid := range trees {
+		trees[tid] = randomTree(r, num_feature, depth, 1)
+	}
+	return EncodeTrees(num_feature, trees...)
+}
+
+// EncodeTrees returns trees as stored in a model, one after another.
+func EncodeTrees(num_feature int, trees ...Tree) []byte {
+	w := new(writer)
+	for _, t := range trees {
+		w.tree(t, num_feature)
+	}
+	return w.buf.Bytes()
+}
+
+func randomTree(r *rand.Rand, num_feature, depth, num_roots int) Tree {
+	if num_roots < 1 {
+		num_roots = 1
+	}
+	nodes := make([]Node, num_roots)
+	for root := range nodes {
+		nodes[root].Parent = -1
+	}
+	var grow func(nid, d int, cover float32)
+	grow = func(nid, d int, cover float32) {
+		nodes[nid].Gain = 1
+		nodes[nid].Cover = cover
+		if d >= depth || (d > 0 && r.Float32() < 0.25) {
+			nodes[nid].Left, nodes[nid].Right = -1, -1
+			nodes[nid].Value = float32(r.NormFloat64() * 0.3)
+			return
+		}
+		nodes[nid].Feature = r.Intn(num_feature)
+		nodes[nid].DefaultLeft = r.Intn(2) == 0
+		nodes[nid].Value = float32(math.Round(r.Float64()*40-20) / 4)
+		left := int32(len(nodes))
+		nodes = append(nodes, Node{Parent: int32(nid)}, Node{Parent: int32(nid)})
+		nodes[nid].Left, nodes[nid].Right = left, left+1
+		frac := float32(0.2 + 0.6*r.Float64())
+		grow(int(left), d+1, cover*frac)
+		grow(int(left)+1, d+1, cover*(1-frac))
+	}
+	for root := 0; root < num_roots; root++ {
+		grow(root, 0, 1000)
+	}
+	return Tree{Nodes: nodes, NumRoots: num_roots, Depth: depth}
+}
+
+// Row returns a dense row of num_feature values that are multiples of 0.25
+// in [-5, 5], with about a tenth of them NaN.
+func Row(r *rand.Rand, num_feature int) []float32 {
+	row := make([]float32, num_feature)
+	for fid := range row {
+		if r.Intn(10) == 0 {
+			row[fid] = float32(math.NaN())
+		} else {
+			row[fid] = float32(math.Round(r.Float64()*40-20) / 4)
+		}
+	}
+	return row
+}
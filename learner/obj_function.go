@@ -2,6 +2,7 @@ package learner
 
 import (
 	"fmt"
+	stdmath "math"
 	"xgboost4go-predictor/math"
 )
 
@@ -10,7 +11,7 @@ var FUNCTIONS = make(map[string]ObjFunction)
 func init() {
 	Register("rank:pairwise", new(DefaultObjFunction))
 	Register("binary:logistic", new(RegLossObjLogistic))
-	Register("binary:logitraw", new(DefaultObjFunction))
+	Register("binary:logitraw", new(RegLossObjLogitRaw))
 	Register("multi:softmax", new(SoftmaxMultiClassObjClassify))
 	Register("multi:softprob", new(SoftmaxMultiClassObjProb))
 	Register("reg:linear", new(DefaultObjFunction))
@@ -44,6 +45,14 @@ type ObjFunction interface {
 	PredTransform(preds []float32) []float32
 }
 
+// BaseScoreConverter is implemented by objectives whose base_score is not a
+// margin. XGBoost 1.0 and later save base_score in prediction space, e.g. as
+// a probability for binary:logistic, and convert it with ProbToMargin on
+// load; objectives that do not implement it use base_score as is.
+type BaseScoreConverter interface {
+	ProbToMargin(base_score float32) (float32, error)
+}
+
 type DefaultObjFunction struct {
 }
 
@@ -124,7 +133,15 @@ func (smcoc SoftmaxMultiClassObjClassify) PredTransform(preds []float32) []float
 		}
 	}
 
-	return append(make([]float32, 1), float32(maxIndex))
+	// The class index is returned after a 0, as it always has been. The
+	// margins are overwritten so that predicting into a buffer does not
+	// allocate.
+	if len(preds) < 2 {
+		return append(make([]float32, 1), float32(maxIndex))
+	}
+	preds[0] = 0
+	preds[1] = float32(maxIndex)
+	return preds[:2]
 }
 
 type RegLossObjLogistic struct {
@@ -134,6 +151,10 @@ func (rlol RegLossObjLogistic) PredTransformSingle(pred float32) float32 {
 	return rlol.Sigmoid(pred)
 }
 
+func (rlol RegLossObjLogistic) ProbToMargin(base_score float32) (float32, error) {
+	return logit(base_score)
+}
+
 func (rlol RegLossObjLogistic) PredTransform(preds []float32) []float32 {
 	for i := 0; i < len(preds); i++ {
 		preds[i] = rlol.Sigmoid(preds[i])
@@ -153,6 +174,10 @@ func (rlolj RegLossObjLogisticJafama) PredTransformSingle(pred float32) float32
 	return rlolj.Sigmoid(pred)
 }
 
+func (rlolj RegLossObjLogisticJafama) ProbToMargin(base_score float32) (float32, error) {
+	return logit(base_score)
+}
+
 func (rlolj RegLossObjLogisticJafama) PredTransform(preds []float32) []float32 {
 	for i := 0; i < len(preds); i++ {
 		preds[i] = rlolj.Sigmoid(preds[i])
@@ -164,3 +189,22 @@ func (rlolj RegLossObjLogisticJafama) PredTransform(preds []float32) []float32 {
 func (rlolj RegLossObjLogisticJafama) Sigmoid(x float32) float32 {
 	return 1.0 / (1.0 + math.ExpFloat32(-x))
 }
+
+// RegLossObjLogitRaw outputs logistic margins untransformed, but like
+// binary:logistic its base_score is a probability.
+type RegLossObjLogitRaw struct {
+	DefaultObjFunction
+}
+
+func (rlolr RegLossObjLogitRaw) ProbToMargin(base_score float32) (float32, error) {
+	return logit(base_score)
+}
+
+// logit rejects probabilities outside (0, 1), as XGBoost does, instead of
+// returning an infinite or NaN margin.
+func logit(p float32) (float32, error) {
+	if !(p > 0 && p < 1) {
+		return 0, fmt.Errorf("Invalid base_score for logistic loss: %v, expected a value in (0, 1)", p)
+	}
+	return float32(-stdmath.Log(float64(1.0/p - 1.0))), nil
+}
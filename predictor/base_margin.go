@@ -0,0 +1,70 @@
+package predictor
+
+import (
+	"fmt"
+)
+
+// The *WithBaseMargin variants start every output group from the given
+// margin instead of Mparam.base_score, like base_margin on an XGBoost
+// DMatrix. Multi-row margins are laid out row-major, one value per output
+// group.
+
+func (predictor *Predictor) PredictArrayWithBaseMargin(values []float32, treatsZeroAsNA bool, base_margin []float32, output_margin bool) ([]float32, error) {
+	err := predictor.checkBaseMargin(base_margin, 1)
+	if err != nil {
+		return nil, err
+	}
+	preds := predictor.Gbm.PredictArray(values, predictor.missingValue(treatsZeroAsNA), 0)
+	addBaseMargin(preds, base_margin)
+	return predictor.transform(preds, output_margin), nil
+}
+
+func (predictor *Predictor) PredictArraySingleWithBaseMargin(values []float32, treatsZeroAsNA bool, base_margin float32, output_margin bool) float32 {
+	pred := predictor.Gbm.PredictSingleFromArray(values, predictor.missingValue(treatsZeroAsNA)) + base_margin
+	return predictor.transformSingle(pred, output_margin)
+}
+
+func (predictor *Predictor) PredictMapWithBaseMargin(values map[int]float32, base_margin []float32, output_margin bool) ([]float32, error) {
+	err := predictor.checkBaseMargin(base_margin, 1)
+	if err != nil {
+		return nil, err
+	}
+	preds := predictor.Gbm.PredictMap(values, 0)
+	addBaseMargin(preds, base_margin)
+	return predictor.transform(preds, output_margin), nil
+}
+
+func (predictor *Predictor) PredictMapSingleWithBaseMargin(values map[int]float32, base_margin float32, output_margin bool) float32 {
+	pred := predictor.Gbm.PredictSingleFromMap(values) + base_margin
+	return predictor.transformSingle(pred, output_margin)
+}
+
+func (predictor *Predictor) checkBaseMargin(base_margin []float32, nrow int) error {
+	expected := nrow * predictor.Gbm.NumOutputGroup()
+	if len(base_margin) != expected {
+		return fmt.Errorf("Base margin size mismatch: expected = %d, actual = %d", expected, len(base_margin))
+	}
+	return nil
+}
+
+func (predictor *Predictor) transform(preds []float32, output_margin bool) []float32 {
+	if output_margin {
+		return preds
+	} else {
+		return predictor.ObjFunction.PredTransform(preds)
+	}
+}
+
+func (predictor *Predictor) transformSingle(pred float32, output_margin bool) float32 {
+	if output_margin {
+		return pred
+	} else {
+		return predictor.ObjFunction.PredTransformSingle(pred)
+	}
+}
+
+func addBaseMargin(preds []float32, base_margin []float32) {
+	for i := 0; i < len(preds); i++ {
+		preds[i] += base_margin[i]
+	}
+}
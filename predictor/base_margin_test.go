@@ -0,0 +1,146 @@
+package predictor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/data"
+)
+
+func checkClose(t *testing.T, name string, got, expected []float32) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("%s: got %v, want %v", name, got, expected)
+	}
+	for i := range expected {
+		if math.Abs(float64(got[i]-expected[i])) > 1e-5 {
+			t.Fatalf("%s: got %v, want %v", name, got, expected)
+		}
+	}
+}
+
+// TestBaseMargin checks that every *WithBaseMargin variant returns the
+// margin with base_score replaced by base_margin, per row and output group.
+func TestBaseMargin(t *testing.T) {
+	rows := testRows[:2]
+	for _, name := range []string{"logistic.bin", "softmax.bin", "linear.bin"} {
+		p := loadTestModel(t, name, *config.DEFAULT)
+		num_output_group := p.Gbm.NumOutputGroup()
+		ncol := len(rows[0])
+
+		var values []float32
+		var base_margin []float32
+		var expected []float32
+		for rid, row := range rows {
+			padded := append(append([]float32{}, row...), make([]float32, ncol-len(row))...)
+			values = append(values, padded...)
+			margin := p.PredictArrayWithMargin(padded, false, true)
+			row_margin := make([]float32, num_output_group)
+			for gid := range row_margin {
+				row_margin[gid] = float32(rid) - 0.5*float32(gid) + 0.25
+				expected = append(expected, margin[gid]-p.Mparam.base_score+row_margin[gid])
+			}
+			base_margin = append(base_margin, row_margin...)
+
+			got, err := p.PredictArrayWithBaseMargin(padded, false, row_margin, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			row_expected := expected[rid*num_output_group:]
+			checkClose(t, fmt.Sprintf("%s row %d PredictArrayWithBaseMargin", name, rid), got, row_expected)
+			transformed, err := p.PredictArrayWithBaseMargin(padded, false, row_margin, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkClose(t, name+" transformed", transformed, p.ObjFunction.PredTransform(append([]float32{}, row_expected...)))
+
+			sparse := make(map[int]float32)
+			for fid, value := range padded {
+				sparse[fid] = value
+			}
+			got, err = p.PredictMapWithBaseMargin(sparse, row_margin, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkClose(t, fmt.Sprintf("%s row %d PredictMapWithBaseMargin", name, rid), got, row_expected)
+
+			if num_output_group == 1 {
+				single := p.PredictArraySingleWithBaseMargin(padded, false, row_margin[0], true)
+				checkClose(t, name+" PredictArraySingleWithBaseMargin", []float32{single}, row_expected[:1])
+				single = p.PredictMapSingleWithBaseMargin(sparse, row_margin[0], true)
+				checkClose(t, name+" PredictMapSingleWithBaseMargin", []float32{single}, row_expected[:1])
+			}
+
+			_, err = p.PredictArrayWithBaseMargin(padded, false, append(row_margin, 0), true)
+			if err == nil {
+				t.Errorf("%s: PredictArrayWithBaseMargin accepted %d margins", name, num_output_group+1)
+			}
+			_, err = p.PredictMapWithBaseMargin(sparse, row_margin[1:], true)
+			if err == nil {
+				t.Errorf("%s: PredictMapWithBaseMargin accepted %d margins", name, num_output_group-1)
+			}
+		}
+
+		dense, err := data.NewDenseMatrix(values, len(rows), ncol, nan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		indptr := []int{0}
+		var indices []int
+		var present []float32
+		for rid := range rows {
+			for fid, value := range dense.Row(rid) {
+				if value == value {
+					indices = append(indices, fid)
+					present = append(present, value)
+				}
+			}
+			indptr = append(indptr, len(indices))
+		}
+		csr, err := data.NewCSRMatrix(indptr, indices, present, ncol)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		preds := make([]float32, len(expected))
+		err = p.PredictDenseWithBaseMargin(dense, base_margin, true, preds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkClose(t, name+" PredictDenseWithBaseMargin", preds, expected)
+		err = p.PredictCSRWithBaseMargin(csr, base_margin, true, preds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkClose(t, name+" PredictCSRWithBaseMargin", preds, expected)
+		options := BatchOptions{Parallelism: 2, ChunkSize: 1, BaseMargin: base_margin}
+		err = p.PredictDenseParallel(context.Background(), dense, true, preds, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkClose(t, name+" PredictDenseParallel", preds, expected)
+		err = p.PredictCSRParallel(context.Background(), csr, true, preds, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkClose(t, name+" PredictCSRParallel", preds, expected)
+
+		short := base_margin[:len(base_margin)-1]
+		if p.PredictDenseWithBaseMargin(dense, short, true, preds) == nil {
+			t.Errorf("%s: PredictDenseWithBaseMargin accepted a short base_margin", name)
+		}
+		if p.PredictCSRWithBaseMargin(csr, short, true, preds) == nil {
+			t.Errorf("%s: PredictCSRWithBaseMargin accepted a short base_margin", name)
+		}
+		options.BaseMargin = append(base_margin, 0)
+		if p.PredictDenseParallel(context.Background(), dense, true, preds, options) == nil {
+			t.Errorf("%s: PredictDenseParallel accepted a long base_margin", name)
+		}
+		if p.PredictCSRParallel(context.Background(), csr, true, preds, options) == nil {
+			t.Errorf("%s: PredictCSRParallel accepted a long base_margin", name)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package predictor
+
+import (
+	"fmt"
+
+	"xgboost4go-predictor/data"
+)
+
+// NumOutput returns the number of values produced per row. It differs from
+// the number of output groups for objectives such as multi:softmax, which
+// return the class index after a 0 instead of the group margins.
+func (predictor *Predictor) NumOutput(output_margin bool) int {
+	num_output_group := predictor.Gbm.NumOutputGroup()
+	if output_margin {
+		return num_output_group
+	}
+	return len(predictor.ObjFunction.PredTransform(make([]float32, num_output_group)))
+}
+
+func (predictor *Predictor) PredictDense(matrix *data.DenseMatrix, output_margin bool, preds []float32) error {
+	return predictor.PredictDenseWithNtree(matrix, output_margin, 0, preds)
+}
+
+func (predictor *Predictor) PredictDenseWithNtree(matrix *data.DenseMatrix, output_margin bool, ntree_limit int, preds []float32) error {
+	return predictor.predictDense(matrix, nil, output_margin, ntree_limit, preds)
+}
+
+func (predictor *Predictor) PredictDenseWithBaseMargin(matrix *data.DenseMatrix, base_margin []float32, output_margin bool, preds []float32) error {
+	err := predictor.checkBaseMargin(base_margin, matrix.NumRow)
+	if err != nil {
+		return err
+	}
+	return predictor.predictDense(matrix, base_margin, output_margin, 0, preds)
+}
+
+func (predictor *Predictor) predictDense(matrix *data.DenseMatrix, base_margin []float32, output_margin bool, ntree_limit int, preds []float32) error {
+	err := matrix.Validate()
+	if err != nil {
+		return err
+	}
+	num_output_group := predictor.Gbm.NumOutputGroup()
+	num_output := predictor.NumOutput(output_margin)
+	if len(preds) < matrix.NumRow*num_output {
+		return fmt.Errorf("Prediction buffer is too small: expected = %d, actual = %d", matrix.NumRow*num_output, len(preds))
+	}
+	margins := preds
+	if num_output != num_output_group {
+		margins = make([]float32, matrix.NumRow*num_output_group)
+	}
+	err = predictor.Gbm.PredictDense(matrix, ntree_limit, margins)
+	if err != nil {
+		return err
+	}
+	predictor.finishBatch(margins, matrix.NumRow, base_margin, output_margin, preds)
+	return nil
+}
+
+// finishBatch adds base_margin, or base_score when it is nil, to the raw
+// margins and writes the transformed predictions to preds.
+func (predictor *Predictor) finishBatch(margins []float32, nrow int, base_margin []float32, output_margin bool, preds []float32) {
+	num_output_group := predictor.Gbm.NumOutputGroup()
+	if base_margin != nil {
+		addBaseMargin(margins[:nrow*num_output_group], base_margin)
+	} else {
+		for i := 0; i < nrow*num_output_group; i++ {
+			margins[i] += predictor.Mparam.base_score
+		}
+	}
+	if output_margin {
+		return
+	}
+	num_output := predictor.NumOutput(output_margin)
+	for rid := 0; rid < nrow; rid++ {
+		transformed := predictor.ObjFunction.PredTransform(margins[rid*num_output_group : (rid+1)*num_output_group])
+		copy(preds[rid*num_output:(rid+1)*num_output], transformed)
+	}
+}
+
+func (predictor *Predictor) PredictCSR(matrix *data.CSRMatrix, output_margin bool, preds []float32) error {
+	return predictor.PredictCSRWithNtree(matrix, output_margin, 0, preds)
+}
+
+func (predictor *Predictor) PredictCSRWithNtree(matrix *data.CSRMatrix, output_margin bool, ntree_limit int, preds []float32) error {
+	return predictor.predictCSR(matrix, nil, output_margin, ntree_limit, preds)
+}
+
+func (predictor *Predictor) PredictCSRWithBaseMargin(matrix *data.CSRMatrix, base_margin []float32, output_margin bool, preds []float32) error {
+	err := predictor.checkBaseMargin(base_margin, matrix.NumRow())
+	if err != nil {
+		return err
+	}
+	return predictor.predictCSR(matrix, base_margin, output_margin, 0, preds)
+}
+
+func (predictor *Predictor) predictCSR(matrix *data.CSRMatrix, base_margin []float32, output_margin bool, ntree_limit int, preds []float32) error {
+	err := matrix.Validate()
+	if err != nil {
+		return err
+	}
+	num_output_group := predictor.Gbm.NumOutputGroup()
+	num_output := predictor.NumOutput(output_margin)
+	nrow := matrix.NumRow()
+	if len(preds) < nrow*num_output {
+		return fmt.Errorf("Prediction buffer is too small: expected = %d, actual = %d", nrow*num_output, len(preds))
+	}
+	margins := preds
+	if num_output != num_output_group {
+		margins = make([]float32, nrow*num_output_group)
+	}
+	err = predictor.Gbm.PredictCSR(matrix, ntree_limit, margins)
+	if err != nil {
+		return err
+	}
+	predictor.finishBatch(margins, nrow, base_margin, output_margin, preds)
+	return nil
+}
@@ -0,0 +1,78 @@
+package predictor
+
+import (
+	"context"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/data"
+)
+
+// TestSoftmaxOutputShape checks that multi:softmax returns the class index
+// after a 0 from every entry point, as it always has.
+func TestSoftmaxOutputShape(t *testing.T) {
+	p := loadTestModel(t, "softmax.bin", *config.DEFAULT)
+	if p.NumOutput(false) != 2 {
+		t.Fatalf("NumOutput = %d, want 2", p.NumOutput(false))
+	}
+	row := []float32{1, -1, 0.5, 2, -3, 0, 1, 4}
+	margins := p.PredictArrayWithMargin(row, false, true)
+	maxIndex := 0
+	for k := range margins {
+		if margins[maxIndex] < margins[k] {
+			maxIndex = k
+		}
+	}
+	expected := []float32{0, float32(maxIndex)}
+
+	check := func(name string, got []float32) {
+		t.Helper()
+		if len(got) != 2 || got[0] != expected[0] || got[1] != expected[1] {
+			t.Errorf("%s = %v, want %v", name, got, expected)
+		}
+	}
+	check("PredictArray", p.PredictArray(row, false))
+	into, err := p.PredictArrayInto(row, false, make([]float32, p.Gbm.NumOutputGroup()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("PredictArrayInto", into)
+
+	matrix, err := data.NewDenseMatrix(append(append([]float32{}, row...), row...), 2, len(row), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preds := make([]float32, 2*p.NumOutput(false))
+	err = p.PredictDense(matrix, false, preds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("PredictDense row 0", preds[:2])
+	check("PredictDense row 1", preds[2:])
+}
+
+// TestPredictDenseInvalidShape checks that matrices built as struct literals
+// are validated instead of panicking on a short Values slice.
+func TestPredictDenseInvalidShape(t *testing.T) {
+	p := loadTestModel(t, "logistic.bin", *config.DEFAULT)
+	matrices := []*data.DenseMatrix{
+		{Values: make([]float32, 5), NumRow: 2, NumCol: 3},
+		{Values: make([]float32, 6), NumRow: -2, NumCol: -3},
+		{Values: nil, NumRow: 1 << 62, NumCol: 4},
+	}
+	for _, matrix := range matrices {
+		preds := make([]float32, 2)
+		err := p.PredictDense(matrix, false, preds)
+		if err == nil {
+			t.Errorf("PredictDense(%d x %d, %d values) succeeded", matrix.NumRow, matrix.NumCol, len(matrix.Values))
+		}
+		err = p.PredictDenseParallel(context.Background(), matrix, false, preds, BatchOptions{})
+		if err == nil {
+			t.Errorf("PredictDenseParallel(%d x %d, %d values) succeeded", matrix.NumRow, matrix.NumCol, len(matrix.Values))
+		}
+		err = p.Gbm.PredictDense(matrix, 0, preds)
+		if err == nil {
+			t.Errorf("Gbm.PredictDense(%d x %d, %d values) succeeded", matrix.NumRow, matrix.NumCol, len(matrix.Values))
+		}
+	}
+}
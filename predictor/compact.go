@@ -0,0 +1,164 @@
+package predictor
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/tree"
+	"xgboost4go-predictor/util"
+)
+
+// The compact model format stores a gbtree model as its flattened forest so
+// that it can be memory-mapped and used without decoding. All fields are
+// little-endian 32-bit words:
+//
+//	magic "XGBFLAT1"
+//	base_score as a margin, num_feature, num_class, num_output_group,
+//	gbm num_feature, num_trees, num_nodes, length of name_obj
+//	name_obj, zero-padded to a multiple of 4 bytes
+//	tree_info[num_trees]
+//	the forest as written by tree.FlatForest.WriteTo
+const COMPACT_MAGIC = "XGBFLAT1"
+
+const compactHeaderSize = 8 + 4*8
+
+func (predictor *Predictor) WriteCompact(w io.Writer) error {
+	gbTree, ok := predictor.Gbm.(*gbm.GBTree)
+	if !ok {
+		return fmt.Errorf("%s is not supported by the compact format.", predictor.Name_gbm)
+	}
+	forest := gbTree.Forest()
+	bw := bufio.NewWriter(w)
+	bw.WriteString(COMPACT_MAGIC)
+	words := []uint32{
+		math.Float32bits(predictor.Mparam.base_score),
+		uint32(predictor.Mparam.num_feature),
+		uint32(predictor.Mparam.num_class),
+		uint32(gbTree.NumOutputGroup()),
+		uint32(gbTree.NumFeature()),
+		uint32(forest.NumTrees()),
+		uint32(forest.NumNodes()),
+		uint32(len(predictor.Name_obj)),
+	}
+	for _, word := range words {
+		binary.Write(bw, binary.LittleEndian, word)
+	}
+	bw.WriteString(predictor.Name_obj)
+	bw.Write(make([]byte, padding(len(predictor.Name_obj))))
+	for _, gid := range gbTree.TreeInfo() {
+		binary.Write(bw, binary.LittleEndian, int32(gid))
+	}
+	_, err := forest.WriteTo(bw)
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func NewPredictorByMmap(fileName string) (*Predictor, error) {
+	return NewPredictorByMmapConf(fileName, *config.DEFAULT)
+}
+
+// NewPredictorByMmapConf maps a compact model file into memory. The
+// predictor reads the mapped pages directly, so it must be released with
+// Close once no prediction is in flight, and must not be used afterwards.
+func NewPredictorByMmapConf(fileName string, configuration config.Configuration) (*Predictor, error) {
+	mappedFile, err := util.MapFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	predictor, err := newPredictorByCompact(mappedFile.Bytes(), configuration)
+	if err != nil {
+		mappedFile.Close()
+		return nil, err
+	}
+	predictor.closer = mappedFile
+	return predictor, nil
+}
+
+func newPredictorByCompact(buf []byte, configuration config.Configuration) (*Predictor, error) {
+	if len(buf) < compactHeaderSize || string(buf[0:8]) != COMPACT_MAGIC {
+		return nil, fmt.Errorf("Not a compact model file.")
+	}
+	word := func(i int) int {
+		return int(int32(binary.LittleEndian.Uint32(buf[8+4*i:])))
+	}
+	num_output_group := word(3)
+	gbm_num_feature := word(4)
+	num_trees := word(5)
+	num_nodes := word(6)
+	nameLength := word(7)
+	if nameLength < 0 || num_trees < 0 || num_nodes < 0 || num_output_group <= 0 || word(1) < 0 || gbm_num_feature < 0 {
+		return nil, fmt.Errorf("Invalid compact model header.")
+	}
+
+	pos := compactHeaderSize
+	treeInfoPos := pos + nameLength + padding(nameLength)
+	forestPos := treeInfoPos + 4*num_trees
+	if treeInfoPos < pos || forestPos < treeInfoPos || forestPos > len(buf) {
+		return nil, fmt.Errorf("Compact model is truncated.")
+	}
+	forestEnd := forestPos + tree.FlatForestSize(num_trees, num_nodes)
+	if forestEnd < forestPos || forestEnd != len(buf) {
+		return nil, fmt.Errorf("Compact model size mismatch: expected = %d, actual = %d", forestEnd, len(buf))
+	}
+
+	predictor := new(Predictor)
+	predictor.Format = MODEL_FORMAT_COMPACT
+	predictor.FeatureMap = configuration.FeatureMap
+	predictor.StrictFeatureNames = configuration.StrictFeatureNames
+	predictor.Missing = configuration.MissingValue()
+	predictor.Mparam = new(PredictorModelParam)
+	predictor.Mparam.base_score = math.Float32frombits(binary.LittleEndian.Uint32(buf[8:]))
+	predictor.Mparam.num_feature = word(1)
+	predictor.Mparam.num_class = word(2)
+	predictor.Name_obj = string(buf[pos : pos+nameLength])
+	predictor.Name_gbm = "gbtree"
+
+	tree_info := make([]int, num_trees)
+	for i := range tree_info {
+		tree_info[i] = int(int32(binary.LittleEndian.Uint32(buf[treeInfoPos+4*i:])))
+	}
+	forest, err := tree.NewFlatForestFromBytes(buf[forestPos:forestEnd], num_trees, num_nodes)
+	if err != nil {
+		return nil, err
+	}
+	gbTree, err := gbm.NewGBTreeFromForest(gbm_num_feature, num_output_group, tree_info, forest)
+	if err != nil {
+		return nil, err
+	}
+	predictor.Gbm = gbTree
+
+	err = predictor.initObjFunction(configuration)
+	if err != nil {
+		return nil, err
+	}
+	err = predictor.initEvaluator(configuration)
+	if err != nil {
+		return nil, err
+	}
+	return predictor, nil
+}
+
+// Close releases resources held by the predictor, such as the mapping of a
+// compact model file. It is a no-op for predictors read from a stream. Close
+// must not be called while predictions are in flight, and a memory-mapped
+// predictor must not be used after Close: its forest points into the
+// released mapping.
+func (predictor *Predictor) Close() error {
+	if predictor.closer == nil {
+		return nil
+	}
+	closer := predictor.closer
+	predictor.closer = nil
+	return closer.Close()
+}
+
+func padding(length int) int {
+	return (4 - length%4) % 4
+}
@@ -0,0 +1,105 @@
+package predictor
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/learner"
+)
+
+func writeCompactFile(t *testing.T, p *Predictor) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "model.flat")
+	file, err := os.Create(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.WriteCompact(file)
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestCompactRoundTrip(t *testing.T) {
+	for _, name := range []string{"logistic.bin", "regression.bin", "softmax.bin"} {
+		expected := loadTestModel(t, name, *config.DEFAULT)
+		actual, err := NewPredictorByMmap(writeCompactFile(t, expected))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		defer actual.Close()
+		if actual.Format != MODEL_FORMAT_COMPACT || actual.Name_obj != expected.Name_obj {
+			t.Errorf("%s: loaded as %s %s, want compact %s", name, actual.Format, actual.Name_obj, expected.Name_obj)
+		}
+		compareMargins(t, expected, actual, name)
+		for _, row := range testRows {
+			want := expected.PredictArray(row, false)
+			got := actual.PredictArray(row, false)
+			for k := range want {
+				if math.Float32bits(want[k]) != math.Float32bits(got[k]) {
+					t.Fatalf("%s: prediction %d differs: %v != %v", name, k, got[k], want[k])
+				}
+			}
+			wantLeaves, err := expected.PredictLeaf(row, false, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotLeaves, err := actual.PredictLeaf(row, false, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k := range wantLeaves {
+				if wantLeaves[k] != gotLeaves[k] {
+					t.Fatalf("%s: leaf %d differs: %d != %d", name, k, gotLeaves[k], wantLeaves[k])
+				}
+			}
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	p, err := NewPredictorByMmap(writeCompactFile(t, loadTestModel(t, "logistic.bin", *config.DEFAULT)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.PredictArray(testRows[0], false)
+	err = p.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if err = loadTestModel(t, "logistic.bin", *config.DEFAULT).Close(); err != nil {
+		t.Errorf("Close of a streamed model: %v", err)
+	}
+}
+
+// TestConfiguredObjFunction checks that Configuration.ObjFunction replaces
+// the objective named in the model for binary and compact models alike.
+func TestConfiguredObjFunction(t *testing.T) {
+	configuration := *config.DEFAULT
+	configuration.ObjFunction = learner.DefaultObjFunction{}
+	p := loadTestModel(t, "logistic.bin", configuration)
+	compact, err := NewPredictorByMmapConf(writeCompactFile(t, p), configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer compact.Close()
+	for _, predictor := range []*Predictor{p, compact} {
+		for _, row := range testRows {
+			got := predictor.PredictArray(row, false)
+			expected := predictor.PredictArrayWithMargin(row, false, true)
+			if got[0] != expected[0] {
+				t.Errorf("%s: got %v, want the margin %v", predictor.Format, got, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package predictor
+
+import (
+	"fmt"
+
+	"xgboost4go-predictor/math"
+)
+
+type contributor interface {
+	ContributionWidth() int
+	PredictContributions(values []float32, missing float32, ntree_limit int) ([]float32, error)
+}
+
+// NumContributions returns the length of a PredictContributions result:
+// one value per feature plus the bias, for every output group.
+func (predictor *Predictor) NumContributions() int {
+	booster, ok := predictor.Gbm.(contributor)
+	if !ok {
+		return 0
+	}
+	return (booster.ContributionWidth() + 1) * predictor.Gbm.NumOutputGroup()
+}
+
+// PredictContributions returns the contribution of every feature to the
+// margin, like pred_contribs in XGBoost. For each output group there are
+// NumFeature values followed by the bias, which includes base_score; they
+// add up to the margin.
+func (predictor *Predictor) PredictContributions(values []float32, treatsZeroAsNA bool) ([]float32, error) {
+	return predictor.PredictContributionsWithNtree(values, treatsZeroAsNA, 0)
+}
+
+func (predictor *Predictor) PredictContributionsWithNtree(values []float32, treatsZeroAsNA bool, ntree_limit int) ([]float32, error) {
+	return predictor.predictContributions(values, predictor.missingValue(treatsZeroAsNA), ntree_limit)
+}
+
+func (predictor *Predictor) PredictMapContributions(values map[int]float32) ([]float32, error) {
+	return predictor.PredictMapContributionsWithNtree(values, 0)
+}
+
+func (predictor *Predictor) PredictMapContributionsWithNtree(values map[int]float32, ntree_limit int) ([]float32, error) {
+	booster, ok := predictor.Gbm.(contributor)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support contributions.", predictor.Name_gbm)
+	}
+	feats := make([]float32, booster.ContributionWidth())
+	for i := range feats {
+		feats[i] = math.NAN
+	}
+	for fid, value := range values {
+		if fid >= 0 && fid < len(feats) {
+			feats[fid] = value
+		}
+	}
+	return predictor.predictContributions(feats, math.NAN, ntree_limit)
+}
+
+func (predictor *Predictor) predictContributions(values []float32, missing float32, ntree_limit int) ([]float32, error) {
+	booster, ok := predictor.Gbm.(contributor)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support contributions.", predictor.Name_gbm)
+	}
+	contribs, err := booster.PredictContributions(values, missing, ntree_limit)
+	if err != nil {
+		return nil, err
+	}
+	width := booster.ContributionWidth() + 1
+	for gid := 0; gid < predictor.Gbm.NumOutputGroup(); gid++ {
+		contribs[gid*width+width-1] += predictor.Mparam.base_score
+	}
+	return contribs, nil
+}
@@ -0,0 +1,42 @@
+package predictor
+
+import (
+	"math"
+	"testing"
+
+	"xgboost4go-predictor/config"
+)
+
+// TestContributionsSumToMargin checks that the contributions of every group,
+// bias included, add up to the margin.
+func TestContributionsSumToMargin(t *testing.T) {
+	for _, name := range []string{"linear.bin", "logistic.bin", "regression.bin", "softmax.bin"} {
+		p := loadTestModel(t, name, *config.DEFAULT)
+		num_group := p.Gbm.NumOutputGroup()
+		width := p.NumContributions() / num_group
+		for _, row := range testRows {
+			margins := p.PredictArrayWithMargin(row, false, true)
+			for _, ntree_limit := range []int{0, 1} {
+				contribs, err := p.PredictContributionsWithNtree(row, false, ntree_limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(contribs) != width*num_group {
+					t.Fatalf("%s: %d contributions, want %d", name, len(contribs), width*num_group)
+				}
+				if ntree_limit != 0 {
+					margins = p.PredictArrayWithNtree(row, false, true, ntree_limit)
+				}
+				for gid := 0; gid < num_group; gid++ {
+					sum := 0.0
+					for _, contrib := range contribs[gid*width : (gid+1)*width] {
+						sum += float64(contrib)
+					}
+					if math.Abs(sum-float64(margins[gid])) > 1e-5*math.Max(1, math.Abs(sum)) {
+						t.Errorf("%s row %v ntree_limit %d group %d: contributions add up to %v, margin is %v", name, row, ntree_limit, gid, sum, margins[gid])
+					}
+				}
+			}
+		}
+	}
+}
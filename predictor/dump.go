@@ -0,0 +1,8 @@
+package predictor
+
+// DumpModel returns the dump of every tree (or the weights of a gblinear
+// model) in the given tree.DUMP_FORMAT_*, naming features from the
+// predictor's feature map.
+func (predictor *Predictor) DumpModel(with_stats bool, format string) ([]string, error) {
+	return predictor.Gbm.DumpModel(predictor.FeatureMap, with_stats, format)
+}
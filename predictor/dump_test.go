@@ -0,0 +1,62 @@
+package predictor
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/tree"
+	"xgboost4go-predictor/util"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/dump")
+
+// TestDumpGolden compares the dumps of logistic.bin in every format, with
+// and without statistics and feature map, with the files in testdata/dump.
+// The feature map names an indicator and an integer feature.
+func TestDumpGolden(t *testing.T) {
+	featureMap, err := util.NewFeatureMapByReader(strings.NewReader("0 present i\n1 count int\n2 ratio q\n3 score float\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := loadTestModel(t, "logistic.bin", *config.DEFAULT)
+	extensions := map[string]string{tree.DUMP_FORMAT_TEXT: "txt", tree.DUMP_FORMAT_JSON: "json", tree.DUMP_FORMAT_DOT: "dot"}
+	for _, fmap := range []*util.FeatureMap{nil, featureMap} {
+		for _, with_stats := range []bool{false, true} {
+			for format, extension := range extensions {
+				name := "logistic"
+				if fmap != nil {
+					name += "_fmap"
+				}
+				if with_stats {
+					name += "_stats"
+				}
+				name += "." + extension
+				p.FeatureMap = fmap
+				dumps, err := p.DumpModel(with_stats, format)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := strings.Join(dumps, "")
+				path := filepath.Join("..", "testdata", "dump", name)
+				if *update {
+					err = os.WriteFile(path, []byte(got), 0644)
+					if err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				expected, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != string(expected) {
+					t.Errorf("%s differs from the golden file:\n%s", name, got)
+				}
+			}
+		}
+	}
+}
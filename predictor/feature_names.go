@@ -0,0 +1,101 @@
+package predictor
+
+import (
+	"fmt"
+
+	"xgboost4go-predictor/math"
+)
+
+func (predictor *Predictor) ResolveFeatureNames(names []string) ([]int, error) {
+	if predictor.FeatureMap == nil {
+		return nil, fmt.Errorf("Feature map is not set.")
+	}
+	return predictor.FeatureMap.Resolve(names, predictor.StrictFeatureNames)
+}
+
+func (predictor *Predictor) NamedToMap(values map[string]float32) (map[int]float32, error) {
+	if predictor.FeatureMap == nil {
+		return nil, fmt.Errorf("Feature map is not set.")
+	}
+	result := make(map[int]float32, len(values))
+	for name, value := range values {
+		fid, ok := predictor.FeatureMap.Index(name)
+		if !ok {
+			if predictor.StrictFeatureNames {
+				return nil, fmt.Errorf("Unknown feature name: %s", name)
+			}
+			continue
+		}
+		result[fid] = value
+	}
+	return result, nil
+}
+
+func (predictor *Predictor) PredictNamed(values map[string]float32) ([]float32, error) {
+	return predictor.PredictNamedWithMargin(values, false)
+}
+
+func (predictor *Predictor) PredictNamedWithMargin(values map[string]float32, output_margin bool) ([]float32, error) {
+	return predictor.PredictNamedWithNtree(values, output_margin, 0)
+}
+
+func (predictor *Predictor) PredictNamedWithNtree(values map[string]float32, output_margin bool, ntree_limit int) ([]float32, error) {
+	indexed, err := predictor.NamedToMap(values)
+	if err != nil {
+		return nil, err
+	}
+	return predictor.PredictMapWithNtree(indexed, output_margin, ntree_limit), nil
+}
+
+func (predictor *Predictor) PredictNamedSingle(values map[string]float32) (float32, error) {
+	return predictor.PredictNamedSingleWithMargin(values, false)
+}
+
+func (predictor *Predictor) PredictNamedSingleWithMargin(values map[string]float32, output_margin bool) (float32, error) {
+	indexed, err := predictor.NamedToMap(values)
+	if err != nil {
+		return 0, err
+	}
+	return predictor.PredictMapSingleWithMargin(indexed, output_margin), nil
+}
+
+// PredictResolved predicts from values given in the order of names resolved
+// once with ResolveFeatureNames, so that names are not looked up on every
+// call. Values of names resolved to -1 are ignored. As with PredictNamed,
+// only NaN values are missing.
+func (predictor *Predictor) PredictResolved(fids []int, values []float32) ([]float32, error) {
+	return predictor.PredictResolvedWithMargin(fids, values, false)
+}
+
+func (predictor *Predictor) PredictResolvedWithMargin(fids []int, values []float32, output_margin bool) ([]float32, error) {
+	return predictor.PredictResolvedWithNtree(fids, values, output_margin, 0)
+}
+
+func (predictor *Predictor) PredictResolvedWithNtree(fids []int, values []float32, output_margin bool, ntree_limit int) ([]float32, error) {
+	if len(fids) != len(values) {
+		return nil, fmt.Errorf("Feature ids and values size mismatch: %d != %d", len(fids), len(values))
+	}
+	num_col := 0
+	for _, fid := range fids {
+		if fid >= num_col {
+			num_col = fid + 1
+		}
+	}
+	row := make([]float32, num_col)
+	for i := range row {
+		row[i] = math.NAN
+	}
+	for i, fid := range fids {
+		if fid >= 0 {
+			row[fid] = values[i]
+		}
+	}
+	preds := predictor.Gbm.PredictArray(row, math.NAN, ntree_limit)
+	for i := 0; i < len(preds); i++ {
+		preds[i] += predictor.Mparam.base_score
+	}
+	if output_margin {
+		return preds, nil
+	}
+	return predictor.ObjFunction.PredTransform(preds), nil
+}
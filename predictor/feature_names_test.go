@@ -0,0 +1,65 @@
+package predictor
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/util"
+)
+
+func TestPredictResolved(t *testing.T) {
+	featureMap, err := util.NewFeatureMapByReader(strings.NewReader("0\ta\tq\n1\tb\tq\n2\tc c\tq\n3\td\tq\n4\te\tq\n5\tf\tq\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configuration := *config.DEFAULT
+	configuration.FeatureMap = featureMap
+	p := loadTestModel(t, "logistic.bin", configuration)
+
+	names := []string{"f", "c c", "unknown", "a", "d"}
+	fids, err := p.ResolveFeatureNames(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fids[2] != -1 {
+		t.Fatalf("unknown name resolved to %d", fids[2])
+	}
+	rows := [][]float32{
+		{0.5, -1, 7, 2, 0},
+		{float32(math.NaN()), 3, 7, -0.25, 1},
+		{1, 0, 0, 0, float32(math.NaN())},
+	}
+	for _, row := range rows {
+		named := make(map[string]float32)
+		for i, name := range names {
+			if row[i] == row[i] {
+				named[name] = row[i]
+			}
+		}
+		for _, output_margin := range []bool{false, true} {
+			expected, err := p.PredictNamedWithMargin(named, output_margin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.PredictResolvedWithMargin(fids, row, output_margin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(expected) || got[0] != expected[0] {
+				t.Errorf("row %v margin %v: PredictResolved = %v, PredictNamed = %v", row, output_margin, got, expected)
+			}
+		}
+	}
+
+	_, err = p.PredictResolved(fids, rows[0][:2])
+	if err == nil {
+		t.Error("PredictResolved accepted fewer values than ids")
+	}
+	p.StrictFeatureNames = true
+	_, err = p.ResolveFeatureNames(names)
+	if err == nil {
+		t.Error("strict ResolveFeatureNames accepted an unknown name")
+	}
+}
@@ -0,0 +1,146 @@
+package predictor
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/data"
+	"xgboost4go-predictor/tree"
+	"xgboost4go-predictor/util"
+)
+
+// fuzzMaxContributions skips contributions of models that declare so many
+// features that the output itself would be huge.
+const fuzzMaxContributions = 1 << 16
+
+func addSeeds(f *testing.F, pattern string) {
+	paths, err := filepath.Glob(filepath.Join("..", "testdata", pattern))
+	if err != nil || len(paths) == 0 {
+		f.Fatalf("no seed models match %s", pattern)
+	}
+	for _, path := range paths {
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf)
+	}
+}
+
+// FuzzBinary loads legacy binary models once for every evaluator, predicts
+// with every entry point, dumps them and converts them to the compact
+// format. The evaluators and the compact model must agree on the margins.
+func FuzzBinary(f *testing.F) {
+	addSeeds(f, "*.bin*")
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		var traversal *Predictor
+		for _, evaluator := range []string{config.EVALUATOR_TRAVERSAL, config.EVALUATOR_QUICKSCORER, config.EVALUATOR_QUANTIZED} {
+			configuration := *config.DEFAULT
+			configuration.MaxModelBytes = int64(len(buf))
+			configuration.Evaluator = evaluator
+			p, err := NewPredictorByConf(*bufio.NewReader(bytes.NewReader(buf)), configuration)
+			if err != nil {
+				return
+			}
+			fuzzPredict(t, p)
+			if traversal == nil {
+				traversal = p
+			}
+			compareMargins(t, traversal, p, evaluator)
+		}
+
+		var compact bytes.Buffer
+		if traversal.WriteCompact(&compact) != nil {
+			return
+		}
+		p, err := newPredictorByCompact(compact.Bytes(), *config.DEFAULT)
+		if err != nil {
+			t.Fatalf("compact model does not load: %v", err)
+		}
+		compareMargins(t, traversal, p, MODEL_FORMAT_COMPACT)
+	})
+}
+
+// FuzzCompact loads compact models and predicts with them.
+func FuzzCompact(f *testing.F) {
+	addSeeds(f, "*.flat")
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		p, err := newPredictorByCompact(buf, *config.DEFAULT)
+		if err != nil {
+			return
+		}
+		fuzzPredict(t, p)
+	})
+}
+
+func fuzzPredict(t *testing.T, p *Predictor) {
+	for _, row := range testRows {
+		p.PredictArray(row, false)
+		p.PredictArrayWithMargin(row, true, true)
+		p.PredictArrayWithNtree(row, false, false, 1)
+		p.PredictLeaf(row, false, 0)
+		values := make(map[int]float32)
+		for fid, value := range row {
+			values[fid] = value
+		}
+		p.PredictMap(values)
+		p.PredictMapLeaf(values, 0)
+		if p.NumContributions() <= fuzzMaxContributions {
+			p.PredictContributions(row, false)
+			p.PredictMapContributions(values)
+		}
+	}
+
+	indptr := []int{0}
+	var indices []int
+	var values []float32
+	for _, row := range testRows {
+		for fid, value := range row {
+			indices = append(indices, fid)
+			values = append(values, value)
+		}
+		indptr = append(indptr, len(indices))
+	}
+	matrix, err := data.NewCSRMatrix(indptr, indices, values, len(testRows[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	preds := make([]float32, matrix.NumRow()*p.NumOutput(false))
+	err = p.PredictCSR(matrix, false, preds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The feature map is shorter than most models, so dumps also name
+	// features past its end.
+	featureMap, err := util.NewFeatureMapByReader(bytes.NewReader([]byte("0 a i\n1 b int\n2 c q\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fmap := range []*util.FeatureMap{nil, featureMap} {
+		p.FeatureMap = fmap
+		for _, format := range []string{tree.DUMP_FORMAT_TEXT, tree.DUMP_FORMAT_JSON, tree.DUMP_FORMAT_DOT} {
+			p.DumpModel(false, format)
+			p.DumpModel(true, format)
+		}
+	}
+	p.FeatureMap = nil
+}
+
+func compareMargins(t *testing.T, expected, actual *Predictor, name string) {
+	t.Helper()
+	for _, row := range testRows {
+		want := expected.PredictArrayWithMargin(row, false, true)
+		got := actual.PredictArrayWithMargin(row, false, true)
+		for k := range want {
+			if math.Float32bits(want[k]) != math.Float32bits(got[k]) {
+				t.Fatalf("%s margin %d differs: %v != %v", name, k, got[k], want[k])
+			}
+		}
+	}
+}
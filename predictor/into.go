@@ -0,0 +1,46 @@
+package predictor
+
+// The *Into variants write margins and transformed predictions into preds,
+// which must hold at least one value per output group, and return the
+// prefix of preds holding the result. They do not allocate for the
+// built-in objective functions.
+
+func (predictor *Predictor) PredictArrayInto(values []float32, treatsZeroAsNA bool, preds []float32) ([]float32, error) {
+	return predictor.PredictArrayWithNtreeInto(values, treatsZeroAsNA, false, 0, preds)
+}
+
+func (predictor *Predictor) PredictArrayWithMarginInto(values []float32, treatsZeroAsNA, output_margin bool, preds []float32) ([]float32, error) {
+	return predictor.PredictArrayWithNtreeInto(values, treatsZeroAsNA, output_margin, 0, preds)
+}
+
+func (predictor *Predictor) PredictArrayWithNtreeInto(values []float32, treatsZeroAsNA, output_margin bool, ntree_limit int, preds []float32) ([]float32, error) {
+	err := predictor.Gbm.PredictArrayInto(values, predictor.missingValue(treatsZeroAsNA), ntree_limit, preds)
+	if err != nil {
+		return nil, err
+	}
+	return predictor.finishInto(preds, output_margin), nil
+}
+
+func (predictor *Predictor) PredictMapInto(values map[int]float32, preds []float32) ([]float32, error) {
+	return predictor.PredictMapWithNtreeInto(values, false, 0, preds)
+}
+
+func (predictor *Predictor) PredictMapWithMarginInto(values map[int]float32, output_margin bool, preds []float32) ([]float32, error) {
+	return predictor.PredictMapWithNtreeInto(values, output_margin, 0, preds)
+}
+
+func (predictor *Predictor) PredictMapWithNtreeInto(values map[int]float32, output_margin bool, ntree_limit int, preds []float32) ([]float32, error) {
+	err := predictor.Gbm.PredictMapInto(values, ntree_limit, preds)
+	if err != nil {
+		return nil, err
+	}
+	return predictor.finishInto(preds, output_margin), nil
+}
+
+func (predictor *Predictor) finishInto(preds []float32, output_margin bool) []float32 {
+	preds = preds[:predictor.Gbm.NumOutputGroup()]
+	for i := 0; i < len(preds); i++ {
+		preds[i] += predictor.Mparam.base_score
+	}
+	return predictor.transform(preds, output_margin)
+}
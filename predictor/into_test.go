@@ -0,0 +1,45 @@
+package predictor
+
+import (
+	"math"
+	"testing"
+
+	"xgboost4go-predictor/config"
+)
+
+func TestIntoDoesNotAllocate(t *testing.T) {
+	row := []float32{0.5, -1, 2, 0, 3, -0.25}
+	values := map[int]float32{0: 0.5, 1: -1, 2: 2, 4: 3}
+	for _, name := range []string{"linear.bin", "logistic.bin", "regression.bin", "softmax.bin"} {
+		for _, evaluator := range []string{config.EVALUATOR_TRAVERSAL, config.EVALUATOR_QUICKSCORER, config.EVALUATOR_QUANTIZED} {
+			configuration := *config.DEFAULT
+			configuration.Evaluator = evaluator
+			p := loadTestModel(t, name, configuration)
+			preds := make([]float32, p.Gbm.NumOutputGroup())
+
+			for _, output_margin := range []bool{false, true} {
+				got, err := p.PredictArrayWithMarginInto(row, false, output_margin, preds)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := p.PredictArrayWithMargin(row, false, output_margin)
+				if len(got) != len(want) || math.Float32bits(got[0]) != math.Float32bits(want[0]) {
+					t.Errorf("%s %s: PredictArrayWithMarginInto = %v, want %v", name, evaluator, got, want)
+				}
+
+				allocs := testing.AllocsPerRun(100, func() {
+					p.PredictArrayWithMarginInto(row, false, output_margin, preds)
+				})
+				if allocs != 0 {
+					t.Errorf("%s %s: PredictArrayWithMarginInto allocates %v times", name, evaluator, allocs)
+				}
+				allocs = testing.AllocsPerRun(100, func() {
+					p.PredictMapWithMarginInto(values, output_margin, preds)
+				})
+				if allocs != 0 {
+					t.Errorf("%s %s: PredictMapWithMarginInto allocates %v times", name, evaluator, allocs)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package predictor
+
+import (
+	"fmt"
+
+	"xgboost4go-predictor/gbm"
+)
+
+// PredictLeaf returns the index of the leaf reached in each of the first
+// ntree_limit trees (all trees when it is 0), like pred_leaf in XGBoost.
+func (predictor *Predictor) PredictLeaf(values []float32, treatsZeroAsNA bool, ntree_limit int) ([]int, error) {
+	gbTree, ok := predictor.Gbm.(*gbm.GBTree)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support leaf prediction.", predictor.Name_gbm)
+	}
+	return gbTree.PredictLeafFromArray(values, predictor.missingValue(treatsZeroAsNA), 0, ntree_limit), nil
+}
+
+func (predictor *Predictor) PredictMapLeaf(values map[int]float32, ntree_limit int) ([]int, error) {
+	gbTree, ok := predictor.Gbm.(*gbm.GBTree)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support leaf prediction.", predictor.Name_gbm)
+	}
+	return gbTree.PredictLeafFromMap(values, ntree_limit), nil
+}
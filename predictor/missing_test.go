@@ -0,0 +1,152 @@
+package predictor
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/data"
+)
+
+const testMissing = float32(-999)
+
+var nan = float32(math.NaN())
+
+var missingRows = [][]float32{
+	{1, -1, 0.5, 2, -3, 0, 1, 4},
+	{-999, -1, -999, 2, nan, 0, -999, 4},
+	{-999, -999, -999, -999, -999, -999, -999, -999},
+	{0.25, nan, 3, -999, 1, -2, nan, 0},
+}
+
+// withNaN returns row with the entries equal to testMissing replaced by NaN.
+func withNaN(row []float32) []float32 {
+	result := make([]float32, len(row))
+	for i, value := range row {
+		if value == testMissing {
+			value = nan
+		}
+		result[i] = value
+	}
+	return result
+}
+
+func checkEqual(t *testing.T, name string, got, expected []float32) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("%s: got %v, want %v", name, got, expected)
+	}
+	for i := range expected {
+		if math.Float32bits(got[i]) != math.Float32bits(expected[i]) {
+			t.Fatalf("%s: got %v, want %v", name, got, expected)
+		}
+	}
+}
+
+// TestMissingValue checks that a configured missing value is treated like
+// NaN by the array, dense and CSR paths, for trees and linear models.
+func TestMissingValue(t *testing.T) {
+	for _, name := range []string{"logistic.bin", "softmax.bin", "linear.bin"} {
+		configuration := *config.DEFAULT
+		missing := testMissing
+		configuration.Missing = &missing
+		p := loadTestModel(t, name, configuration)
+		reference := loadTestModel(t, name, *config.DEFAULT)
+		num_output_group := p.Gbm.NumOutputGroup()
+
+		var csv strings.Builder
+		var values []float32
+		for rid, row := range missingRows {
+			expected := reference.PredictArrayWithMargin(withNaN(row), false, true)
+			checkEqual(t, fmt.Sprintf("%s row %d PredictArray", name, rid), p.PredictArrayWithMargin(row, false, true), expected)
+
+			present := make(map[int]float32)
+			for fid, value := range withNaN(row) {
+				if value == value {
+					present[fid] = value
+				}
+			}
+			checkEqual(t, fmt.Sprintf("%s row %d PredictMap", name, rid), p.PredictMapWithMargin(present, true), expected)
+
+			// The default configuration uses -999 as a value.
+			if rid == 1 && name != "softmax.bin" {
+				same := reference.PredictArrayWithMargin(row, false, true)
+				if same[0] == expected[0] {
+					t.Errorf("%s: -999 is missing without being configured", name)
+				}
+			}
+
+			values = append(values, row...)
+			fields := make([]string, len(row))
+			for fid, value := range row {
+				fields[fid] = fmt.Sprint(value)
+			}
+			csv.WriteString(strings.Join(fields, ",") + "\n")
+		}
+		expected := make([]float32, len(missingRows)*num_output_group)
+		for rid, row := range missingRows {
+			copy(expected[rid*num_output_group:], reference.PredictArrayWithMargin(withNaN(row), false, true))
+		}
+
+		dense, err := data.NewDenseMatrix(values, len(missingRows), len(missingRows[0]), testMissing)
+		if err != nil {
+			t.Fatal(err)
+		}
+		preds := make([]float32, len(expected))
+		err = p.PredictDenseWithNtree(dense, true, 0, preds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkEqual(t, name+" PredictDense", preds, expected)
+
+		// Text inputs drop missing values from the CSR batches.
+		reader, err := data.NewTextReader(strings.NewReader(csv.String()), data.TextOptions{Format: data.FORMAT_CSV, Missing: &missing})
+		if err != nil {
+			t.Fatal(err)
+		}
+		matrix, _, err := reader.ReadBatch(len(missingRows))
+		if err != nil {
+			t.Fatal(err)
+		}
+		preds = make([]float32, len(expected))
+		err = p.PredictCSRWithNtree(matrix, true, 0, preds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkEqual(t, name+" PredictCSR", preds, expected)
+	}
+}
+
+// TestMissingInMap checks that NaN in map input is missing, as an absent
+// key is.
+func TestMissingInMap(t *testing.T) {
+	for _, name := range []string{"logistic.bin", "softmax.bin", "linear.bin"} {
+		p := loadTestModel(t, name, *config.DEFAULT)
+		for rid, row := range missingRows {
+			withMissing := make(map[int]float32)
+			present := make(map[int]float32)
+			for fid, value := range withNaN(row) {
+				withMissing[fid] = value
+				if value == value {
+					present[fid] = value
+				}
+			}
+			checkEqual(t, fmt.Sprintf("%s row %d", name, rid), p.PredictMapWithMargin(withMissing, true), p.PredictMapWithMargin(present, true))
+		}
+	}
+}
+
+// TestTreatsZeroAsNA checks that treatsZeroAsNA replaces the configured
+// missing value for the call.
+func TestTreatsZeroAsNA(t *testing.T) {
+	configuration := *config.DEFAULT
+	missing := testMissing
+	configuration.Missing = &missing
+	p := loadTestModel(t, "linear.bin", configuration)
+	reference := loadTestModel(t, "linear.bin", *config.DEFAULT)
+	row := []float32{0, 1, -999, 0, 2, 0.5, 0, 1}
+	withZeros := []float32{nan, 1, -999, nan, 2, 0.5, nan, 1}
+	checkEqual(t, "treatsZeroAsNA", p.PredictArrayWithMargin(row, true, true), reference.PredictArrayWithMargin(withZeros, false, true))
+}
@@ -0,0 +1,144 @@
+package predictor
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"xgboost4go-predictor/data"
+)
+
+const DEFAULT_CHUNK_SIZE = 1024
+
+// BatchOptions controls how batch predictions are sharded across goroutines.
+// Rows are split into chunks of ChunkSize rows which are scored by
+// Parallelism workers; every chunk writes to its own range of the output,
+// so the result does not depend on scheduling. BaseMargin, when set,
+// replaces base_score as in PredictDenseWithBaseMargin.
+type BatchOptions struct {
+	Parallelism int
+	ChunkSize   int
+	NtreeLimit  int
+	BaseMargin  []float32
+}
+
+func (options BatchOptions) workers() int {
+	if options.Parallelism <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return options.Parallelism
+}
+
+func (options BatchOptions) chunkSize() int {
+	if options.ChunkSize <= 0 {
+		return DEFAULT_CHUNK_SIZE
+	}
+	return options.ChunkSize
+}
+
+func (predictor *Predictor) PredictDenseParallel(ctx context.Context, matrix *data.DenseMatrix, output_margin bool, preds []float32, options BatchOptions) error {
+	err := matrix.Validate()
+	if err != nil {
+		return err
+	}
+	num_output := predictor.NumOutput(output_margin)
+	if len(preds) < matrix.NumRow*num_output {
+		return fmt.Errorf("Prediction buffer is too small: expected = %d, actual = %d", matrix.NumRow*num_output, len(preds))
+	}
+	if options.BaseMargin != nil {
+		err = predictor.checkBaseMargin(options.BaseMargin, matrix.NumRow)
+		if err != nil {
+			return err
+		}
+	}
+	return runParallel(ctx, matrix.NumRow, options, func(begin, end int) error {
+		return predictor.predictDense(matrix.Slice(begin, end), predictor.sliceBaseMargin(options.BaseMargin, begin, end), output_margin, options.NtreeLimit, preds[begin*num_output:end*num_output])
+	})
+}
+
+func (predictor *Predictor) PredictCSRParallel(ctx context.Context, matrix *data.CSRMatrix, output_margin bool, preds []float32, options BatchOptions) error {
+	err := matrix.Validate()
+	if err != nil {
+		return err
+	}
+	num_output := predictor.NumOutput(output_margin)
+	nrow := matrix.NumRow()
+	if len(preds) < nrow*num_output {
+		return fmt.Errorf("Prediction buffer is too small: expected = %d, actual = %d", nrow*num_output, len(preds))
+	}
+	if options.BaseMargin != nil {
+		err = predictor.checkBaseMargin(options.BaseMargin, nrow)
+		if err != nil {
+			return err
+		}
+	}
+	return runParallel(ctx, nrow, options, func(begin, end int) error {
+		return predictor.predictCSR(matrix.Slice(begin, end), predictor.sliceBaseMargin(options.BaseMargin, begin, end), output_margin, options.NtreeLimit, preds[begin*num_output:end*num_output])
+	})
+}
+
+func (predictor *Predictor) sliceBaseMargin(base_margin []float32, begin, end int) []float32 {
+	if base_margin == nil {
+		return nil
+	}
+	num_output_group := predictor.Gbm.NumOutputGroup()
+	return base_margin[begin*num_output_group : end*num_output_group]
+}
+
+// runParallel calls predictChunk for consecutive row ranges of nrow rows
+// from a pool of workers. It stops handing out chunks after the first error
+// or when ctx is done, and returns that error.
+func runParallel(ctx context.Context, nrow int, options BatchOptions, predictChunk func(begin, end int) error) error {
+	chunkSize := options.chunkSize()
+	numChunk := (nrow + chunkSize - 1) / chunkSize
+	workers := options.workers()
+	if workers > numChunk {
+		workers = numChunk
+	}
+
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				begin := chunk * chunkSize
+				end := begin + chunkSize
+				if end > nrow {
+					end = nrow
+				}
+				err := predictChunk(begin, end)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	for chunk := 0; chunk < numChunk; chunk++ {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case chunks <- chunk:
+		case <-ctx.Done():
+		}
+	}
+	close(chunks)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
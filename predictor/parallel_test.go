@@ -0,0 +1,140 @@
+package predictor
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/data"
+)
+
+// randomMatrices returns the same rows as a dense matrix, with NaN for
+// missing values, and as a CSR matrix without them.
+func randomMatrices(t *testing.T, r *rand.Rand, nrow, ncol int) (*data.DenseMatrix, *data.CSRMatrix) {
+	t.Helper()
+	values := make([]float32, nrow*ncol)
+	indptr := []int{0}
+	var indices []int
+	var present []float32
+	for rid := 0; rid < nrow; rid++ {
+		for fid := 0; fid < ncol; fid++ {
+			value := float32(math.NaN())
+			if r.Intn(4) != 0 {
+				value = float32(r.NormFloat64())
+				indices = append(indices, fid)
+				present = append(present, value)
+			}
+			values[rid*ncol+fid] = value
+		}
+		indptr = append(indptr, len(indices))
+	}
+	dense, err := data.NewDenseMatrix(values, nrow, ncol, float32(math.NaN()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := data.NewCSRMatrix(indptr, indices, present, ncol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dense, csr
+}
+
+func checkBitwise(t *testing.T, name string, got, expected []float32) {
+	t.Helper()
+	for i := range expected {
+		if math.Float32bits(got[i]) != math.Float32bits(expected[i]) {
+			t.Fatalf("%s: value %d is %v, serial is %v", name, i, got[i], expected[i])
+		}
+	}
+}
+
+// TestPredictParallel checks that sharding does not change a single bit of
+// the serial output or the order of the rows.
+func TestPredictParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const nrow = 1000
+	for _, name := range []string{"logistic.bin", "softmax.bin", "linear.bin"} {
+		p := loadTestModel(t, name, *config.DEFAULT)
+		dense, csr := randomMatrices(t, r, nrow, 8)
+		for _, output_margin := range []bool{false, true} {
+			num_output := p.NumOutput(output_margin)
+			expected := make([]float32, nrow*num_output)
+			err := p.PredictDenseWithNtree(dense, output_margin, 2, expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			serialCSR := make([]float32, nrow*num_output)
+			err = p.PredictCSRWithNtree(csr, output_margin, 2, serialCSR)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkBitwise(t, name+" CSR", serialCSR, expected)
+			for rid := 0; rid < nrow; rid += 97 {
+				row := p.PredictArrayWithNtree(dense.Row(rid), false, output_margin, 2)
+				checkBitwise(t, name+" PredictArray", row, expected[rid*num_output:(rid+1)*num_output])
+			}
+
+			for _, chunkSize := range []int{0, 1, 7, 64, nrow, 2 * nrow} {
+				for _, parallelism := range []int{0, 1, 3, 16} {
+					options := BatchOptions{Parallelism: parallelism, ChunkSize: chunkSize, NtreeLimit: 2}
+					preds := make([]float32, nrow*num_output)
+					err = p.PredictDenseParallel(context.Background(), dense, output_margin, preds, options)
+					if err != nil {
+						t.Fatal(err)
+					}
+					checkBitwise(t, name+" PredictDenseParallel", preds, expected)
+
+					preds = make([]float32, nrow*num_output)
+					err = p.PredictCSRParallel(context.Background(), csr, output_margin, preds, options)
+					if err != nil {
+						t.Fatal(err)
+					}
+					checkBitwise(t, name+" PredictCSRParallel", preds, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestPredictParallelCancelled(t *testing.T) {
+	p := loadTestModel(t, "logistic.bin", *config.DEFAULT)
+	dense, csr := randomMatrices(t, rand.New(rand.NewSource(2)), 100, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	preds := make([]float32, 100)
+	options := BatchOptions{Parallelism: 4, ChunkSize: 10}
+	err := p.PredictDenseParallel(ctx, dense, false, preds, options)
+	if err != context.Canceled {
+		t.Errorf("PredictDenseParallel: got %v, want %v", err, context.Canceled)
+	}
+	err = p.PredictCSRParallel(ctx, csr, false, preds, options)
+	if err != context.Canceled {
+		t.Errorf("PredictCSRParallel: got %v, want %v", err, context.Canceled)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Unix(0, 0))
+	defer cancel()
+	err = p.PredictDenseParallel(ctx, dense, false, preds, options)
+	if err != context.DeadlineExceeded {
+		t.Errorf("PredictDenseParallel: got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestPredictParallelErrors(t *testing.T) {
+	p := loadTestModel(t, "logistic.bin", *config.DEFAULT)
+	dense, csr := randomMatrices(t, rand.New(rand.NewSource(3)), 10, 8)
+	options := BatchOptions{Parallelism: 2, ChunkSize: 3}
+	if p.PredictDenseParallel(context.Background(), dense, false, make([]float32, 9), options) == nil {
+		t.Error("PredictDenseParallel accepted a short buffer")
+	}
+	if p.PredictCSRParallel(context.Background(), csr, false, make([]float32, 9), options) == nil {
+		t.Error("PredictCSRParallel accepted a short buffer")
+	}
+	dense.NumRow = 11
+	if p.PredictDenseParallel(context.Background(), dense, false, make([]float32, 11), options) == nil {
+		t.Error("PredictDenseParallel accepted an invalid matrix")
+	}
+}
@@ -5,15 +5,32 @@ import (
 	"xgboost4go-predictor/gbm"
 	"xgboost4go-predictor/util"
 	"bufio"
+	"fmt"
+	"io"
 	"xgboost4go-predictor/config"
 )
 
+// Model formats recognised by the loaders, as reported by Predictor.Format.
+const (
+	MODEL_FORMAT_BINARY    = "binary"
+	MODEL_FORMAT_BINF      = "binf"
+	MODEL_FORMAT_XGBOOST4J = "xgboost4j"
+	MODEL_FORMAT_COMPACT   = "compact"
+)
+
 type Predictor struct {
+	Format      string
 	Mparam      *PredictorModelParam
 	Name_obj    string
 	Name_gbm    string
 	ObjFunction learner.ObjFunction
 	Gbm         gbm.GradBooster
+
+	FeatureMap         *util.FeatureMap
+	StrictFeatureNames bool
+	Missing            float32
+
+	closer io.Closer
 }
 
 func NewPredictorByReader(reader bufio.Reader) (*Predictor, error) {
@@ -22,7 +39,11 @@ func NewPredictorByReader(reader bufio.Reader) (*Predictor, error) {
 
 func NewPredictorByConf(reader bufio.Reader, configuration config.Configuration) (*Predictor, error) {
 	modelReader := util.NewModelReaderByReader(reader)
+	modelReader.MaxBytes = configuration.MaxModelBytes
 	predictor := new(Predictor)
+	predictor.FeatureMap = configuration.FeatureMap
+	predictor.StrictFeatureNames = configuration.StrictFeatureNames
+	predictor.Missing = configuration.MissingValue()
 	err := predictor.readParam(modelReader)
 	if err != nil {
 		return predictor, err
@@ -31,30 +52,43 @@ func NewPredictorByConf(reader bufio.Reader, configuration config.Configuration)
 	if err != nil {
 		return predictor, err
 	}
+	err = predictor.initBaseScore()
+	if err != nil {
+		return predictor, err
+	}
 	err = predictor.initObjGbm()
 	if err != nil {
 		return predictor, err
 	}
-	predictor.Gbm.LoadModel(modelReader, predictor.Mparam.saved_with_pbuffer != 0)
+	err = predictor.Gbm.LoadModel(modelReader, predictor.Mparam.saved_with_pbuffer != 0)
+	if err != nil {
+		return predictor, err
+	}
+	err = predictor.initEvaluator(configuration)
+	if err != nil {
+		return predictor, err
+	}
 	return predictor, nil
 }
 
 func (predictor *Predictor) readParam(reader *util.ModelReader) error {
 	first4Bytes, err := reader.ReadByteArray(4)
 	if err != nil {
-		return err
+		return reader.FieldError("header", err)
 	}
 	next4Bytes, err := reader.ReadByteArray(4)
 	if err != nil {
-		return err
+		return reader.FieldError("header", err)
 	}
 	var base_score float32
 	var num_feature int
+	predictor.Format = MODEL_FORMAT_BINARY
 	if (first4Bytes[0] == 98 && first4Bytes[1] == 105 && first4Bytes[2] == 110 && first4Bytes[3] == 102) {
+		predictor.Format = MODEL_FORMAT_BINF
 		base_score = reader.AsFloat(next4Bytes)
 		num_feature, err = reader.ReadUnsignedInt()
 		if err != nil {
-			return err
+			return reader.FieldError("num_feature", err)
 		}
 	} else if (first4Bytes[0] == 0 && first4Bytes[1] == 5 && first4Bytes[2] == 95) {
 		var modelType string
@@ -65,35 +99,36 @@ func (predictor *Predictor) readParam(reader *util.ModelReader) error {
 		}
 
 		if (modelType != "") {
+			predictor.Format = MODEL_FORMAT_XGBOOST4J
 			temp, err := reader.ReadByteAsInt()
 			if err != nil {
-				return err
+				return reader.FieldError("header", err)
 			}
 			len := (int(next4Bytes[3]) << 8) + temp
 			_, err = reader.ReadFixedString(len)
 			if err != nil {
-				return err
+				return reader.FieldError("header", err)
 			}
 			base_score, err = reader.ReadFloat()
 			if err != nil {
-				return err
+				return reader.FieldError("base_score", err)
 			}
 			num_feature, err = reader.ReadUnsignedInt()
 			if err != nil {
-				return err
+				return reader.FieldError("num_feature", err)
 			}
 		} else {
 			base_score = reader.AsFloat(first4Bytes)
 			num_feature, err = reader.AsUnsignedInt(next4Bytes)
 			if err != nil {
-				return err
+				return reader.FieldError("num_feature", err)
 			}
 		}
 	} else {
 		base_score = reader.AsFloat(first4Bytes)
 		num_feature, err = reader.AsUnsignedInt(next4Bytes)
 		if err != nil {
-			return err
+			return reader.FieldError("num_feature", err)
 		}
 	}
 
@@ -103,10 +138,10 @@ func (predictor *Predictor) readParam(reader *util.ModelReader) error {
 	}
 	predictor.Name_obj, err = reader.ReadString()
 	if err != nil {
-		return err
+		return reader.FieldError("name_obj", err)
 	}
 	predictor.Name_gbm, err = reader.ReadString()
-	return err
+	return reader.FieldError("name_gbm", err)
 }
 
 func (predictor *Predictor) initObjFunction(configuration config.Configuration) error {
@@ -118,12 +153,25 @@ func (predictor *Predictor) initObjFunction(configuration config.Configuration)
 	return err
 }
 
-func (predictor *Predictor) initObjGbm() error {
-	var err error
-	predictor.ObjFunction, err = learner.FromName(predictor.Name_obj)
+// initBaseScore turns base_score into a margin. XGBoost 1.0 and later save
+// base_score in prediction space, e.g. as a probability for binary:logistic,
+// and record their version in the reserved fields; earlier versions saved
+// the margin.
+func (predictor *Predictor) initBaseScore() error {
+	converter, ok := predictor.ObjFunction.(learner.BaseScoreConverter)
+	if !ok || predictor.Mparam.MajorVersion() < 1 {
+		return nil
+	}
+	base_score, err := converter.ProbToMargin(predictor.Mparam.base_score)
 	if err != nil {
 		return err
 	}
+	predictor.Mparam.base_score = base_score
+	return nil
+}
+
+func (predictor *Predictor) initObjGbm() error {
+	var err error
 	predictor.Gbm, err = gbm.CreateGradBooster(predictor.Name_gbm)
 	if err != nil {
 		return err
@@ -132,6 +180,31 @@ func (predictor *Predictor) initObjGbm() error {
 	return nil
 }
 
+func (predictor *Predictor) initEvaluator(configuration config.Configuration) error {
+	switch configuration.Evaluator {
+	case "", config.EVALUATOR_TRAVERSAL:
+		return nil
+	case config.EVALUATOR_QUICKSCORER:
+		if gbTree, ok := predictor.Gbm.(*gbm.GBTree); ok {
+			gbTree.UseQuickScorer()
+		}
+		return nil
+	case config.EVALUATOR_QUANTIZED:
+		if gbTree, ok := predictor.Gbm.(*gbm.GBTree); ok {
+			gbTree.UseQuantized()
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s is not supported evaluator.", configuration.Evaluator)
+	}
+}
+
+// SizeBytes estimates the memory held by the model. For a memory-mapped
+// model it includes the mapped forest.
+func (predictor *Predictor) SizeBytes() int {
+	return predictor.Gbm.SizeBytes()
+}
+
 func (predictor *Predictor) PredictArray(values []float32, treatsZeroAsNA bool) []float32 {
 	return predictor.PredictArrayWithMargin(values, treatsZeroAsNA, false)
 }
@@ -150,7 +223,7 @@ func (predictor *Predictor) PredictArrayWithNtree(values []float32, treatsZeroAs
 }
 
 func (predictor *Predictor) PredictArrayRaw(values []float32, treatsZeroAsNA bool, ntree_limit int) []float32 {
-	preds := predictor.Gbm.PredictArray(values, treatsZeroAsNA, ntree_limit)
+	preds := predictor.Gbm.PredictArray(values, predictor.missingValue(treatsZeroAsNA), ntree_limit)
 	for i := 0; i < len(preds); i++ {
 		preds[i] += predictor.Mparam.base_score
 	}
@@ -172,10 +245,19 @@ func (predictor *Predictor) PredictArraySingleWithMargin(values []float32, treat
 }
 
 func (predictor *Predictor) PredictArraySingleRaw(values []float32, treatsZeroAsNA bool) float32 {
-	temp := predictor.Gbm.PredictSingleFromArray(values, treatsZeroAsNA)
+	temp := predictor.Gbm.PredictSingleFromArray(values, predictor.missingValue(treatsZeroAsNA))
 	return temp + predictor.Mparam.base_score
 }
 
+// missingValue returns the missing value for array inputs: zero when
+// treatsZeroAsNA is set, the configured Missing otherwise.
+func (predictor *Predictor) missingValue(treatsZeroAsNA bool) float32 {
+	if treatsZeroAsNA {
+		return 0
+	}
+	return predictor.Missing
+}
+
 func (predictor *Predictor) PredictMap(values map[int]float32) []float32 {
 	return predictor.PredictMapWithMargin(values, false)
 }
@@ -235,12 +317,34 @@ func NewPredictorModelParam(base_score float32, num_feature int, reader *util.Mo
 	predictorModelParam.num_feature = num_feature
 	predictorModelParam.num_class, err = reader.ReadInt()
 	if err != nil {
-		return predictorModelParam, err
+		return predictorModelParam, reader.FieldError("num_class", err)
 	}
 	predictorModelParam.saved_with_pbuffer, err = reader.ReadInt()
 	if err != nil {
-		return predictorModelParam, err
+		return predictorModelParam, reader.FieldError("saved_with_pbuffer", err)
 	}
 	predictorModelParam.reserved, err = reader.ReadIntArray(30)
-	return predictorModelParam, err
+	return predictorModelParam, reader.FieldError("reserved", err)
+}
+
+// BaseScore returns base_score as a margin.
+func (predictorModelParam *PredictorModelParam) BaseScore() float32 {
+	return predictorModelParam.base_score
+}
+
+// MajorVersion returns the major version of XGBoost that saved the model,
+// or 0 for versions before 1.0, which did not record it.
+func (predictorModelParam *PredictorModelParam) MajorVersion() int {
+	if len(predictorModelParam.reserved) < 2 {
+		return 0
+	}
+	return predictorModelParam.reserved[1]
+}
+
+func (predictorModelParam *PredictorModelParam) NumFeature() int {
+	return predictorModelParam.num_feature
+}
+
+func (predictorModelParam *PredictorModelParam) NumClass() int {
+	return predictorModelParam.num_class
 }
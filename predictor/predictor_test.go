@@ -0,0 +1,155 @@
+package predictor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/util"
+)
+
+// testRows holds an ordinary row, a row of extreme values and an empty row.
+var testRows = [][]float32{
+	{1, -1, 0.5, 2, -3, 0, 1, 4},
+	{float32(math.NaN()), 0, float32(math.Inf(1)), -1e30, 1e-30},
+	{},
+}
+
+func loadTestModel(t testing.TB, name string, configuration config.Configuration) *Predictor {
+	t.Helper()
+	file, err := os.Open(filepath.Join("..", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	p, err := NewPredictorByConf(*bufio.NewReader(file), configuration)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return p
+}
+
+// singleLeafModel encodes a legacy binary gbtree model with one tree that is
+// a single leaf. major_version is written to the reserved fields as XGBoost
+// 1.0 and later do.
+func singleLeafModel(base_score float32, major_version int, objective string, leaf float32) []byte {
+	var buf bytes.Buffer
+	write := func(values ...interface{}) {
+		for _, v := range values {
+			binary.Write(&buf, binary.LittleEndian, v)
+		}
+	}
+	str := func(s string) {
+		write(int64(len(s)))
+		buf.WriteString(s)
+	}
+	reserved := make([]int32, 30)
+	reserved[1] = int32(major_version)
+	write(base_score, int32(1), int32(0), int32(0), reserved)
+	str(objective)
+	str("gbtree")
+	write(int32(1), int32(1), int32(1), int32(0), int64(0), int32(1), int32(0), make([]int32, 32))
+	write(int32(1), int32(1), int32(0), int32(0), int32(1), int32(0), make([]int32, 31))
+	write(int32(-1), int32(-1), int32(-1), uint32(0), leaf)
+	write(float32(0), float32(1), leaf, int32(0))
+	write(int32(0))
+	return buf.Bytes()
+}
+
+// TestBaseScore checks how base_score becomes a margin. The expected values
+// follow the conversion rules; they are not outputs of a real XGBoost.
+func TestBaseScore(t *testing.T) {
+	const leaf = 0.25
+	cases := []struct {
+		objective     string
+		major_version int
+		base_score    float32
+		margin        float64
+	}{
+		// Models saved before 1.0 store the margin.
+		{"binary:logistic", 0, 0.5, 0.5 + leaf},
+		{"binary:logistic", 0, -2, -2 + leaf},
+		// Later models store a probability for the logistic objectives.
+		{"binary:logistic", 1, 0.5, leaf},
+		{"binary:logistic", 1, 0.8, math.Log(4) + leaf},
+		{"binary:logitraw", 1, 0.2, -math.Log(4) + leaf},
+		// The other objectives use base_score as is.
+		{"reg:linear", 0, 0.5, 0.5 + leaf},
+		{"reg:linear", 1, 0.5, 0.5 + leaf},
+		{"reg:linear", 1, -3, -3 + leaf},
+	}
+	for _, c := range cases {
+		buf := singleLeafModel(c.base_score, c.major_version, c.objective, leaf)
+		p, err := NewPredictorByReader(*bufio.NewReader(bytes.NewReader(buf)))
+		if err != nil {
+			t.Fatalf("%s version %d: %v", c.objective, c.major_version, err)
+		}
+		got := p.PredictArrayWithMargin([]float32{1}, false, true)
+		if len(got) != 1 || math.Abs(float64(got[0])-c.margin) > 1e-6 {
+			t.Errorf("%s version %d base_score %v: got margin %v, want %v", c.objective, c.major_version, c.base_score, got, c.margin)
+		}
+		if math.Abs(float64(p.Mparam.BaseScore())-(c.margin-leaf)) > 1e-6 {
+			t.Errorf("%s version %d: BaseScore() = %v, want %v", c.objective, c.major_version, p.Mparam.BaseScore(), c.margin-leaf)
+		}
+	}
+
+	// A zero leaf predicts the base_score probability back.
+	for _, base_score := range []float32{0.01, 0.3, 0.5, 0.99} {
+		buf := singleLeafModel(base_score, 1, "binary:logistic", 0)
+		p, err := NewPredictorByReader(*bufio.NewReader(bytes.NewReader(buf)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := p.PredictArray([]float32{1}, false)
+		if math.Abs(float64(got[0]-base_score)) > 1e-6 {
+			t.Errorf("base_score %v: predicted %v", base_score, got[0])
+		}
+	}
+}
+
+// TestBaseScoreOutOfRange checks that a logistic base_score that is not a
+// probability is rejected instead of becoming an infinite or NaN margin.
+func TestBaseScoreOutOfRange(t *testing.T) {
+	for _, base_score := range []float32{0, 1, -0.5, 1.5, float32(math.NaN()), float32(math.Inf(1))} {
+		for _, objective := range []string{"binary:logistic", "binary:logitraw"} {
+			buf := singleLeafModel(base_score, 1, objective, 0.25)
+			_, err := NewPredictorByReader(*bufio.NewReader(bytes.NewReader(buf)))
+			if err == nil || !strings.Contains(err.Error(), "Invalid base_score") {
+				t.Errorf("%s base_score %v: got %v", objective, base_score, err)
+			}
+		}
+	}
+}
+
+func TestTruncatedModel(t *testing.T) {
+	buf, err := os.ReadFile("../testdata/logistic.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for length := 0; length < len(buf); length++ {
+		_, err := NewPredictorByReader(*bufio.NewReader(bytes.NewReader(buf[:length])))
+		var readError *util.ReadError
+		if !errors.As(err, &readError) {
+			t.Fatalf("length %d: got %v, want a ReadError", length, err)
+		}
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("length %d: got %v, want unexpected EOF", length, err)
+		}
+		if readError.Offset < 0 || readError.Offset > int64(length) {
+			t.Fatalf("length %d: offset %d out of range", length, readError.Offset)
+		}
+	}
+
+	_, err = NewPredictorByReader(*bufio.NewReader(bytes.NewReader(buf[:10])))
+	if err == nil || err.Error() != "num_class at offset 8: unexpected EOF" {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,1236 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: predictor.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type OutputType int32
+
+const (
+	OutputType_OUTPUT_TYPE_PREDICTION OutputType = 0
+	OutputType_OUTPUT_TYPE_MARGIN     OutputType = 1
+	OutputType_OUTPUT_TYPE_LEAF       OutputType = 2
+)
+
+// Enum value maps for OutputType.
+var (
+	OutputType_name = map[int32]string{
+		0: "OUTPUT_TYPE_PREDICTION",
+		1: "OUTPUT_TYPE_MARGIN",
+		2: "OUTPUT_TYPE_LEAF",
+	}
+	OutputType_value = map[string]int32{
+		"OUTPUT_TYPE_PREDICTION": 0,
+		"OUTPUT_TYPE_MARGIN":     1,
+		"OUTPUT_TYPE_LEAF":       2,
+	}
+)
+
+func (x OutputType) Enum() *OutputType {
+	p := new(OutputType)
+	*p = x
+	return p
+}
+
+func (x OutputType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OutputType) Descriptor() protoreflect.EnumDescriptor {
+	return file_predictor_proto_enumTypes[0].Descriptor()
+}
+
+func (OutputType) Type() protoreflect.EnumType {
+	return &file_predictor_proto_enumTypes[0]
+}
+
+func (x OutputType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OutputType.Descriptor instead.
+func (OutputType) EnumDescriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{0}
+}
+
+// Row holds the features of one instance.
+type Row struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Features:
+	//
+	//	*Row_Dense
+	//	*Row_Sparse
+	//	*Row_Named
+	Features      isRow_Features `protobuf_oneof:"features"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Row) Reset() {
+	*x = Row{}
+	mi := &file_predictor_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Row) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Row) ProtoMessage() {}
+
+func (x *Row) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Row.ProtoReflect.Descriptor instead.
+func (*Row) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Row) GetFeatures() isRow_Features {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *Row) GetDense() *DenseRow {
+	if x != nil {
+		if x, ok := x.Features.(*Row_Dense); ok {
+			return x.Dense
+		}
+	}
+	return nil
+}
+
+func (x *Row) GetSparse() *SparseRow {
+	if x != nil {
+		if x, ok := x.Features.(*Row_Sparse); ok {
+			return x.Sparse
+		}
+	}
+	return nil
+}
+
+func (x *Row) GetNamed() *NamedRow {
+	if x != nil {
+		if x, ok := x.Features.(*Row_Named); ok {
+			return x.Named
+		}
+	}
+	return nil
+}
+
+type isRow_Features interface {
+	isRow_Features()
+}
+
+type Row_Dense struct {
+	Dense *DenseRow `protobuf:"bytes,1,opt,name=dense,proto3,oneof"`
+}
+
+type Row_Sparse struct {
+	Sparse *SparseRow `protobuf:"bytes,2,opt,name=sparse,proto3,oneof"`
+}
+
+type Row_Named struct {
+	Named *NamedRow `protobuf:"bytes,3,opt,name=named,proto3,oneof"`
+}
+
+func (*Row_Dense) isRow_Features() {}
+
+func (*Row_Sparse) isRow_Features() {}
+
+func (*Row_Named) isRow_Features() {}
+
+// DenseRow holds feature values by index; NaN is missing.
+type DenseRow struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DenseRow) Reset() {
+	*x = DenseRow{}
+	mi := &file_predictor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DenseRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DenseRow) ProtoMessage() {}
+
+func (x *DenseRow) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DenseRow.ProtoReflect.Descriptor instead.
+func (*DenseRow) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DenseRow) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type SparseRow struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Indices       []int32                `protobuf:"varint,1,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+	Values        []float32              `protobuf:"fixed32,2,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SparseRow) Reset() {
+	*x = SparseRow{}
+	mi := &file_predictor_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SparseRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SparseRow) ProtoMessage() {}
+
+func (x *SparseRow) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SparseRow.ProtoReflect.Descriptor instead.
+func (*SparseRow) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SparseRow) GetIndices() []int32 {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+func (x *SparseRow) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// NamedRow holds feature values by name; the model needs a feature map.
+type NamedRow struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        map[string]float32     `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed32,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NamedRow) Reset() {
+	*x = NamedRow{}
+	mi := &file_predictor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NamedRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NamedRow) ProtoMessage() {}
+
+func (x *NamedRow) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NamedRow.ProtoReflect.Descriptor instead.
+func (*NamedRow) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *NamedRow) GetValues() map[string]float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type PredictRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Model   string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Version string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Row     *Row                   `protobuf:"bytes,3,opt,name=row,proto3" json:"row,omitempty"`
+	Output  OutputType             `protobuf:"varint,4,opt,name=output,proto3,enum=xgbpredictor.v1.OutputType" json:"output,omitempty"`
+	// Only applies to dense rows.
+	TreatZeroAsMissing bool  `protobuf:"varint,5,opt,name=treat_zero_as_missing,json=treatZeroAsMissing,proto3" json:"treat_zero_as_missing,omitempty"`
+	NtreeLimit         int32 `protobuf:"varint,6,opt,name=ntree_limit,json=ntreeLimit,proto3" json:"ntree_limit,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *PredictRequest) Reset() {
+	*x = PredictRequest{}
+	mi := &file_predictor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PredictRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictRequest) ProtoMessage() {}
+
+func (x *PredictRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictRequest.ProtoReflect.Descriptor instead.
+func (*PredictRequest) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PredictRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *PredictRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PredictRequest) GetRow() *Row {
+	if x != nil {
+		return x.Row
+	}
+	return nil
+}
+
+func (x *PredictRequest) GetOutput() OutputType {
+	if x != nil {
+		return x.Output
+	}
+	return OutputType_OUTPUT_TYPE_PREDICTION
+}
+
+func (x *PredictRequest) GetTreatZeroAsMissing() bool {
+	if x != nil {
+		return x.TreatZeroAsMissing
+	}
+	return false
+}
+
+func (x *PredictRequest) GetNtreeLimit() int32 {
+	if x != nil {
+		return x.NtreeLimit
+	}
+	return 0
+}
+
+// Prediction holds the predictions or margins of one row, one per output
+// group, or its leaf indices, one per tree.
+type Prediction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	Leaves        []int32                `protobuf:"varint,2,rep,packed,name=leaves,proto3" json:"leaves,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Prediction) Reset() {
+	*x = Prediction{}
+	mi := &file_predictor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Prediction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Prediction) ProtoMessage() {}
+
+func (x *Prediction) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Prediction.ProtoReflect.Descriptor instead.
+func (*Prediction) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Prediction) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *Prediction) GetLeaves() []int32 {
+	if x != nil {
+		return x.Leaves
+	}
+	return nil
+}
+
+type PredictResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Prediction    *Prediction            `protobuf:"bytes,3,opt,name=prediction,proto3" json:"prediction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PredictResponse) Reset() {
+	*x = PredictResponse{}
+	mi := &file_predictor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PredictResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictResponse) ProtoMessage() {}
+
+func (x *PredictResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictResponse.ProtoReflect.Descriptor instead.
+func (*PredictResponse) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PredictResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *PredictResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PredictResponse) GetPrediction() *Prediction {
+	if x != nil {
+		return x.Prediction
+	}
+	return nil
+}
+
+type PredictBatchRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Model              string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Version            string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Rows               []*Row                 `protobuf:"bytes,3,rep,name=rows,proto3" json:"rows,omitempty"`
+	Output             OutputType             `protobuf:"varint,4,opt,name=output,proto3,enum=xgbpredictor.v1.OutputType" json:"output,omitempty"`
+	TreatZeroAsMissing bool                   `protobuf:"varint,5,opt,name=treat_zero_as_missing,json=treatZeroAsMissing,proto3" json:"treat_zero_as_missing,omitempty"`
+	NtreeLimit         int32                  `protobuf:"varint,6,opt,name=ntree_limit,json=ntreeLimit,proto3" json:"ntree_limit,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *PredictBatchRequest) Reset() {
+	*x = PredictBatchRequest{}
+	mi := &file_predictor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PredictBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictBatchRequest) ProtoMessage() {}
+
+func (x *PredictBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictBatchRequest.ProtoReflect.Descriptor instead.
+func (*PredictBatchRequest) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PredictBatchRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *PredictBatchRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PredictBatchRequest) GetRows() []*Row {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+func (x *PredictBatchRequest) GetOutput() OutputType {
+	if x != nil {
+		return x.Output
+	}
+	return OutputType_OUTPUT_TYPE_PREDICTION
+}
+
+func (x *PredictBatchRequest) GetTreatZeroAsMissing() bool {
+	if x != nil {
+		return x.TreatZeroAsMissing
+	}
+	return false
+}
+
+func (x *PredictBatchRequest) GetNtreeLimit() int32 {
+	if x != nil {
+		return x.NtreeLimit
+	}
+	return 0
+}
+
+type PredictBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Predictions   []*Prediction          `protobuf:"bytes,3,rep,name=predictions,proto3" json:"predictions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PredictBatchResponse) Reset() {
+	*x = PredictBatchResponse{}
+	mi := &file_predictor_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PredictBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictBatchResponse) ProtoMessage() {}
+
+func (x *PredictBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictBatchResponse.ProtoReflect.Descriptor instead.
+func (*PredictBatchResponse) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PredictBatchResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *PredictBatchResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PredictBatchResponse) GetPredictions() []*Prediction {
+	if x != nil {
+		return x.Predictions
+	}
+	return nil
+}
+
+type ExplainRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Model              string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Version            string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Row                *Row                   `protobuf:"bytes,3,opt,name=row,proto3" json:"row,omitempty"`
+	TreatZeroAsMissing bool                   `protobuf:"varint,4,opt,name=treat_zero_as_missing,json=treatZeroAsMissing,proto3" json:"treat_zero_as_missing,omitempty"`
+	NtreeLimit         int32                  `protobuf:"varint,5,opt,name=ntree_limit,json=ntreeLimit,proto3" json:"ntree_limit,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ExplainRequest) Reset() {
+	*x = ExplainRequest{}
+	mi := &file_predictor_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainRequest) ProtoMessage() {}
+
+func (x *ExplainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainRequest.ProtoReflect.Descriptor instead.
+func (*ExplainRequest) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ExplainRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *ExplainRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ExplainRequest) GetRow() *Row {
+	if x != nil {
+		return x.Row
+	}
+	return nil
+}
+
+func (x *ExplainRequest) GetTreatZeroAsMissing() bool {
+	if x != nil {
+		return x.TreatZeroAsMissing
+	}
+	return false
+}
+
+func (x *ExplainRequest) GetNtreeLimit() int32 {
+	if x != nil {
+		return x.NtreeLimit
+	}
+	return 0
+}
+
+// Contributions holds one value per feature and the bias, which includes
+// base_score; together they add up to the margin of the output group.
+type Contributions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	Bias          float32                `protobuf:"fixed32,2,opt,name=bias,proto3" json:"bias,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Contributions) Reset() {
+	*x = Contributions{}
+	mi := &file_predictor_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Contributions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Contributions) ProtoMessage() {}
+
+func (x *Contributions) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Contributions.ProtoReflect.Descriptor instead.
+func (*Contributions) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Contributions) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *Contributions) GetBias() float32 {
+	if x != nil {
+		return x.Bias
+	}
+	return 0
+}
+
+type ExplainResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Groups        []*Contributions       `protobuf:"bytes,3,rep,name=groups,proto3" json:"groups,omitempty"`
+	FeatureNames  []string               `protobuf:"bytes,4,rep,name=feature_names,json=featureNames,proto3" json:"feature_names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainResponse) Reset() {
+	*x = ExplainResponse{}
+	mi := &file_predictor_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainResponse) ProtoMessage() {}
+
+func (x *ExplainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainResponse.ProtoReflect.Descriptor instead.
+func (*ExplainResponse) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ExplainResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *ExplainResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ExplainResponse) GetGroups() []*Contributions {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *ExplainResponse) GetFeatureNames() []string {
+	if x != nil {
+		return x.FeatureNames
+	}
+	return nil
+}
+
+type GetModelMetadataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetModelMetadataRequest) Reset() {
+	*x = GetModelMetadataRequest{}
+	mi := &file_predictor_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetModelMetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetModelMetadataRequest) ProtoMessage() {}
+
+func (x *GetModelMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetModelMetadataRequest.ProtoReflect.Descriptor instead.
+func (*GetModelMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetModelMetadataRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *GetModelMetadataRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type ModelMetadata struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Name           string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version        string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Aliases        []string               `protobuf:"bytes,3,rep,name=aliases,proto3" json:"aliases,omitempty"`
+	Source         string                 `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	SizeBytes      int64                  `protobuf:"varint,5,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	Objective      string                 `protobuf:"bytes,6,opt,name=objective,proto3" json:"objective,omitempty"`
+	Booster        string                 `protobuf:"bytes,7,opt,name=booster,proto3" json:"booster,omitempty"`
+	NumFeature     int32                  `protobuf:"varint,8,opt,name=num_feature,json=numFeature,proto3" json:"num_feature,omitempty"`
+	NumClass       int32                  `protobuf:"varint,9,opt,name=num_class,json=numClass,proto3" json:"num_class,omitempty"`
+	NumOutputGroup int32                  `protobuf:"varint,10,opt,name=num_output_group,json=numOutputGroup,proto3" json:"num_output_group,omitempty"`
+	NumTrees       int32                  `protobuf:"varint,11,opt,name=num_trees,json=numTrees,proto3" json:"num_trees,omitempty"`
+	BaseScore      float32                `protobuf:"fixed32,12,opt,name=base_score,json=baseScore,proto3" json:"base_score,omitempty"`
+	FeatureNames   []string               `protobuf:"bytes,13,rep,name=feature_names,json=featureNames,proto3" json:"feature_names,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ModelMetadata) Reset() {
+	*x = ModelMetadata{}
+	mi := &file_predictor_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelMetadata) ProtoMessage() {}
+
+func (x *ModelMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_predictor_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelMetadata.ProtoReflect.Descriptor instead.
+func (*ModelMetadata) Descriptor() ([]byte, []int) {
+	return file_predictor_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ModelMetadata) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ModelMetadata) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ModelMetadata) GetAliases() []string {
+	if x != nil {
+		return x.Aliases
+	}
+	return nil
+}
+
+func (x *ModelMetadata) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ModelMetadata) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *ModelMetadata) GetObjective() string {
+	if x != nil {
+		return x.Objective
+	}
+	return ""
+}
+
+func (x *ModelMetadata) GetBooster() string {
+	if x != nil {
+		return x.Booster
+	}
+	return ""
+}
+
+func (x *ModelMetadata) GetNumFeature() int32 {
+	if x != nil {
+		return x.NumFeature
+	}
+	return 0
+}
+
+func (x *ModelMetadata) GetNumClass() int32 {
+	if x != nil {
+		return x.NumClass
+	}
+	return 0
+}
+
+func (x *ModelMetadata) GetNumOutputGroup() int32 {
+	if x != nil {
+		return x.NumOutputGroup
+	}
+	return 0
+}
+
+func (x *ModelMetadata) GetNumTrees() int32 {
+	if x != nil {
+		return x.NumTrees
+	}
+	return 0
+}
+
+func (x *ModelMetadata) GetBaseScore() float32 {
+	if x != nil {
+		return x.BaseScore
+	}
+	return 0
+}
+
+func (x *ModelMetadata) GetFeatureNames() []string {
+	if x != nil {
+		return x.FeatureNames
+	}
+	return nil
+}
+
+var File_predictor_proto protoreflect.FileDescriptor
+
+const file_predictor_proto_rawDesc = "" +
+	"\n" +
+	"\x0fpredictor.proto\x12\x0fxgbpredictor.v1\"\xad\x01\n" +
+	"\x03Row\x121\n" +
+	"\x05dense\x18\x01 \x01(\v2\x19.xgbpredictor.v1.DenseRowH\x00R\x05dense\x124\n" +
+	"\x06sparse\x18\x02 \x01(\v2\x1a.xgbpredictor.v1.SparseRowH\x00R\x06sparse\x121\n" +
+	"\x05named\x18\x03 \x01(\v2\x19.xgbpredictor.v1.NamedRowH\x00R\x05namedB\n" +
+	"\n" +
+	"\bfeatures\"\"\n" +
+	"\bDenseRow\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\x02R\x06values\"=\n" +
+	"\tSparseRow\x12\x18\n" +
+	"\aindices\x18\x01 \x03(\x05R\aindices\x12\x16\n" +
+	"\x06values\x18\x02 \x03(\x02R\x06values\"\x84\x01\n" +
+	"\bNamedRow\x12=\n" +
+	"\x06values\x18\x01 \x03(\v2%.xgbpredictor.v1.NamedRow.ValuesEntryR\x06values\x1a9\n" +
+	"\vValuesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x02R\x05value:\x028\x01\"\xf1\x01\n" +
+	"\x0ePredictRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12&\n" +
+	"\x03row\x18\x03 \x01(\v2\x14.xgbpredictor.v1.RowR\x03row\x123\n" +
+	"\x06output\x18\x04 \x01(\x0e2\x1b.xgbpredictor.v1.OutputTypeR\x06output\x121\n" +
+	"\x15treat_zero_as_missing\x18\x05 \x01(\bR\x12treatZeroAsMissing\x12\x1f\n" +
+	"\vntree_limit\x18\x06 \x01(\x05R\n" +
+	"ntreeLimit\"<\n" +
+	"\n" +
+	"Prediction\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\x02R\x06values\x12\x16\n" +
+	"\x06leaves\x18\x02 \x03(\x05R\x06leaves\"~\n" +
+	"\x0fPredictResponse\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12;\n" +
+	"\n" +
+	"prediction\x18\x03 \x01(\v2\x1b.xgbpredictor.v1.PredictionR\n" +
+	"prediction\"\xf8\x01\n" +
+	"\x13PredictBatchRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12(\n" +
+	"\x04rows\x18\x03 \x03(\v2\x14.xgbpredictor.v1.RowR\x04rows\x123\n" +
+	"\x06output\x18\x04 \x01(\x0e2\x1b.xgbpredictor.v1.OutputTypeR\x06output\x121\n" +
+	"\x15treat_zero_as_missing\x18\x05 \x01(\bR\x12treatZeroAsMissing\x12\x1f\n" +
+	"\vntree_limit\x18\x06 \x01(\x05R\n" +
+	"ntreeLimit\"\x85\x01\n" +
+	"\x14PredictBatchResponse\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12=\n" +
+	"\vpredictions\x18\x03 \x03(\v2\x1b.xgbpredictor.v1.PredictionR\vpredictions\"\xbc\x01\n" +
+	"\x0eExplainRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12&\n" +
+	"\x03row\x18\x03 \x01(\v2\x14.xgbpredictor.v1.RowR\x03row\x121\n" +
+	"\x15treat_zero_as_missing\x18\x04 \x01(\bR\x12treatZeroAsMissing\x12\x1f\n" +
+	"\vntree_limit\x18\x05 \x01(\x05R\n" +
+	"ntreeLimit\";\n" +
+	"\rContributions\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\x02R\x06values\x12\x12\n" +
+	"\x04bias\x18\x02 \x01(\x02R\x04bias\"\x9e\x01\n" +
+	"\x0fExplainResponse\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x126\n" +
+	"\x06groups\x18\x03 \x03(\v2\x1e.xgbpredictor.v1.ContributionsR\x06groups\x12#\n" +
+	"\rfeature_names\x18\x04 \x03(\tR\ffeatureNames\"I\n" +
+	"\x17GetModelMetadataRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\"\x8f\x03\n" +
+	"\rModelMetadata\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x18\n" +
+	"\aaliases\x18\x03 \x03(\tR\aaliases\x12\x16\n" +
+	"\x06source\x18\x04 \x01(\tR\x06source\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x05 \x01(\x03R\tsizeBytes\x12\x1c\n" +
+	"\tobjective\x18\x06 \x01(\tR\tobjective\x12\x18\n" +
+	"\abooster\x18\a \x01(\tR\abooster\x12\x1f\n" +
+	"\vnum_feature\x18\b \x01(\x05R\n" +
+	"numFeature\x12\x1b\n" +
+	"\tnum_class\x18\t \x01(\x05R\bnumClass\x12(\n" +
+	"\x10num_output_group\x18\n" +
+	" \x01(\x05R\x0enumOutputGroup\x12\x1b\n" +
+	"\tnum_trees\x18\v \x01(\x05R\bnumTrees\x12\x1d\n" +
+	"\n" +
+	"base_score\x18\f \x01(\x02R\tbaseScore\x12#\n" +
+	"\rfeature_names\x18\r \x03(\tR\ffeatureNames*V\n" +
+	"\n" +
+	"OutputType\x12\x1a\n" +
+	"\x16OUTPUT_TYPE_PREDICTION\x10\x00\x12\x16\n" +
+	"\x12OUTPUT_TYPE_MARGIN\x10\x01\x12\x14\n" +
+	"\x10OUTPUT_TYPE_LEAF\x10\x022\xc4\x03\n" +
+	"\tPredictor\x12L\n" +
+	"\aPredict\x12\x1f.xgbpredictor.v1.PredictRequest\x1a .xgbpredictor.v1.PredictResponse\x12[\n" +
+	"\fPredictBatch\x12$.xgbpredictor.v1.PredictBatchRequest\x1a%.xgbpredictor.v1.PredictBatchResponse\x12L\n" +
+	"\aExplain\x12\x1f.xgbpredictor.v1.ExplainRequest\x1a .xgbpredictor.v1.ExplainResponse\x12\\\n" +
+	"\x10GetModelMetadata\x12(.xgbpredictor.v1.GetModelMetadataRequest\x1a\x1e.xgbpredictor.v1.ModelMetadata\x12`\n" +
+	"\rPredictStream\x12$.xgbpredictor.v1.PredictBatchRequest\x1a%.xgbpredictor.v1.PredictBatchResponse(\x010\x01B\x1eZ\x1cxgboost4go-predictor/rpc;rpcb\x06proto3"
+
+var (
+	file_predictor_proto_rawDescOnce sync.Once
+	file_predictor_proto_rawDescData []byte
+)
+
+func file_predictor_proto_rawDescGZIP() []byte {
+	file_predictor_proto_rawDescOnce.Do(func() {
+		file_predictor_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_predictor_proto_rawDesc), len(file_predictor_proto_rawDesc)))
+	})
+	return file_predictor_proto_rawDescData
+}
+
+var file_predictor_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_predictor_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_predictor_proto_goTypes = []any{
+	(OutputType)(0),                 // 0: xgbpredictor.v1.OutputType
+	(*Row)(nil),                     // 1: xgbpredictor.v1.Row
+	(*DenseRow)(nil),                // 2: xgbpredictor.v1.DenseRow
+	(*SparseRow)(nil),               // 3: xgbpredictor.v1.SparseRow
+	(*NamedRow)(nil),                // 4: xgbpredictor.v1.NamedRow
+	(*PredictRequest)(nil),          // 5: xgbpredictor.v1.PredictRequest
+	(*Prediction)(nil),              // 6: xgbpredictor.v1.Prediction
+	(*PredictResponse)(nil),         // 7: xgbpredictor.v1.PredictResponse
+	(*PredictBatchRequest)(nil),     // 8: xgbpredictor.v1.PredictBatchRequest
+	(*PredictBatchResponse)(nil),    // 9: xgbpredictor.v1.PredictBatchResponse
+	(*ExplainRequest)(nil),          // 10: xgbpredictor.v1.ExplainRequest
+	(*Contributions)(nil),           // 11: xgbpredictor.v1.Contributions
+	(*ExplainResponse)(nil),         // 12: xgbpredictor.v1.ExplainResponse
+	(*GetModelMetadataRequest)(nil), // 13: xgbpredictor.v1.GetModelMetadataRequest
+	(*ModelMetadata)(nil),           // 14: xgbpredictor.v1.ModelMetadata
+	nil,                             // 15: xgbpredictor.v1.NamedRow.ValuesEntry
+}
+var file_predictor_proto_depIdxs = []int32{
+	2,  // 0: xgbpredictor.v1.Row.dense:type_name -> xgbpredictor.v1.DenseRow
+	3,  // 1: xgbpredictor.v1.Row.sparse:type_name -> xgbpredictor.v1.SparseRow
+	4,  // 2: xgbpredictor.v1.Row.named:type_name -> xgbpredictor.v1.NamedRow
+	15, // 3: xgbpredictor.v1.NamedRow.values:type_name -> xgbpredictor.v1.NamedRow.ValuesEntry
+	1,  // 4: xgbpredictor.v1.PredictRequest.row:type_name -> xgbpredictor.v1.Row
+	0,  // 5: xgbpredictor.v1.PredictRequest.output:type_name -> xgbpredictor.v1.OutputType
+	6,  // 6: xgbpredictor.v1.PredictResponse.prediction:type_name -> xgbpredictor.v1.Prediction
+	1,  // 7: xgbpredictor.v1.PredictBatchRequest.rows:type_name -> xgbpredictor.v1.Row
+	0,  // 8: xgbpredictor.v1.PredictBatchRequest.output:type_name -> xgbpredictor.v1.OutputType
+	6,  // 9: xgbpredictor.v1.PredictBatchResponse.predictions:type_name -> xgbpredictor.v1.Prediction
+	1,  // 10: xgbpredictor.v1.ExplainRequest.row:type_name -> xgbpredictor.v1.Row
+	11, // 11: xgbpredictor.v1.ExplainResponse.groups:type_name -> xgbpredictor.v1.Contributions
+	5,  // 12: xgbpredictor.v1.Predictor.Predict:input_type -> xgbpredictor.v1.PredictRequest
+	8,  // 13: xgbpredictor.v1.Predictor.PredictBatch:input_type -> xgbpredictor.v1.PredictBatchRequest
+	10, // 14: xgbpredictor.v1.Predictor.Explain:input_type -> xgbpredictor.v1.ExplainRequest
+	13, // 15: xgbpredictor.v1.Predictor.GetModelMetadata:input_type -> xgbpredictor.v1.GetModelMetadataRequest
+	8,  // 16: xgbpredictor.v1.Predictor.PredictStream:input_type -> xgbpredictor.v1.PredictBatchRequest
+	7,  // 17: xgbpredictor.v1.Predictor.Predict:output_type -> xgbpredictor.v1.PredictResponse
+	9,  // 18: xgbpredictor.v1.Predictor.PredictBatch:output_type -> xgbpredictor.v1.PredictBatchResponse
+	12, // 19: xgbpredictor.v1.Predictor.Explain:output_type -> xgbpredictor.v1.ExplainResponse
+	14, // 20: xgbpredictor.v1.Predictor.GetModelMetadata:output_type -> xgbpredictor.v1.ModelMetadata
+	9,  // 21: xgbpredictor.v1.Predictor.PredictStream:output_type -> xgbpredictor.v1.PredictBatchResponse
+	17, // [17:22] is the sub-list for method output_type
+	12, // [12:17] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_predictor_proto_init() }
+func file_predictor_proto_init() {
+	if File_predictor_proto != nil {
+		return
+	}
+	file_predictor_proto_msgTypes[0].OneofWrappers = []any{
+		(*Row_Dense)(nil),
+		(*Row_Sparse)(nil),
+		(*Row_Named)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_predictor_proto_rawDesc), len(file_predictor_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_predictor_proto_goTypes,
+		DependencyIndexes: file_predictor_proto_depIdxs,
+		EnumInfos:         file_predictor_proto_enumTypes,
+		MessageInfos:      file_predictor_proto_msgTypes,
+	}.Build()
+	File_predictor_proto = out.File
+	file_predictor_proto_goTypes = nil
+	file_predictor_proto_depIdxs = nil
+}
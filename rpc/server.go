@@ -0,0 +1,243 @@
+// Package rpc implements the gRPC Predictor service of predictor.proto on
+// top of a serving.Registry.
+//
+// The server can be exercised in-process with
+// google.golang.org/grpc/test/bufconn:
+//
+//	listener := bufconn.Listen(1 << 20)
+//	server := grpc.NewServer()
+//	rpc.RegisterPredictorServer(server, rpc.NewServer(registry))
+//	go server.Serve(listener)
+//	conn, _ := grpc.NewClient("passthrough:///bufnet",
+//		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+//			return listener.DialContext(ctx)
+//		}),
+//		grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	client := rpc.NewPredictorClient(conn)
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative predictor.proto
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"xgboost4go-predictor/gbm"
+	"xgboost4go-predictor/predictor"
+	"xgboost4go-predictor/serving"
+)
+
+const DEFAULT_MAX_ROWS = 100000
+
+type Server struct {
+	UnimplementedPredictorServer
+	registry *serving.Registry
+	// MaxRows limits the rows of a batch request.
+	MaxRows int
+}
+
+func NewServer(registry *serving.Registry) *Server {
+	return &Server{registry: registry, MaxRows: DEFAULT_MAX_ROWS}
+}
+
+func (server *Server) model(name, version string) (*serving.Model, error) {
+	model, err := server.registry.Get(name, version)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return model, nil
+}
+
+func (server *Server) Predict(ctx context.Context, request *PredictRequest) (*PredictResponse, error) {
+	model, err := server.model(request.GetModel(), request.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	prediction, err := predictRow(model.Predictor, request.GetRow(), request.GetOutput(), request.GetTreatZeroAsMissing(), int(request.GetNtreeLimit()))
+	if err != nil {
+		return nil, err
+	}
+	return &PredictResponse{Model: request.GetModel(), Version: model.Version, Prediction: prediction}, nil
+}
+
+func (server *Server) PredictBatch(ctx context.Context, request *PredictBatchRequest) (*PredictBatchResponse, error) {
+	model, err := server.model(request.GetModel(), request.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	rows := request.GetRows()
+	if len(rows) > server.MaxRows {
+		return nil, status.Errorf(codes.InvalidArgument, "Too many rows: %d, the limit is %d.", len(rows), server.MaxRows)
+	}
+	response := &PredictBatchResponse{Model: request.GetModel(), Version: model.Version}
+	response.Predictions = make([]*Prediction, len(rows))
+	for i, row := range rows {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		response.Predictions[i], err = predictRow(model.Predictor, row, request.GetOutput(), request.GetTreatZeroAsMissing(), int(request.GetNtreeLimit()))
+		if err != nil {
+			return nil, status.Errorf(status.Code(err), "Row %d: %s", i, status.Convert(err).Message())
+		}
+	}
+	return response, nil
+}
+
+func (server *Server) PredictStream(stream Predictor_PredictStreamServer) error {
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		response, err := server.PredictBatch(stream.Context(), request)
+		if err != nil {
+			return err
+		}
+		err = stream.Send(response)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (server *Server) Explain(ctx context.Context, request *ExplainRequest) (*ExplainResponse, error) {
+	model, err := server.model(request.GetModel(), request.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	p := model.Predictor
+	values, sparse, err := rowValues(p, request.GetRow())
+	if err != nil {
+		return nil, err
+	}
+	var contribs []float32
+	if sparse != nil {
+		contribs, err = p.PredictMapContributionsWithNtree(sparse, int(request.GetNtreeLimit()))
+	} else {
+		contribs, err = p.PredictContributionsWithNtree(values, request.GetTreatZeroAsMissing(), int(request.GetNtreeLimit()))
+	}
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	response := &ExplainResponse{Model: request.GetModel(), Version: model.Version}
+	num_output_group := p.Gbm.NumOutputGroup()
+	width := len(contribs) / num_output_group
+	for gid := 0; gid < num_output_group; gid++ {
+		group := contribs[gid*width : (gid+1)*width]
+		response.Groups = append(response.Groups, &Contributions{Values: group[:width-1], Bias: group[width-1]})
+	}
+	if p.FeatureMap != nil {
+		for fid := 0; fid < p.FeatureMap.NumFeature(); fid++ {
+			response.FeatureNames = append(response.FeatureNames, p.FeatureMap.Name(fid))
+		}
+	}
+	return response, nil
+}
+
+func (server *Server) GetModelMetadata(ctx context.Context, request *GetModelMetadataRequest) (*ModelMetadata, error) {
+	model, err := server.model(request.GetModel(), request.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range server.registry.List() {
+		if info.Model != model {
+			continue
+		}
+		p := model.Predictor
+		metadata := &ModelMetadata{
+			Name:           info.Name,
+			Version:        info.Version,
+			Aliases:        info.Aliases,
+			Source:         info.Source,
+			SizeBytes:      int64(info.SizeBytes),
+			Objective:      p.Name_obj,
+			Booster:        p.Name_gbm,
+			NumFeature:     int32(p.Mparam.NumFeature()),
+			NumClass:       int32(p.Mparam.NumClass()),
+			NumOutputGroup: int32(p.Gbm.NumOutputGroup()),
+			BaseScore:      p.Mparam.BaseScore(),
+		}
+		if gbTree, ok := p.Gbm.(*gbm.GBTree); ok {
+			metadata.NumTrees = int32(gbTree.Forest().NumTrees())
+		}
+		if p.FeatureMap != nil {
+			for fid := 0; fid < p.FeatureMap.NumFeature(); fid++ {
+				metadata.FeatureNames = append(metadata.FeatureNames, p.FeatureMap.Name(fid))
+			}
+		}
+		return metadata, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "Model %s was unloaded.", request.GetModel())
+}
+
+// rowValues returns the values of a dense row, or the map of a sparse or
+// named one.
+func rowValues(p *predictor.Predictor, row *Row) ([]float32, map[int]float32, error) {
+	switch features := row.GetFeatures().(type) {
+	case *Row_Dense:
+		return features.Dense.GetValues(), nil, nil
+	case *Row_Sparse:
+		indices, values := features.Sparse.GetIndices(), features.Sparse.GetValues()
+		if len(indices) != len(values) {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "Sparse row has %d indices and %d values.", len(indices), len(values))
+		}
+		sparse := make(map[int]float32, len(indices))
+		for i, fid := range indices {
+			if fid < 0 {
+				return nil, nil, status.Errorf(codes.InvalidArgument, "Invalid feature index %d.", fid)
+			}
+			sparse[int(fid)] = values[i]
+		}
+		return nil, sparse, nil
+	case *Row_Named:
+		sparse, err := p.NamedToMap(features.Named.GetValues())
+		if err != nil {
+			return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, sparse, nil
+	default:
+		return nil, nil, status.Error(codes.InvalidArgument, "Row has no features.")
+	}
+}
+
+func predictRow(p *predictor.Predictor, row *Row, output OutputType, treatsZeroAsNA bool, ntree_limit int) (*Prediction, error) {
+	if ntree_limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ntree_limit must not be negative.")
+	}
+	values, sparse, err := rowValues(p, row)
+	if err != nil {
+		return nil, err
+	}
+	switch output {
+	case OutputType_OUTPUT_TYPE_PREDICTION, OutputType_OUTPUT_TYPE_MARGIN:
+		output_margin := output == OutputType_OUTPUT_TYPE_MARGIN
+		if sparse != nil {
+			return &Prediction{Values: p.PredictMapWithNtree(sparse, output_margin, ntree_limit)}, nil
+		}
+		return &Prediction{Values: p.PredictArrayWithNtree(values, treatsZeroAsNA, output_margin, ntree_limit)}, nil
+	case OutputType_OUTPUT_TYPE_LEAF:
+		var leaves []int
+		if sparse != nil {
+			leaves, err = p.PredictMapLeaf(sparse, ntree_limit)
+		} else {
+			leaves, err = p.PredictLeaf(values, treatsZeroAsNA, ntree_limit)
+		}
+		if err != nil {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		prediction := &Prediction{Leaves: make([]int32, len(leaves))}
+		for i, leaf := range leaves {
+			prediction.Leaves[i] = int32(leaf)
+		}
+		return prediction, nil
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "Unknown output type %d.", output)
+	}
+}
@@ -0,0 +1,346 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/serving"
+	"xgboost4go-predictor/util"
+)
+
+func newTestClient(t *testing.T, registry *serving.Registry) PredictorClient {
+	t.Helper()
+	return newTestClientFor(t, NewServer(registry))
+}
+
+func newTestClientFor(t *testing.T, predictorServer *Server) PredictorClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterPredictorServer(server, predictorServer)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewPredictorClient(conn)
+}
+
+func TestPredictOverBufconn(t *testing.T) {
+	registry := serving.NewRegistry(serving.RegistryOptions{})
+	model, err := registry.LoadFile("logistic", "1", "../testdata/logistic.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient(t, registry)
+	ctx := context.Background()
+
+	values := []float32{0.5, -1, 2, 0, 3, -0.25}
+	response, err := client.Predict(ctx, &PredictRequest{
+		Model: "logistic",
+		Row:   &Row{Features: &Row_Dense{Dense: &DenseRow{Values: values}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := model.Predictor.PredictArray(values, false)
+	got := response.GetPrediction().GetValues()
+	if len(got) != len(expected) || got[0] != expected[0] {
+		t.Fatalf("Predict = %v, want %v", got, expected)
+	}
+	if response.GetVersion() != "1" {
+		t.Errorf("version = %q, want 1", response.GetVersion())
+	}
+
+	sparse, err := client.Predict(ctx, &PredictRequest{
+		Model:  "logistic",
+		Output: OutputType_OUTPUT_TYPE_MARGIN,
+		Row:    &Row{Features: &Row_Sparse{Sparse: &SparseRow{Indices: []int32{0, 2}, Values: []float32{0.5, 2}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	margin := model.Predictor.PredictMapWithMargin(map[int]float32{0: 0.5, 2: 2}, true)
+	if got := sparse.GetPrediction().GetValues(); len(got) != 1 || got[0] != margin[0] {
+		t.Fatalf("sparse margin = %v, want %v", got, margin)
+	}
+
+	_, err = client.Predict(ctx, &PredictRequest{Model: "missing", Row: &Row{}})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown model: got %v, want NotFound", err)
+	}
+	_, err = client.Predict(ctx, &PredictRequest{Model: "logistic", Row: &Row{}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty row: got %v, want InvalidArgument", err)
+	}
+}
+
+// newTestRegistry loads logistic.bin with a feature map and the alias
+// "stable", and softmax.bin.
+func newTestRegistry(t *testing.T) *serving.Registry {
+	t.Helper()
+	featureMap, err := util.NewFeatureMapByReader(strings.NewReader("0 a q\n1 b q\n2 c q\n3 d q\n4 e q\n5 f q\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configuration := *config.DEFAULT
+	configuration.FeatureMap = featureMap
+	registry := serving.NewRegistry(serving.RegistryOptions{Configuration: configuration})
+	_, err = registry.LoadFile("logistic", "1", "../testdata/logistic.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = registry.SetAlias("logistic", "stable", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = registry.LoadFile("softmax", "3", "../testdata/softmax.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func denseRow(values ...float32) *Row {
+	return &Row{Features: &Row_Dense{Dense: &DenseRow{Values: values}}}
+}
+
+var testRows = []*Row{
+	denseRow(0.5, -1, 2, 0, 3, -0.25),
+	{Features: &Row_Sparse{Sparse: &SparseRow{Indices: []int32{1, 4}, Values: []float32{2, -3}}}},
+	{Features: &Row_Named{Named: &NamedRow{Values: map[string]float32{"a": 1, "f": 2}}}},
+	denseRow(),
+}
+
+func TestPredictBatch(t *testing.T) {
+	registry := newTestRegistry(t)
+	server := NewServer(registry)
+	server.MaxRows = len(testRows)
+	client := newTestClientFor(t, server)
+	ctx := context.Background()
+
+	model, err := registry.Get("logistic", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.PredictBatch(ctx, &PredictBatchRequest{Model: "logistic", Version: "stable", Rows: testRows})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.GetVersion() != "1" || len(response.GetPredictions()) != len(testRows) {
+		t.Fatalf("got version %q and %d predictions", response.GetVersion(), len(response.GetPredictions()))
+	}
+	for i, row := range testRows {
+		single, err := client.Predict(ctx, &PredictRequest{Model: "logistic", Row: row})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, want := response.GetPredictions()[i].GetValues(), single.GetPrediction().GetValues()
+		if len(got) != 1 || got[0] != want[0] {
+			t.Errorf("row %d: batch %v, single %v", i, got, want)
+		}
+	}
+	expected := model.Predictor.PredictMap(map[int]float32{0: 1, 5: 2})
+	if got := response.GetPredictions()[2].GetValues(); got[0] != expected[0] {
+		t.Errorf("named row: %v, want %v", got, expected)
+	}
+
+	leaves, err := client.PredictBatch(ctx, &PredictBatchRequest{Model: "logistic", Rows: testRows[:1], Output: OutputType_OUTPUT_TYPE_LEAF, NtreeLimit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := model.Predictor.PredictLeaf(testRows[0].GetDense().GetValues(), false, 2)
+	got := leaves.GetPredictions()[0].GetLeaves()
+	if len(got) != len(want) || int(got[0]) != want[0] || int(got[1]) != want[1] {
+		t.Errorf("leaves %v, want %v", got, want)
+	}
+
+	cases := []struct {
+		name    string
+		request *PredictBatchRequest
+		code    codes.Code
+	}{
+		{"too many rows", &PredictBatchRequest{Model: "logistic", Rows: append(testRows, denseRow(1))}, codes.InvalidArgument},
+		{"unknown model", &PredictBatchRequest{Model: "absent", Rows: testRows}, codes.NotFound},
+		{"unknown version", &PredictBatchRequest{Model: "logistic", Version: "2", Rows: testRows}, codes.NotFound},
+		{"empty row", &PredictBatchRequest{Model: "logistic", Rows: []*Row{denseRow(1), {}}}, codes.InvalidArgument},
+		{"negative ntree_limit", &PredictBatchRequest{Model: "logistic", Rows: testRows, NtreeLimit: -1}, codes.InvalidArgument},
+		{"negative feature index", &PredictBatchRequest{Model: "logistic", Rows: []*Row{{Features: &Row_Sparse{Sparse: &SparseRow{Indices: []int32{-1}, Values: []float32{1}}}}}}, codes.InvalidArgument},
+	}
+	for _, c := range cases {
+		_, err := client.PredictBatch(ctx, c.request)
+		if status.Code(err) != c.code {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.code)
+		}
+	}
+	_, err = client.PredictBatch(ctx, &PredictBatchRequest{Model: "logistic", Rows: []*Row{denseRow(1), {}}})
+	if !strings.Contains(status.Convert(err).Message(), "Row 1:") {
+		t.Errorf("error %v does not name the row", err)
+	}
+}
+
+func TestPredictBatchCancelled(t *testing.T) {
+	server := NewServer(newTestRegistry(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := server.PredictBatch(ctx, &PredictBatchRequest{Model: "logistic", Rows: testRows})
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("got %v, want Canceled", err)
+	}
+
+	client := newTestClientFor(t, server)
+	_, err = client.PredictBatch(ctx, &PredictBatchRequest{Model: "logistic", Rows: testRows})
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("over bufconn: got %v, want Canceled", err)
+	}
+}
+
+func TestPredictStream(t *testing.T) {
+	client := newTestClient(t, newTestRegistry(t))
+	stream, err := client.PredictStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := []*PredictBatchRequest{
+		{Model: "logistic", Rows: testRows},
+		{Model: "softmax", Rows: testRows[:2]},
+		{Model: "logistic", Rows: testRows[:1], Output: OutputType_OUTPUT_TYPE_MARGIN},
+	}
+	for _, request := range requests {
+		err = stream.Send(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, request := range requests {
+		response, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.GetModel() != request.GetModel() || len(response.GetPredictions()) != len(request.GetRows()) {
+			t.Errorf("response %d: %s with %d predictions, want %s with %d", i, response.GetModel(), len(response.GetPredictions()), request.GetModel(), len(request.GetRows()))
+		}
+	}
+
+	// An invalid batch ends the stream with its error.
+	err = stream.Send(&PredictBatchRequest{Model: "absent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("got %v, want NotFound", err)
+	}
+	_, err = stream.Recv()
+	if err == nil || err == io.EOF {
+		t.Errorf("stream continued after an error: %v", err)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	registry := newTestRegistry(t)
+	client := newTestClient(t, registry)
+	ctx := context.Background()
+	for _, name := range []string{"logistic", "softmax"} {
+		model, err := registry.Get(name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := model.Predictor
+		values := testRows[0].GetDense().GetValues()
+		response, err := client.Explain(ctx, &ExplainRequest{Model: name, Row: testRows[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		contribs, err := p.PredictContributions(values, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		margins := p.PredictArrayWithMargin(values, false, true)
+		num_group := p.Gbm.NumOutputGroup()
+		width := len(contribs) / num_group
+		if len(response.GetGroups()) != num_group {
+			t.Fatalf("%s: %d groups, want %d", name, len(response.GetGroups()), num_group)
+		}
+		for gid, group := range response.GetGroups() {
+			if len(group.GetValues()) != width-1 {
+				t.Fatalf("%s group %d: %d values, want %d", name, gid, len(group.GetValues()), width-1)
+			}
+			sum := float64(group.GetBias())
+			for fid, value := range group.GetValues() {
+				if value != contribs[gid*width+fid] {
+					t.Errorf("%s group %d feature %d: %v, want %v", name, gid, fid, value, contribs[gid*width+fid])
+				}
+				sum += float64(value)
+			}
+			if group.GetBias() != contribs[(gid+1)*width-1] {
+				t.Errorf("%s group %d: bias %v, want %v", name, gid, group.GetBias(), contribs[(gid+1)*width-1])
+			}
+			if math.Abs(sum-float64(margins[gid])) > 1e-5 {
+				t.Errorf("%s group %d: contributions add up to %v, margin is %v", name, gid, sum, margins[gid])
+			}
+		}
+	}
+
+	response, err := client.Explain(ctx, &ExplainRequest{Model: "logistic", Row: testRows[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := response.GetFeatureNames()
+	if len(names) != 6 || names[0] != "a" || names[5] != "f" {
+		t.Errorf("feature names %v", names)
+	}
+	_, err = client.Explain(ctx, &ExplainRequest{Model: "logistic", Row: &Row{}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty row: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestGetModelMetadata(t *testing.T) {
+	client := newTestClient(t, newTestRegistry(t))
+	ctx := context.Background()
+	metadata, err := client.GetModelMetadata(ctx, &GetModelMetadataRequest{Model: "logistic", Version: "stable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.GetName() != "logistic" || metadata.GetVersion() != "1" || strings.Join(metadata.GetAliases(), ",") != "default,stable" {
+		t.Errorf("got %v", metadata)
+	}
+	if metadata.GetObjective() != "binary:logistic" || metadata.GetBooster() != "gbtree" || metadata.GetNumTrees() == 0 || metadata.GetNumOutputGroup() != 1 {
+		t.Errorf("got %v", metadata)
+	}
+	if metadata.GetSource() != "../testdata/logistic.bin" || metadata.GetSizeBytes() <= 0 || len(metadata.GetFeatureNames()) != 6 {
+		t.Errorf("got %v", metadata)
+	}
+
+	softmax, err := client.GetModelMetadata(ctx, &GetModelMetadataRequest{Model: "softmax"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if softmax.GetVersion() != "3" || softmax.GetNumClass() < 2 || softmax.GetNumOutputGroup() != softmax.GetNumClass() {
+		t.Errorf("got %v", softmax)
+	}
+
+	_, err = client.GetModelMetadata(ctx, &GetModelMetadataRequest{Model: "logistic", Version: "9"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown version: got %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,181 @@
+// Package serving keeps models available to long-running services: a Handle
+// holds the active model of one service and replaces it while predictions
+// are in flight.
+package serving
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"xgboost4go-predictor/config"
+	"xgboost4go-predictor/predictor"
+)
+
+// Model is a loaded predictor together with where it came from. A Model is
+// never modified after it has been installed in a Handle.
+type Model struct {
+	Predictor *predictor.Predictor
+	Version   string
+	Source    string
+	LoadedAt  time.Time
+}
+
+// HandleOptions controls how a Handle loads and validates models.
+// CanaryRows are predicted with every new model before it is installed;
+// a panic, a wrong number of outputs or a NaN or infinite margin rejects
+// the model. Validate, when set, runs after the canary rows. OnSwap is
+// called after a model is installed, with the model it replaced, and
+// OnError when a load fails; both are called from the loading goroutine.
+// OnSwap runs before the next load can start, so calls arrive in the order
+// of the swaps; it must not load or swap models on the same Handle.
+type HandleOptions struct {
+	Configuration config.Configuration
+	CanaryRows    [][]float32
+	Validate      func(model *Model) error
+	OnSwap        func(current, previous *Model)
+	OnError       func(source string, err error)
+}
+
+// Handle holds the active model of a service. Current and Predictor may be
+// called from any goroutine; they return either the old or the new model,
+// never a partly loaded one. Loads are serialized.
+type Handle struct {
+	options HandleOptions
+	current atomic.Value
+	loading sync.Mutex
+}
+
+type modelBox struct {
+	model *Model
+}
+
+func NewHandle(options HandleOptions) *Handle {
+	handle := new(Handle)
+	handle.options = options
+	handle.current.Store(modelBox{})
+	return handle
+}
+
+// Current returns the active model, or nil before the first load.
+func (handle *Handle) Current() *Model {
+	return handle.current.Load().(modelBox).model
+}
+
+// Predictor returns the predictor of the active model, or nil before the
+// first load. Callers should take it once per request so that all of the
+// request is served by the same model.
+func (handle *Handle) Predictor() *predictor.Predictor {
+	model := handle.Current()
+	if model == nil {
+		return nil
+	}
+	return model.Predictor
+}
+
+// Swap installs model without validating it and returns the model it
+// replaced. It is meant for models that were validated before, such as a
+// rollback to a previous version.
+func (handle *Handle) Swap(model *Model) *Model {
+	handle.loading.Lock()
+	defer handle.loading.Unlock()
+	return handle.swap(model)
+}
+
+// swap installs model and calls OnSwap. The caller holds loading.
+func (handle *Handle) swap(model *Model) *Model {
+	previous := handle.Current()
+	handle.current.Store(modelBox{model})
+	if handle.options.OnSwap != nil {
+		handle.options.OnSwap(model, previous)
+	}
+	return previous
+}
+
+// LoadFile loads, validates and installs the model at path. On failure the
+// active model is kept.
+func (handle *Handle) LoadFile(path, version string) (*Model, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, handle.fail(path, err)
+	}
+	defer file.Close()
+	return handle.LoadReader(file, path, version)
+}
+
+// LoadReader is like LoadFile for a model read from reader; source is only
+// used to describe the model.
+func (handle *Handle) LoadReader(reader io.Reader, source, version string) (*Model, error) {
+	handle.loading.Lock()
+	model, err := handle.load(reader, source, version)
+	if err == nil {
+		handle.swap(model)
+	}
+	handle.loading.Unlock()
+
+	if err != nil {
+		return nil, handle.fail(source, err)
+	}
+	return model, nil
+}
+
+// ReloadFile runs LoadFile in a new goroutine. The returned channel
+// receives the result of the load and is then closed.
+func (handle *Handle) ReloadFile(path, version string) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := handle.LoadFile(path, version)
+		done <- err
+		close(done)
+	}()
+	return done
+}
+
+func (handle *Handle) load(reader io.Reader, source, version string) (model *Model, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			model, err = nil, fmt.Errorf("Loading %s panicked: %v", source, r)
+		}
+	}()
+	p, err := predictor.NewPredictorByConf(*bufio.NewReader(reader), handle.options.Configuration)
+	if err != nil {
+		return nil, err
+	}
+	model = &Model{Predictor: p, Version: version, Source: source, LoadedAt: time.Now()}
+	err = handle.validate(model)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func (handle *Handle) validate(model *Model) error {
+	num_output_group := model.Predictor.Gbm.NumOutputGroup()
+	for i, row := range handle.options.CanaryRows {
+		margins := model.Predictor.PredictArrayWithMargin(row, false, true)
+		if len(margins) != num_output_group {
+			return fmt.Errorf("Canary row %d gives %d margins, expected %d.", i, len(margins), num_output_group)
+		}
+		for _, margin := range margins {
+			if math.IsNaN(float64(margin)) || math.IsInf(float64(margin), 0) {
+				return fmt.Errorf("Canary row %d gives margin %v.", i, margin)
+			}
+		}
+	}
+	if handle.options.Validate != nil {
+		return handle.options.Validate(model)
+	}
+	return nil
+}
+
+func (handle *Handle) fail(source string, err error) error {
+	if handle.options.OnError != nil {
+		handle.options.OnError(source, err)
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+package serving
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"xgboost4go-predictor/internal/testmodel"
+)
+
+// nanModel predicts NaN for every row.
+func nanModel() []byte {
+	return testmodel.Encode(testmodel.Spec{
+		NumFeature: 1,
+		Objective:  "reg:linear",
+		Trees:      []testmodel.Tree{{Nodes: []testmodel.Node{{Parent: -1, Left: -1, Right: -1, Value: float32(math.NaN())}}}},
+	})
+}
+
+func readTestModel(t *testing.T, name string) []byte {
+	t.Helper()
+	buf, err := os.ReadFile("../testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestHandleRejectsBadModels(t *testing.T) {
+	var failures []string
+	validated := 0
+	rejectVersion := errors.New("rejected by Validate")
+	handle := NewHandle(HandleOptions{
+		CanaryRows: [][]float32{{1, 2, 3}, {}},
+		Validate: func(model *Model) error {
+			validated++
+			if model.Version == "rejected" {
+				return rejectVersion
+			}
+			return nil
+		},
+		OnError: func(source string, err error) {
+			failures = append(failures, source)
+		},
+	})
+	if handle.Current() != nil || handle.Predictor() != nil {
+		t.Fatal("a new handle has a model")
+	}
+	first, err := handle.LoadReader(bytes.NewReader(readTestModel(t, "logistic.bin")), "first", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handle.Current() != first || handle.Predictor() != first.Predictor || first.Version != "1" || first.Source != "first" {
+		t.Fatalf("Current() = %v after loading %v", handle.Current(), first)
+	}
+
+	cases := []struct {
+		source string
+		buf    []byte
+		err    string
+	}{
+		{"nan", nanModel(), "Canary row 0 gives margin NaN."},
+		{"truncated", readTestModel(t, "logistic.bin")[:100], "unexpected EOF"},
+		{"garbage", []byte("not a model"), ""},
+	}
+	for _, c := range cases {
+		model, err := handle.LoadReader(bytes.NewReader(c.buf), c.source, "2")
+		if err == nil || model != nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: got %v, %v", c.source, model, err)
+		}
+		if handle.Current() != first {
+			t.Errorf("%s replaced the active model", c.source)
+		}
+	}
+	if validated != 1 {
+		t.Errorf("Validate ran %d times, want only for the model that passed the canary rows", validated)
+	}
+
+	_, err = handle.LoadReader(bytes.NewReader(readTestModel(t, "regression.bin")), "validated", "rejected")
+	if err != rejectVersion {
+		t.Errorf("got %v, want the error of Validate", err)
+	}
+	if handle.Current() != first {
+		t.Error("a model rejected by Validate replaced the active model")
+	}
+	expected := []string{"nan", "truncated", "garbage", "validated"}
+	if fmt.Sprint(failures) != fmt.Sprint(expected) {
+		t.Errorf("OnError sources %v, want %v", failures, expected)
+	}
+
+	// A Validate that panics rejects the model.
+	panicking := NewHandle(HandleOptions{Validate: func(model *Model) error { panic("boom") }})
+	_, err = panicking.LoadReader(bytes.NewReader(readTestModel(t, "logistic.bin")), "panic", "1")
+	if err == nil || !strings.Contains(err.Error(), "panicked: boom") || panicking.Current() != nil {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestHandleReloadFile(t *testing.T) {
+	var swaps, failures int
+	handle := NewHandle(HandleOptions{
+		OnSwap:  func(current, previous *Model) { swaps++ },
+		OnError: func(source string, err error) { failures++ },
+	})
+	err := <-handle.ReloadFile("../testdata/logistic.bin", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := handle.Current()
+	if first == nil || first.Version != "1" || first.Source != "../testdata/logistic.bin" {
+		t.Fatalf("Current() = %v", first)
+	}
+
+	done := handle.ReloadFile("../testdata/absent.bin", "2")
+	err = <-done
+	if !os.IsNotExist(err) {
+		t.Errorf("missing file: got %v", err)
+	}
+	if _, ok := <-done; ok {
+		t.Error("the channel is not closed after the result")
+	}
+	if handle.Current() != first {
+		t.Error("a failed reload replaced the active model")
+	}
+	if swaps != 1 || failures != 1 {
+		t.Errorf("%d swaps and %d failures, want 1 and 1", swaps, failures)
+	}
+}
+
+// TestHandleOnSwapOrder checks that OnSwap calls arrive in the order of the
+// swaps under concurrent loads: every call's previous model is the current
+// model of the call before.
+func TestHandleOnSwapOrder(t *testing.T) {
+	type swap struct {
+		current, previous *Model
+	}
+	var mu sync.Mutex
+	var swaps []swap
+	handle := NewHandle(HandleOptions{
+		OnSwap: func(current, previous *Model) {
+			// Give the next load time to overtake a late OnSwap.
+			time.Sleep(time.Duration(len(current.Version)%2) * time.Millisecond)
+			mu.Lock()
+			swaps = append(swaps, swap{current, previous})
+			mu.Unlock()
+		},
+	})
+	buf := readTestModel(t, "logistic.bin")
+	const loads = 32
+	var wg sync.WaitGroup
+	for i := 0; i < loads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%4 == 0 {
+				if err := <-handle.ReloadFile("../testdata/logistic.bin", fmt.Sprint(i)); err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if _, err := handle.LoadReader(bytes.NewReader(buf), "reader", fmt.Sprint(i)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(swaps) != loads {
+		t.Fatalf("%d swaps, want %d", len(swaps), loads)
+	}
+	var previous *Model
+	for i, s := range swaps {
+		if s.previous != previous {
+			t.Fatalf("swap %d replaced %v, want %v", i, s.previous, previous)
+		}
+		previous = s.current
+	}
+	if handle.Current() != previous {
+		t.Errorf("the active model is not the one of the last OnSwap")
+	}
+
+	rolledBack := handle.Swap(swaps[0].current)
+	if rolledBack != previous || handle.Current() != swaps[0].current || len(swaps) != loads+1 || swaps[loads].previous != previous {
+		t.Error("Swap did not install the model and call OnSwap")
+	}
+}
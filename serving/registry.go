@@ -0,0 +1,251 @@
+package serving
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"xgboost4go-predictor/config"
+)
+
+// RegistryOptions controls how a Registry loads and validates models; the
+// fields have the same meaning as in HandleOptions.
+type RegistryOptions struct {
+	Configuration config.Configuration
+	CanaryRows    [][]float32
+	Validate      func(model *Model) error
+}
+
+// Registry holds the models of a process by name and version. Each name can
+// have aliases, such as "stable", that point to one of its versions; the
+// "default" alias is used when no version is given and otherwise resolves to
+// the highest version by CompareVersions. It is safe for concurrent use.
+type Registry struct {
+	options RegistryOptions
+
+	mu     sync.RWMutex
+	models map[string]*registryEntry
+}
+
+const DEFAULT_ALIAS = "default"
+
+type registryEntry struct {
+	versions map[string]*Model
+	aliases  map[string]string
+}
+
+// ModelInfo describes a model held by a Registry.
+type ModelInfo struct {
+	Name      string
+	Version   string
+	Aliases   []string
+	Source    string
+	SizeBytes int
+	Model     *Model
+}
+
+func NewRegistry(options RegistryOptions) *Registry {
+	registry := new(Registry)
+	registry.options = options
+	registry.models = make(map[string]*registryEntry)
+	return registry
+}
+
+// LoadFile loads and validates the model at path and adds it as version of
+// name. It fails when that version is already registered; Unload it first
+// to replace it.
+func (registry *Registry) LoadFile(name, version, path string) (*Model, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return registry.LoadReader(name, version, file, path)
+}
+
+func (registry *Registry) LoadReader(name, version string, reader io.Reader, source string) (*Model, error) {
+	handle := NewHandle(HandleOptions{
+		Configuration: registry.options.Configuration,
+		CanaryRows:    registry.options.CanaryRows,
+		Validate:      registry.options.Validate,
+	})
+	model, err := handle.load(reader, source, version)
+	if err != nil {
+		return nil, err
+	}
+	err = registry.Add(name, model)
+	if err != nil {
+		model.Predictor.Close()
+		return nil, err
+	}
+	return model, nil
+}
+
+// Add registers a model that was loaded elsewhere, such as a memory-mapped
+// one, under name and model.Version. It fails when the version is already
+// registered or is the name of an alias, which would hide it.
+func (registry *Registry) Add(name string, model *Model) error {
+	if name == "" || model.Version == "" {
+		return fmt.Errorf("Model name and version are required.")
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if model.Version == DEFAULT_ALIAS {
+		return fmt.Errorf("Version %s of model %s is the name of an alias.", model.Version, name)
+	}
+	entry, ok := registry.models[name]
+	if ok {
+		if _, ok := entry.versions[model.Version]; ok {
+			return fmt.Errorf("Model %s version %s is already loaded.", name, model.Version)
+		}
+		if _, ok := entry.aliases[model.Version]; ok {
+			return fmt.Errorf("Version %s of model %s is the name of an alias.", model.Version, name)
+		}
+	} else {
+		entry = &registryEntry{versions: make(map[string]*Model), aliases: make(map[string]string)}
+		registry.models[name] = entry
+	}
+	entry.versions[model.Version] = model
+	return nil
+}
+
+// Get returns a version of the model called name. version may be an alias;
+// when it is empty the default version is returned.
+func (registry *Registry) Get(name, version string) (*Model, error) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	entry, ok := registry.models[name]
+	if !ok {
+		return nil, fmt.Errorf("Model %s is not loaded.", name)
+	}
+	resolved := entry.resolve(version)
+	model, ok := entry.versions[resolved]
+	if !ok {
+		return nil, fmt.Errorf("Model %s has no version %s.", name, version)
+	}
+	return model, nil
+}
+
+func (entry *registryEntry) resolve(version string) string {
+	if version == "" {
+		version = DEFAULT_ALIAS
+	}
+	if target, ok := entry.aliases[version]; ok {
+		return target
+	}
+	if version == DEFAULT_ALIAS {
+		return entry.latest()
+	}
+	return version
+}
+
+func (entry *registryEntry) latest() string {
+	latest := ""
+	for version := range entry.versions {
+		if latest == "" || CompareVersions(version, latest) > 0 {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// SetAlias points alias of name at version, which must be loaded.
+func (registry *Registry) SetAlias(name, alias, version string) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	entry, ok := registry.models[name]
+	if !ok {
+		return fmt.Errorf("Model %s is not loaded.", name)
+	}
+	if _, ok := entry.versions[version]; !ok {
+		return fmt.Errorf("Model %s has no version %s.", name, version)
+	}
+	if _, ok := entry.versions[alias]; ok || alias == "" {
+		return fmt.Errorf("Alias %q of model %s is not valid.", alias, name)
+	}
+	entry.aliases[alias] = version
+	return nil
+}
+
+func (registry *Registry) RemoveAlias(name, alias string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if entry, ok := registry.models[name]; ok {
+		delete(entry.aliases, alias)
+	}
+}
+
+// Unload removes a version of name, together with the aliases pointing at
+// it, and returns the removed model. The predictor is not closed, since
+// requests may still use it; callers that memory-map models should close it
+// once those requests are done.
+func (registry *Registry) Unload(name, version string) (*Model, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	entry, ok := registry.models[name]
+	if !ok {
+		return nil, fmt.Errorf("Model %s is not loaded.", name)
+	}
+	model, ok := entry.versions[version]
+	if !ok {
+		return nil, fmt.Errorf("Model %s has no version %s.", name, version)
+	}
+	delete(entry.versions, version)
+	for alias, target := range entry.aliases {
+		if target == version {
+			delete(entry.aliases, alias)
+		}
+	}
+	if len(entry.versions) == 0 {
+		delete(registry.models, name)
+	}
+	return model, nil
+}
+
+// List returns every loaded version, ordered by name and then by version.
+func (registry *Registry) List() []ModelInfo {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	var infos []ModelInfo
+	for name, entry := range registry.models {
+		defaultVersion := entry.resolve(DEFAULT_ALIAS)
+		_, explicitDefault := entry.aliases[DEFAULT_ALIAS]
+		for version, model := range entry.versions {
+			info := ModelInfo{
+				Name:      name,
+				Version:   version,
+				Source:    model.Source,
+				SizeBytes: model.Predictor.SizeBytes(),
+				Model:     model,
+			}
+			for alias, target := range entry.aliases {
+				if target == version {
+					info.Aliases = append(info.Aliases, alias)
+				}
+			}
+			if version == defaultVersion && !explicitDefault {
+				info.Aliases = append(info.Aliases, DEFAULT_ALIAS)
+			}
+			sort.Strings(info.Aliases)
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Name != infos[j].Name {
+			return infos[i].Name < infos[j].Name
+		}
+		return CompareVersions(infos[i].Version, infos[j].Version) < 0
+	})
+	return infos
+}
+
+// SizeBytes returns the estimated memory held by all loaded models.
+func (registry *Registry) SizeBytes() int {
+	size := 0
+	for _, info := range registry.List() {
+		size += info.SizeBytes
+	}
+	return size
+}
@@ -0,0 +1,82 @@
+package serving
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	registry := NewRegistry(RegistryOptions{})
+	first, err := registry.LoadFile("m", "1", "../testdata/logistic.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = registry.LoadFile("m", "1", "../testdata/regression.bin")
+	if err == nil || !strings.Contains(err.Error(), "already loaded") {
+		t.Errorf("duplicate version: got %v", err)
+	}
+	if model, _ := registry.Get("m", "1"); model != first {
+		t.Errorf("duplicate version replaced the loaded model")
+	}
+
+	err = registry.SetAlias("m", "stable", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []string{"stable", DEFAULT_ALIAS} {
+		_, err = registry.LoadFile("m", version, "../testdata/regression.bin")
+		if err == nil || !strings.Contains(err.Error(), "alias") {
+			t.Errorf("version %s: got %v, want an alias error", version, err)
+		}
+	}
+	if model, _ := registry.Get("m", "stable"); model != first {
+		t.Errorf("stable no longer resolves to version 1")
+	}
+
+	_, err = registry.Unload("m", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := registry.LoadFile("m", "1", "../testdata/regression.bin")
+	if err != nil {
+		t.Fatalf("reload after Unload: %v", err)
+	}
+	if model, _ := registry.Get("m", ""); model != second {
+		t.Errorf("default version is not the reloaded model")
+	}
+}
+
+// TestRegistryAddValidatesFirst checks that a rejected first version does
+// not leave an empty entry behind.
+func TestRegistryAddValidatesFirst(t *testing.T) {
+	registry := NewRegistry(RegistryOptions{})
+	for _, version := range []string{DEFAULT_ALIAS, ""} {
+		_, err := registry.LoadFile("m", version, "../testdata/logistic.bin")
+		if err == nil {
+			t.Errorf("version %q was accepted", version)
+		}
+	}
+	_, err := registry.LoadFile("", "1", "../testdata/logistic.bin")
+	if err == nil {
+		t.Error("a model without a name was accepted")
+	}
+	if infos := registry.List(); len(infos) != 0 {
+		t.Errorf("List() = %v after rejected loads", infos)
+	}
+	_, err = registry.Get("m", "")
+	if err == nil || !strings.Contains(err.Error(), "is not loaded") {
+		t.Errorf("Get: got %v, want a not loaded error", err)
+	}
+	err = registry.SetAlias("m", "stable", "1")
+	if err == nil || !strings.Contains(err.Error(), "is not loaded") {
+		t.Errorf("SetAlias: got %v, want a not loaded error", err)
+	}
+
+	_, err = registry.LoadFile("m", "1", "../testdata/logistic.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model, err := registry.Get("m", ""); err != nil || model.Version != "1" {
+		t.Errorf("Get: got %v, %v", model, err)
+	}
+}
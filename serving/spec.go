@@ -0,0 +1,61 @@
+package serving
+
+import (
+	"fmt"
+	"strings"
+
+	"xgboost4go-predictor/util"
+)
+
+// ListFlag is a repeatable command-line flag collecting its values, as used
+// for -model and -fmap.
+type ListFlag []string
+
+func (list *ListFlag) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *ListFlag) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
+// ModelSpec names a model file to load, as given on a command line in the
+// form name[:version]=path. The version is "1" when omitted.
+type ModelSpec struct {
+	Name    string
+	Version string
+	Path    string
+}
+
+func ParseModelSpec(value string) (ModelSpec, error) {
+	eq := strings.Index(value, "=")
+	if eq <= 0 || eq == len(value)-1 {
+		return ModelSpec{}, fmt.Errorf("Invalid model %q, expected name[:version]=path.", value)
+	}
+	spec := ModelSpec{Name: value[:eq], Version: "1", Path: value[eq+1:]}
+	if colon := strings.Index(spec.Name, ":"); colon >= 0 {
+		spec.Name, spec.Version = spec.Name[:colon], spec.Name[colon+1:]
+	}
+	if spec.Name == "" || spec.Version == "" {
+		return ModelSpec{}, fmt.Errorf("Invalid model %q, expected name[:version]=path.", value)
+	}
+	return spec, nil
+}
+
+// LoadFeatureMaps reads feature maps given as name=path.
+func LoadFeatureMaps(values []string) (map[string]*util.FeatureMap, error) {
+	featureMaps := make(map[string]*util.FeatureMap)
+	for _, value := range values {
+		eq := strings.Index(value, "=")
+		if eq <= 0 || eq == len(value)-1 {
+			return nil, fmt.Errorf("Invalid feature map %q, expected name=path.", value)
+		}
+		fm, err := util.NewFeatureMapByFile(value[eq+1:])
+		if err != nil {
+			return nil, err
+		}
+		featureMaps[value[:eq]] = fm
+	}
+	return featureMaps, nil
+}
@@ -0,0 +1,281 @@
+package serving
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DEFAULT_POLL_INTERVAL = 10 * time.Second
+
+// WatcherOptions describes where a Watcher looks for models. Model files are
+// named Prefix + version + Suffix, for example "ctr-20240115.3.bin" with
+// Prefix "ctr-" and Suffix ".bin"; versions are ordered by CompareVersions.
+type WatcherOptions struct {
+	Dir      string
+	Prefix   string
+	Suffix   string
+	Interval time.Duration
+}
+
+// Watcher polls a directory and installs the newest valid model in a Handle.
+// A file is loaded once its size and modification time are unchanged
+// between two polls, so that files still being written are skipped; files
+// present at the first poll are assumed complete. A version that fails to
+// load, or that was rolled back, is not tried again until its file changes.
+type Watcher struct {
+	handle  *Handle
+	options WatcherOptions
+
+	// polling serializes Poll and Rollback and guards the file stamps. It
+	// is held while models are loaded and swapped, so OnSwap may call the
+	// methods that only take mu.
+	polling  sync.Mutex
+	polled   bool
+	stamps   map[string]fileStamp
+	rejected map[string]fileStamp
+
+	mu       sync.Mutex
+	previous *Model
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+type modelFile struct {
+	path    string
+	version string
+	stamp   fileStamp
+}
+
+func NewWatcher(handle *Handle, options WatcherOptions) *Watcher {
+	watcher := new(Watcher)
+	watcher.handle = handle
+	watcher.options = options
+	if watcher.options.Interval <= 0 {
+		watcher.options.Interval = DEFAULT_POLL_INTERVAL
+	}
+	watcher.stamps = make(map[string]fileStamp)
+	watcher.rejected = make(map[string]fileStamp)
+	return watcher
+}
+
+// Start polls once and then keeps polling in the background until Stop is
+// called. The error of the first poll is returned, but polling continues.
+func (watcher *Watcher) Start() error {
+	watcher.mu.Lock()
+	if watcher.stop != nil {
+		watcher.mu.Unlock()
+		return fmt.Errorf("Watcher is already started.")
+	}
+	watcher.stop = make(chan struct{})
+	watcher.done = make(chan struct{})
+	watcher.mu.Unlock()
+
+	err := watcher.Poll()
+	go watcher.run(watcher.stop, watcher.done)
+	return err
+}
+
+func (watcher *Watcher) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(watcher.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			watcher.Poll()
+		}
+	}
+}
+
+// Stop ends background polling and waits for a poll in progress to finish.
+func (watcher *Watcher) Stop() {
+	watcher.mu.Lock()
+	stop, done := watcher.stop, watcher.done
+	watcher.stop, watcher.done = nil, nil
+	watcher.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Poll scans the directory once and loads the newest stable model file
+// whose version is newer than the active one. When it fails, older files
+// that are still newer than the active model are tried in turn.
+func (watcher *Watcher) Poll() error {
+	watcher.polling.Lock()
+	defer watcher.polling.Unlock()
+
+	files, err := watcher.scan()
+	if err != nil {
+		return err
+	}
+	first := !watcher.polled
+	watcher.polled = true
+	stamps := make(map[string]fileStamp, len(files))
+	for _, file := range files {
+		stamps[file.path] = file.stamp
+	}
+	previousStamps := watcher.stamps
+	watcher.stamps = stamps
+
+	active := watcher.ActiveVersion()
+	var errs []string
+	for _, file := range files {
+		if watcher.handle.Current() != nil && CompareVersions(file.version, active) <= 0 {
+			break
+		}
+		if stamp, ok := previousStamps[file.path]; !first && (!ok || stamp != file.stamp) {
+			continue
+		}
+		if stamp, ok := watcher.rejected[file.path]; ok && stamp == file.stamp {
+			continue
+		}
+		before := watcher.handle.Current()
+		_, err := watcher.handle.LoadFile(file.path, file.version)
+		if err != nil {
+			watcher.rejected[file.path] = file.stamp
+			errs = append(errs, err.Error())
+			continue
+		}
+		watcher.mu.Lock()
+		watcher.previous = before
+		watcher.mu.Unlock()
+		return nil
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("Failed to load models: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// scan returns the model files of the directory, newest version first.
+func (watcher *Watcher) scan() ([]modelFile, error) {
+	infos, err := ioutil.ReadDir(watcher.options.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []modelFile
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, watcher.options.Prefix) || !strings.HasSuffix(name, watcher.options.Suffix) {
+			continue
+		}
+		version := name[len(watcher.options.Prefix):]
+		if len(version) < len(watcher.options.Suffix) {
+			continue
+		}
+		version = version[:len(version)-len(watcher.options.Suffix)]
+		if version == "" {
+			continue
+		}
+		files = append(files, modelFile{
+			path:    filepath.Join(watcher.options.Dir, name),
+			version: version,
+			stamp:   fileStamp{size: info.Size(), modTime: info.ModTime()},
+		})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return CompareVersions(files[i].version, files[j].version) > 0
+	})
+	return files, nil
+}
+
+// ActiveVersion returns the version of the model in the handle, or "" when
+// none is loaded.
+func (watcher *Watcher) ActiveVersion() string {
+	model := watcher.handle.Current()
+	if model == nil {
+		return ""
+	}
+	return model.Version
+}
+
+// Previous returns the model that the watcher last replaced, if any.
+func (watcher *Watcher) Previous() *Model {
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+	return watcher.previous
+}
+
+// Rollback reinstalls the previous model. The file of the model being
+// rolled back is not loaded again until it changes.
+func (watcher *Watcher) Rollback() error {
+	watcher.polling.Lock()
+	defer watcher.polling.Unlock()
+	watcher.mu.Lock()
+	previous := watcher.previous
+	watcher.previous = nil
+	watcher.mu.Unlock()
+	if previous == nil {
+		return fmt.Errorf("No previous model to roll back to.")
+	}
+	current := watcher.handle.Current()
+	if current != nil {
+		if stamp, ok := watcher.stamps[current.Source]; ok {
+			watcher.rejected[current.Source] = stamp
+		}
+	}
+	watcher.handle.Swap(previous)
+	return nil
+}
+
+// CompareVersions orders versions by their dot, dash or underscore
+// separated parts. Parts made of digits compare as numbers and sort before
+// other parts, which compare as strings; a version that is a prefix of
+// another is older. It returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	partsA, partsB := versionParts(a), versionParts(b)
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		cmp := comparePart(partsA[i], partsB[i])
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	switch {
+	case len(partsA) < len(partsB):
+		return -1
+	case len(partsA) > len(partsB):
+		return 1
+	}
+	return 0
+}
+
+func versionParts(version string) []string {
+	return strings.FieldsFunc(version, func(r rune) bool {
+		return r == '.' || r == '-' || r == '_'
+	})
+}
+
+func comparePart(a, b string) int {
+	numA, errA := strconv.ParseUint(a, 10, 64)
+	numB, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if numA != numB {
+			if numA < numB {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
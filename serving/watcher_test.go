@@ -0,0 +1,71 @@
+package serving
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func copyModel(t *testing.T, dir, name string) {
+	t.Helper()
+	buf, err := os.ReadFile("../testdata/logistic.bin")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, name), buf, 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// withTimeout fails the test if f does not return, e.g. on a deadlock.
+func withTimeout(t *testing.T, name string, f func() error) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- f() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("%s did not return", name)
+	}
+}
+
+func TestWatcherOnSwapCallsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	copyModel(t, dir, "m-1.bin")
+
+	var watcher *Watcher
+	var swaps []string
+	handle := NewHandle(HandleOptions{
+		OnSwap: func(current, previous *Model) {
+			watcher.Previous()
+			swaps = append(swaps, current.Version)
+		},
+	})
+	watcher = NewWatcher(handle, WatcherOptions{Dir: dir, Prefix: "m-", Suffix: ".bin"})
+
+	withTimeout(t, "Poll", watcher.Poll)
+	copyModel(t, dir, "m-2.bin")
+	// A new file is loaded once it is unchanged between two polls.
+	withTimeout(t, "Poll", watcher.Poll)
+	withTimeout(t, "Poll", watcher.Poll)
+	if watcher.ActiveVersion() != "2" || watcher.Previous() == nil {
+		t.Fatalf("active version %q, want 2 with a previous model", watcher.ActiveVersion())
+	}
+
+	withTimeout(t, "Rollback", watcher.Rollback)
+	if watcher.ActiveVersion() != "1" || watcher.Previous() != nil {
+		t.Fatalf("after rollback: active version %q, want 1 and no previous model", watcher.ActiveVersion())
+	}
+	// The rolled back file is not loaded again.
+	withTimeout(t, "Poll", watcher.Poll)
+	if watcher.ActiveVersion() != "1" {
+		t.Errorf("rolled back version was loaded again")
+	}
+	if len(swaps) != 3 || swaps[0] != "1" || swaps[1] != "2" || swaps[2] != "1" {
+		t.Errorf("swaps %v, want [1 2 1]", swaps)
+	}
+}
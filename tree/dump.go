@@ -0,0 +1,174 @@
+package tree
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"xgboost4go-predictor/util"
+)
+
+// Dump formats, as accepted by XGBoost's dump_model, plus Graphviz.
+const (
+	DUMP_FORMAT_TEXT = "text"
+	DUMP_FORMAT_JSON = "json"
+	DUMP_FORMAT_DOT  = "dot"
+)
+
+// Dump returns the tree in the given format. Features are named from fmap
+// when it is not nil and "f<index>" otherwise. with_stats adds the gain and
+// cover of every node.
+func (rt *RegTree) Dump(fmap *util.FeatureMap, with_stats bool, format string) (string, error) {
+	switch format {
+	case DUMP_FORMAT_TEXT:
+		return rt.DumpText(fmap, with_stats), nil
+	case DUMP_FORMAT_JSON:
+		return rt.DumpJSON(fmap, with_stats), nil
+	case DUMP_FORMAT_DOT:
+		return rt.DumpDot(fmap, with_stats), nil
+	}
+	return "", fmt.Errorf("Unknown dump format: %s", format)
+}
+
+// DumpText returns the tree like XGBoost's text dump, one node per line,
+// indented by depth:
+//
+//	0:[f2<0.5] yes=1,no=2,missing=1,gain=12.5,cover=100
+//		1:leaf=0.25,cover=60
+func (rt *RegTree) DumpText(fmap *util.FeatureMap, with_stats bool) string {
+	var builder strings.Builder
+	rt.Walk(func(nid, depth int) {
+		n := rt.nodes[nid]
+		builder.WriteString(strings.Repeat("\t", depth))
+		if n._isLeaf {
+			fmt.Fprintf(&builder, "%d:leaf=%s", nid, formatFloat(n.leaf_value))
+			if with_stats {
+				fmt.Fprintf(&builder, ",cover=%s", formatFloat(rt.stats[nid].Sum_hess))
+			}
+		} else {
+			if featureType(fmap, n._splitIndex) == util.FEATURE_TYPE_INDICATOR {
+				// An indicator is true when present, so "yes" is the branch
+				// that is not taken for missing values.
+				yes := n.cleft_
+				if n._defaultNext == n.cleft_ {
+					yes = n.cright_
+				}
+				fmt.Fprintf(&builder, "%d:[%s] yes=%d,no=%d", nid, splitText(fmap, n), yes, n._defaultNext)
+			} else {
+				fmt.Fprintf(&builder, "%d:[%s] yes=%d,no=%d,missing=%d", nid, splitText(fmap, n), n.cleft_, n.cright_, n._defaultNext)
+			}
+			if with_stats {
+				fmt.Fprintf(&builder, ",gain=%s,cover=%s", formatFloat(rt.stats[nid].Loss_chg), formatFloat(rt.stats[nid].Sum_hess))
+			}
+		}
+		builder.WriteByte('\n')
+	})
+	return builder.String()
+}
+
+// DumpJSON returns the tree like XGBoost's JSON dump, as nested objects with
+// nodeid, depth, split, split_condition, yes, no, missing and children, or
+// nodeid and leaf for leaves.
+func (rt *RegTree) DumpJSON(fmap *util.FeatureMap, with_stats bool) string {
+	var builder strings.Builder
+	var dump func(nid, depth int)
+	dump = func(nid, depth int) {
+		n := rt.nodes[nid]
+		indent := strings.Repeat("  ", depth)
+		if n._isLeaf {
+			fmt.Fprintf(&builder, "%s{ \"nodeid\": %d, \"leaf\": %s", indent, nid, jsonFloat(n.leaf_value))
+			if with_stats {
+				fmt.Fprintf(&builder, ", \"cover\": %s", jsonFloat(rt.stats[nid].Sum_hess))
+			}
+			builder.WriteString(" }")
+			return
+		}
+		fmt.Fprintf(&builder, "%s{ \"nodeid\": %d, \"depth\": %d, \"split\": %s, \"split_condition\": %s, \"yes\": %d, \"no\": %d, \"missing\": %d",
+			indent, nid, depth, strconv.Quote(featureName(fmap, n._splitIndex)), jsonFloat(n.split_cond), n.cleft_, n.cright_, n._defaultNext)
+		if with_stats {
+			fmt.Fprintf(&builder, ", \"gain\": %s, \"cover\": %s", jsonFloat(rt.stats[nid].Loss_chg), jsonFloat(rt.stats[nid].Sum_hess))
+		}
+		builder.WriteString(", \"children\": [\n")
+		dump(n.cleft_, depth+1)
+		builder.WriteString(",\n")
+		dump(n.cright_, depth+1)
+		fmt.Fprintf(&builder, "\n%s]}", indent)
+	}
+	dump(0, 0)
+	builder.WriteByte('\n')
+	return builder.String()
+}
+
+// DumpDot returns the tree as a Graphviz graph in the style of XGBoost's
+// to_graphviz: the "yes" edge is blue, the "no" edge red, and the default
+// direction is marked "missing".
+func (rt *RegTree) DumpDot(fmap *util.FeatureMap, with_stats bool) string {
+	var builder strings.Builder
+	builder.WriteString("digraph {\n\tgraph [rankdir=TB]\n")
+	rt.Walk(func(nid, depth int) {
+		n := rt.nodes[nid]
+		if n._isLeaf {
+			label := "leaf=" + formatFloat(n.leaf_value)
+			if with_stats {
+				label += "\\ncover=" + formatFloat(rt.stats[nid].Sum_hess)
+			}
+			fmt.Fprintf(&builder, "\t%d [label=%s shape=box]\n", nid, strconv.Quote(label))
+			return
+		}
+		label := splitText(fmap, n)
+		if with_stats {
+			label += "\\ngain=" + formatFloat(rt.stats[nid].Loss_chg) + "\\ncover=" + formatFloat(rt.stats[nid].Sum_hess)
+		}
+		fmt.Fprintf(&builder, "\t%d [label=%s]\n", nid, strconv.Quote(label))
+		yes, no := "yes", "no"
+		if n._defaultNext == n.cleft_ {
+			yes += ", missing"
+		} else {
+			no += ", missing"
+		}
+		fmt.Fprintf(&builder, "\t%d -> %d [label=%s color=\"#0000FF\"]\n", nid, n.cleft_, strconv.Quote(yes))
+		fmt.Fprintf(&builder, "\t%d -> %d [label=%s color=\"#FF0000\"]\n", nid, n.cright_, strconv.Quote(no))
+	})
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+func featureName(fmap *util.FeatureMap, fid int) string {
+	if fmap != nil && fid < fmap.NumFeature() {
+		return fmap.Name(fid)
+	}
+	return "f" + strconv.Itoa(fid)
+}
+
+func featureType(fmap *util.FeatureMap, fid int) string {
+	if fmap != nil && fid < fmap.NumFeature() {
+		return fmap.Type(fid)
+	}
+	return util.FEATURE_TYPE_QUANTITATIVE
+}
+
+// splitText formats a split condition by feature type as XGBoost does:
+// indicators by name only, integers against the rounded-up threshold.
+func splitText(fmap *util.FeatureMap, n *Node) string {
+	name := featureName(fmap, n._splitIndex)
+	switch featureType(fmap, n._splitIndex) {
+	case util.FEATURE_TYPE_INDICATOR:
+		return name
+	case util.FEATURE_TYPE_INTEGER:
+		return name + "<" + strconv.FormatFloat(math.Ceil(float64(n.split_cond)), 'f', -1, 64)
+	}
+	return name + "<" + formatFloat(n.split_cond)
+}
+
+func formatFloat(value float32) string {
+	return strconv.FormatFloat(float64(value), 'g', -1, 32)
+}
+
+// jsonFloat writes NaN and infinities, which JSON lacks, as null.
+func jsonFloat(value float32) string {
+	if value != value || math.IsInf(float64(value), 0) {
+		return "null"
+	}
+	return formatFloat(value)
+}
@@ -0,0 +1,262 @@
+package tree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// FlatForest is a compiled form of a sequence of trees which keeps every
+// node field in its own contiguous array. Node nid of tree tid is stored at
+// offsets[tid]+nid, so the original node ids are kept and traversal touches
+// only the fields it needs.
+type FlatForest struct {
+	offsets     []int32
+	splitIndex  []int32
+	value       []float32
+	left        []int32
+	right       []int32
+	defaultNext []int32
+	numFeature  int
+}
+
+const FLAT_LEAF = int32(-1)
+
+func NewFlatForest(trees []*RegTree) *FlatForest {
+	numNodes := 0
+	for _, rt := range trees {
+		numNodes += len(rt.nodes)
+	}
+
+	forest := new(FlatForest)
+	forest.offsets = make([]int32, len(trees)+1)
+	forest.splitIndex = make([]int32, numNodes)
+	forest.value = make([]float32, numNodes)
+	forest.left = make([]int32, numNodes)
+	forest.right = make([]int32, numNodes)
+	forest.defaultNext = make([]int32, numNodes)
+
+	pos := int32(0)
+	for tid, rt := range trees {
+		forest.offsets[tid] = pos
+		for _, n := range rt.nodes {
+			if n._isLeaf {
+				forest.splitIndex[pos] = FLAT_LEAF
+				forest.value[pos] = n.leaf_value
+				forest.left[pos] = FLAT_LEAF
+				forest.right[pos] = FLAT_LEAF
+				forest.defaultNext[pos] = FLAT_LEAF
+			} else {
+				offset := forest.offsets[tid]
+				forest.splitIndex[pos] = int32(n._splitIndex)
+				forest.value[pos] = n.split_cond
+				forest.left[pos] = offset + int32(n.cleft_)
+				forest.right[pos] = offset + int32(n.cright_)
+				forest.defaultNext[pos] = offset + int32(n._defaultNext)
+				if n._splitIndex >= forest.numFeature {
+					forest.numFeature = n._splitIndex + 1
+				}
+			}
+			pos++
+		}
+	}
+	forest.offsets[len(trees)] = pos
+	return forest
+}
+
+func (forest *FlatForest) NumTrees() int {
+	return len(forest.offsets) - 1
+}
+
+func (forest *FlatForest) NumNodes() int {
+	return len(forest.splitIndex)
+}
+
+// NumFeature returns one more than the largest split index, the length of a
+// dense feature vector that covers every split.
+func (forest *FlatForest) NumFeature() int {
+	return forest.numFeature
+}
+
+// SizeBytes returns the memory held by the forest arrays.
+func (forest *FlatForest) SizeBytes() int {
+	return FlatForestSize(forest.NumTrees(), forest.NumNodes())
+}
+
+func (forest *FlatForest) leafFromArray(nid int32, values []float32, missing float32) int32 {
+	for {
+		fid := forest.splitIndex[nid]
+		if fid == FLAT_LEAF {
+			return nid
+		}
+		if int(fid) >= len(values) {
+			nid = forest.defaultNext[nid]
+			continue
+		}
+		value := values[fid]
+		if value != value || value == missing {
+			nid = forest.defaultNext[nid]
+		} else if value < forest.value[nid] {
+			nid = forest.left[nid]
+		} else {
+			nid = forest.right[nid]
+		}
+	}
+}
+
+func (forest *FlatForest) leafFromMap(nid int32, values map[int]float32) int32 {
+	for {
+		fid := forest.splitIndex[nid]
+		if fid == FLAT_LEAF {
+			return nid
+		}
+		value, ok := values[int(fid)]
+		if !ok || value != value {
+			nid = forest.defaultNext[nid]
+		} else if value < forest.value[nid] {
+			nid = forest.left[nid]
+		} else {
+			nid = forest.right[nid]
+		}
+	}
+}
+
+func (forest *FlatForest) GetLeafByArray(tid int, values []float32, missing float32) float32 {
+	return forest.value[forest.leafFromArray(forest.offsets[tid], values, missing)]
+}
+
+func (forest *FlatForest) GetLeafByMap(tid int, values map[int]float32, root_id int) float32 {
+	return forest.value[forest.leafFromMap(forest.offsets[tid]+int32(root_id), values)]
+}
+
+func (forest *FlatForest) GetLeafIndexByArray(tid int, values []float32, missing float32, root_id int) int {
+	offset := forest.offsets[tid]
+	return int(forest.leafFromArray(offset+int32(root_id), values, missing) - offset)
+}
+
+func (forest *FlatForest) GetLeafIndexByMap(tid int, values map[int]float32, root_id int) int {
+	offset := forest.offsets[tid]
+	return int(forest.leafFromMap(offset+int32(root_id), values) - offset)
+}
+
+// FlatForestSize returns the number of bytes WriteTo produces for a forest
+// of numTrees trees and numNodes nodes.
+func FlatForestSize(numTrees, numNodes int) int {
+	return 4 * (numTrees + 1 + 5*numNodes)
+}
+
+// WriteTo writes the forest arrays in little-endian order: offsets, split
+// indices, values, left, right and default children.
+func (forest *FlatForest) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var word [4]byte
+	put := func(v uint32) {
+		binary.LittleEndian.PutUint32(word[:], v)
+		bw.Write(word[:])
+	}
+	for _, array := range [][]int32{forest.offsets, forest.splitIndex} {
+		for _, v := range array {
+			put(uint32(v))
+		}
+	}
+	for _, v := range forest.value {
+		put(math.Float32bits(v))
+	}
+	for _, array := range [][]int32{forest.left, forest.right, forest.defaultNext} {
+		for _, v := range array {
+			put(uint32(v))
+		}
+	}
+	err := bw.Flush()
+	if err != nil {
+		return 0, err
+	}
+	return int64(FlatForestSize(forest.NumTrees(), forest.NumNodes())), nil
+}
+
+// NewFlatForestFromBytes reads a forest written by WriteTo. On little-endian
+// hosts the arrays alias buf instead of being copied, so buf must stay valid
+// and unmodified while the forest is in use; buf must be 4-byte aligned.
+func NewFlatForestFromBytes(buf []byte, numTrees, numNodes int) (*FlatForest, error) {
+	if numTrees < 0 || numNodes < 0 || len(buf) != FlatForestSize(numTrees, numNodes) {
+		return nil, fmt.Errorf("Invalid flat forest size: %d bytes for %d trees and %d nodes", len(buf), numTrees, numNodes)
+	}
+	forest := new(FlatForest)
+	pos := 0
+	next := func(n int) []int32 {
+		array := bytesAsInt32s(buf[pos : pos+4*n])
+		pos += 4 * n
+		return array
+	}
+	forest.offsets = next(numTrees + 1)
+	forest.splitIndex = next(numNodes)
+	forest.value = int32sAsFloat32s(next(numNodes))
+	forest.left = next(numNodes)
+	forest.right = next(numNodes)
+	forest.defaultNext = next(numNodes)
+
+	err := forest.checkBounds()
+	if err != nil {
+		return nil, err
+	}
+	return forest, nil
+}
+
+// checkBounds verifies that tree offsets are ordered, that every child
+// stays within its own tree, that default children are the left or right
+// child, and that no node is reached twice from the root, so that traversals
+// end at a leaf.
+func (forest *FlatForest) checkBounds() error {
+	numNodes := int32(len(forest.splitIndex))
+	if forest.offsets[0] != 0 || forest.offsets[len(forest.offsets)-1] != numNodes {
+		return fmt.Errorf("Invalid flat forest offsets: [%d, %d] for %d nodes", forest.offsets[0], forest.offsets[len(forest.offsets)-1], numNodes)
+	}
+	for tid := 0; tid < forest.NumTrees(); tid++ {
+		if forest.offsets[tid] >= forest.offsets[tid+1] {
+			return fmt.Errorf("Tree %d has no nodes.", tid)
+		}
+	}
+	for tid := 0; tid < forest.NumTrees(); tid++ {
+		begin, end := forest.offsets[tid], forest.offsets[tid+1]
+		for nid := begin; nid < end; nid++ {
+			if forest.splitIndex[nid] == FLAT_LEAF {
+				continue
+			}
+			if forest.splitIndex[nid] < 0 {
+				return fmt.Errorf("Tree %d node %d has invalid split index %d", tid, nid-begin, forest.splitIndex[nid])
+			}
+			for _, child := range []int32{forest.left[nid], forest.right[nid], forest.defaultNext[nid]} {
+				if child < begin || child >= end {
+					return fmt.Errorf("Tree %d node %d has child %d out of range", tid, nid-begin, child-begin)
+				}
+			}
+			if forest.defaultNext[nid] != forest.left[nid] && forest.defaultNext[nid] != forest.right[nid] {
+				return fmt.Errorf("Tree %d node %d has default child %d, which is neither of its children", tid, nid-begin, forest.defaultNext[nid]-begin)
+			}
+			if int(forest.splitIndex[nid]) >= forest.numFeature {
+				forest.numFeature = int(forest.splitIndex[nid]) + 1
+			}
+		}
+	}
+
+	visited := make([]bool, numNodes)
+	var stack []int32
+	for tid := 0; tid < forest.NumTrees(); tid++ {
+		begin := forest.offsets[tid]
+		stack = append(stack[:0], begin)
+		for len(stack) > 0 {
+			nid := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if visited[nid] {
+				return fmt.Errorf("Tree %d node %d is reached twice, the tree has a cycle", tid, nid-begin)
+			}
+			visited[nid] = true
+			if forest.splitIndex[nid] != FLAT_LEAF {
+				stack = append(stack, forest.right[nid], forest.left[nid])
+			}
+		}
+	}
+	return nil
+}
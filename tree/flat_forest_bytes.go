@@ -0,0 +1,41 @@
+package tree
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+)
+
+var littleEndianHost = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+func bytesAsInt32s(buf []byte) []int32 {
+	n := len(buf) / 4
+	if n == 0 {
+		return []int32{}
+	}
+	if littleEndianHost && uintptr(unsafe.Pointer(&buf[0]))%4 == 0 {
+		return unsafe.Slice((*int32)(unsafe.Pointer(&buf[0])), n)
+	}
+	array := make([]int32, n)
+	for i := range array {
+		array[i] = int32(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return array
+}
+
+func int32sAsFloat32s(array []int32) []float32 {
+	if len(array) == 0 {
+		return []float32{}
+	}
+	if littleEndianHost {
+		return unsafe.Slice((*float32)(unsafe.Pointer(&array[0])), len(array))
+	}
+	values := make([]float32, len(array))
+	for i, v := range array {
+		values[i] = math.Float32frombits(uint32(v))
+	}
+	return values
+}
@@ -0,0 +1,115 @@
+package tree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"xgboost4go-predictor/internal/testmodel"
+	"xgboost4go-predictor/util"
+)
+
+// loadTestTrees reads random trees written by testmodel.Trees.
+func loadTestTrees(t *testing.T, seed int64, num_trees, num_feature, depth int) []*RegTree {
+	t.Helper()
+	return loadTrees(t, testmodel.Trees(seed, num_trees, num_feature, depth), num_trees)
+}
+
+func loadTrees(t *testing.T, buf []byte, num_trees int) []*RegTree {
+	t.Helper()
+	reader := util.NewModelReaderByReader(*bufio.NewReader(bytes.NewReader(buf)))
+	trees := make([]*RegTree, num_trees)
+	for tid := range trees {
+		trees[tid] = new(RegTree)
+		err := trees[tid].LoadModel(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return trees
+}
+
+// testRows returns random rows, rows of special values and a row shorter
+// than num_feature.
+func testRows(seed int64, num_rows, num_feature int) [][]float32 {
+	r := rand.New(rand.NewSource(seed))
+	rows := make([][]float32, num_rows)
+	for i := range rows {
+		rows[i] = testmodel.Row(r, num_feature)
+	}
+	special := []float32{float32(math.Inf(1)), float32(math.Inf(-1)), float32(math.Copysign(0, -1)), 0, float32(math.NaN())}
+	for _, value := range special {
+		row := make([]float32, num_feature)
+		for fid := range row {
+			row[fid] = value
+		}
+		rows = append(rows, row)
+	}
+	return append(rows, rows[0][:num_feature/2])
+}
+
+// mapRow drops the missing values of row.
+func mapRow(row []float32, missing float32) map[int]float32 {
+	values := make(map[int]float32)
+	for fid, value := range row {
+		if value == value && value != missing {
+			values[fid] = value
+		}
+	}
+	return values
+}
+
+// flatForestBytes encodes a one-tree forest as written by WriteTo.
+func flatForestBytes(splitIndex []int32, value []float32, left, right, defaultNext []int32) []byte {
+	var buf bytes.Buffer
+	words := append([]int32{0, int32(len(splitIndex))}, splitIndex...)
+	for _, v := range value {
+		words = append(words, int32(math.Float32bits(v)))
+	}
+	words = append(words, left...)
+	words = append(words, right...)
+	words = append(words, defaultNext...)
+	binary.Write(&buf, binary.LittleEndian, words)
+	return buf.Bytes()
+}
+
+func TestFlatForestFromBytes(t *testing.T) {
+	leaf := FLAT_LEAF
+	cases := []struct {
+		name        string
+		left        []int32
+		right       []int32
+		defaultNext []int32
+		err         string
+	}{
+		{"valid", []int32{1, leaf, leaf}, []int32{2, leaf, leaf}, []int32{2, leaf, leaf}, ""},
+		{"cycle", []int32{0, leaf, leaf}, []int32{2, leaf, leaf}, []int32{2, leaf, leaf}, "cycle"},
+		{"shared child", []int32{1, leaf, leaf}, []int32{1, leaf, leaf}, []int32{1, leaf, leaf}, "reached twice"},
+		{"default child", []int32{1, leaf, leaf}, []int32{2, leaf, leaf}, []int32{0, leaf, leaf}, "default child"},
+		{"out of range", []int32{1, leaf, leaf}, []int32{3, leaf, leaf}, []int32{1, leaf, leaf}, "out of range"},
+	}
+	for _, c := range cases {
+		buf := flatForestBytes([]int32{0, leaf, leaf}, []float32{0.5, -1, 1}, c.left, c.right, c.defaultNext)
+		forest, err := NewFlatForestFromBytes(buf, 1, 3)
+		if c.err == "" {
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			nan := float32(math.NaN())
+			if got := forest.GetLeafByArray(0, []float32{0.25}, nan); got != -1 {
+				t.Errorf("%s: left leaf = %v, want -1", c.name, got)
+			}
+			if got := forest.GetLeafByArray(0, []float32{nan}, nan); got != 1 {
+				t.Errorf("%s: default leaf = %v, want 1", c.name, got)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: got error %v, want %q", c.name, err, c.err)
+		}
+	}
+}
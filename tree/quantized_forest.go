@@ -0,0 +1,241 @@
+package tree
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"unsafe"
+)
+
+// QuantizedForest is a compact form of a forest in which split thresholds are
+// replaced by bin ids. The distinct thresholds of each feature are sorted,
+// and an input value is binned once per row as the number of thresholds that
+// are <= value. For the k-th threshold t, value < t holds exactly when
+// bin(value) <= k, so comparing bins gives the same branch as comparing
+// float32 values. Nodes take 12 bytes; the children of a split are stored
+// next to each other, and a leaf keeps its value in place of the child.
+type QuantizedForest struct {
+	roots      []uint32
+	nodes      []quantNode
+	cuts       [][]float32
+	numFeature int
+
+	pool sync.Pool
+}
+
+type quantNode struct {
+	feature uint32
+	bin     uint16
+	flags   uint16
+	// child is the position of the left child, or the bits of the leaf value.
+	child uint32
+}
+
+const (
+	QUANT_MISSING      = uint16(0xffff)
+	QUANT_MAX_CUTS     = int(QUANT_MISSING) - 1
+	QUANT_MAX_FEATURES = 1 << 20
+
+	quantLeaf        = uint16(1)
+	quantDefaultLeft = uint16(2)
+)
+
+// NewQuantizedForest compiles forest, starting every tree at its first root.
+// It returns false when a split index is not below QUANT_MAX_FEATURES, a
+// feature has more than QUANT_MAX_CUTS distinct thresholds or a tree is not
+// a binary tree with a default child on either side, in which case the flat
+// forest must be used.
+func NewQuantizedForest(forest *FlatForest) (*QuantizedForest, bool) {
+	if forest.NumFeature() > QUANT_MAX_FEATURES {
+		return nil, false
+	}
+	qf := new(QuantizedForest)
+	for nid, fid := range forest.splitIndex {
+		if fid == FLAT_LEAF {
+			continue
+		}
+		for int(fid) >= len(qf.cuts) {
+			qf.cuts = append(qf.cuts, nil)
+		}
+		// A NaN threshold is never greater than a value, so it is left out
+		// and its nodes always go right.
+		if threshold := forest.value[nid]; threshold == threshold {
+			qf.cuts[fid] = append(qf.cuts[fid], threshold)
+		}
+	}
+	qf.numFeature = len(qf.cuts)
+	for fid, cuts := range qf.cuts {
+		sort.Slice(cuts, func(i, j int) bool { return cuts[i] < cuts[j] })
+		unique := cuts[:0]
+		for i, threshold := range cuts {
+			if i == 0 || threshold != unique[len(unique)-1] {
+				unique = append(unique, threshold)
+			}
+		}
+		if len(unique) > QUANT_MAX_CUTS {
+			return nil, false
+		}
+		qf.cuts[fid] = unique[:len(unique):len(unique)]
+	}
+
+	qf.roots = make([]uint32, forest.NumTrees())
+	qf.nodes = make([]quantNode, 0, forest.NumNodes())
+	for tid := range qf.roots {
+		if !qf.addTree(forest, tid) {
+			return nil, false
+		}
+	}
+
+	numFeature := qf.numFeature
+	qf.pool.New = func() interface{} {
+		bins := make([]uint16, numFeature)
+		return &bins
+	}
+	return qf, true
+}
+
+// addTree appends the nodes of tree tid that are reachable from its root in
+// breadth-first order, so that both children of a split are allocated
+// together.
+func (qf *QuantizedForest) addTree(forest *FlatForest, tid int) bool {
+	begin, end := forest.offsets[tid], forest.offsets[tid+1]
+	qf.roots[tid] = uint32(len(qf.nodes))
+	qf.nodes = append(qf.nodes, quantNode{})
+	queue := []int32{begin}
+	for len(queue) > 0 {
+		nid := queue[0]
+		queue = queue[1:]
+		pos := len(qf.nodes) - len(queue) - 1
+		// Each node of a tree is reached at most once, so more nodes than the
+		// tree holds means that its children form a cycle.
+		if len(qf.nodes)-int(qf.roots[tid]) > int(end-begin) {
+			return false
+		}
+		qf.nodes[pos] = qf.compileNode(forest, nid)
+		if qf.nodes[pos].flags&quantLeaf != 0 {
+			continue
+		}
+		left, right, defaultNext := forest.left[nid], forest.right[nid], forest.defaultNext[nid]
+		if defaultNext != left && defaultNext != right {
+			return false
+		}
+		qf.nodes[pos].child = uint32(len(qf.nodes))
+		qf.nodes = append(qf.nodes, quantNode{}, quantNode{})
+		queue = append(queue, left, right)
+	}
+	return true
+}
+
+func (qf *QuantizedForest) compileNode(forest *FlatForest, nid int32) quantNode {
+	fid := forest.splitIndex[nid]
+	if fid == FLAT_LEAF {
+		return quantNode{flags: quantLeaf, child: math.Float32bits(forest.value[nid])}
+	}
+	n := quantNode{feature: uint32(fid)}
+	if threshold := forest.value[nid]; threshold == threshold {
+		n.bin = uint16(searchCut(qf.cuts[fid], threshold) + 1)
+	}
+	if forest.defaultNext[nid] == forest.left[nid] {
+		n.flags = quantDefaultLeft
+	}
+	return n
+}
+
+// searchCut returns the index of threshold in the sorted cuts.
+func searchCut(cuts []float32, threshold float32) int {
+	return sort.Search(len(cuts), func(i int) bool { return cuts[i] >= threshold })
+}
+
+// NumBins returns the number of bins a row is binned into.
+func (qf *QuantizedForest) NumBins() int {
+	return qf.numFeature
+}
+
+func (qf *QuantizedForest) NumTrees() int {
+	return len(qf.roots)
+}
+
+func (qf *QuantizedForest) NumNodes() int {
+	return len(qf.nodes)
+}
+
+// SizeBytes returns the memory held by the nodes and the thresholds.
+func (qf *QuantizedForest) SizeBytes() int {
+	size := len(qf.nodes)*int(unsafe.Sizeof(quantNode{})) + 4*len(qf.roots)
+	for _, cuts := range qf.cuts {
+		size += 4 * len(cuts)
+	}
+	return size
+}
+
+// AcquireBins returns a scratch buffer for the Bin methods; it should be
+// handed back with ReleaseBins.
+func (qf *QuantizedForest) AcquireBins() *[]uint16 {
+	return qf.pool.Get().(*[]uint16)
+}
+
+func (qf *QuantizedForest) ReleaseBins(bins *[]uint16) {
+	qf.pool.Put(bins)
+}
+
+func (qf *QuantizedForest) BinArray(values []float32, missing float32, bins []uint16) {
+	for fid, cuts := range qf.cuts {
+		if fid >= len(values) {
+			bins[fid] = QUANT_MISSING
+			continue
+		}
+		value := values[fid]
+		if value != value || value == missing {
+			bins[fid] = QUANT_MISSING
+		} else {
+			bins[fid] = upperBound(cuts, value)
+		}
+	}
+}
+
+func (qf *QuantizedForest) BinMap(values map[int]float32, bins []uint16) {
+	for i := range bins {
+		bins[i] = QUANT_MISSING
+	}
+	for fid, value := range values {
+		if fid < 0 || fid >= qf.numFeature || value != value {
+			continue
+		}
+		bins[fid] = upperBound(qf.cuts[fid], value)
+	}
+}
+
+// upperBound returns the number of cuts that are <= value.
+func upperBound(cuts []float32, value float32) uint16 {
+	lo, hi := 0, len(cuts)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if cuts[mid] <= value {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return uint16(lo)
+}
+
+// GetLeafByBins returns the leaf value of tree tid for a row binned by
+// BinArray or BinMap.
+func (qf *QuantizedForest) GetLeafByBins(tid int, bins []uint16) float32 {
+	pos := qf.roots[tid]
+	for {
+		n := &qf.nodes[pos]
+		if n.flags&quantLeaf != 0 {
+			return math.Float32frombits(n.child)
+		}
+		bin := bins[n.feature]
+		right := bin >= n.bin
+		if bin == QUANT_MISSING {
+			right = n.flags&quantDefaultLeft == 0
+		}
+		pos = n.child
+		if right {
+			pos++
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package tree
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuantizedMatchesTraversal(t *testing.T) {
+	const num_feature = 10
+	trees := loadTestTrees(t, 1, 200, num_feature, 6)
+	forest := NewFlatForest(trees)
+	qf, ok := NewQuantizedForest(forest)
+	if !ok {
+		t.Fatal("forest is not eligible for quantization")
+	}
+	bins := make([]uint16, qf.NumBins())
+	for _, missing := range []float32{float32(math.NaN()), 0} {
+		for i, row := range testRows(2, 200, num_feature) {
+			qf.BinArray(row, missing, bins)
+			var want, got float32
+			for tid := 0; tid < forest.NumTrees(); tid++ {
+				want += forest.GetLeafByArray(tid, row, missing)
+				got += qf.GetLeafByBins(tid, bins)
+			}
+			if math.Float32bits(got) != math.Float32bits(want) {
+				t.Fatalf("missing %v row %d: array margin %v, traversal %v", missing, i, got, want)
+			}
+
+			values := mapRow(row, missing)
+			qf.BinMap(values, bins)
+			got, want = 0, 0
+			for tid := 0; tid < forest.NumTrees(); tid++ {
+				want += forest.GetLeafByMap(tid, values, 0)
+				got += qf.GetLeafByBins(tid, bins)
+			}
+			if math.Float32bits(got) != math.Float32bits(want) {
+				t.Fatalf("missing %v row %d: map margin %v, traversal %v", missing, i, got, want)
+			}
+		}
+	}
+}
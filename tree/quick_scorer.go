@@ -0,0 +1,195 @@
+package tree
+
+import (
+	"math/bits"
+	"sort"
+	"sync"
+)
+
+const QUICK_SCORER_MAX_LEAVES = 64
+
+// QuickScorer evaluates an ensemble with the QuickScorer algorithm
+// (Lucchese et al., SIGIR 2015). The leaves of each tree are numbered from
+// left to right and every tree keeps a 64-bit vector of leaves that can
+// still be reached. For each feature, the split nodes are sorted by
+// threshold; a node whose test is false (value >= split_cond, or missing
+// with a right default) clears the leaves of its left subtree. The exit
+// leaf of a tree is then the lowest bit left set.
+type QuickScorer struct {
+	numTrees   int
+	leafValues []float32
+
+	features    []int
+	offsets     []int
+	thresholds  []float32
+	treeIds     []int32
+	masks       []uint64
+	missOffsets []int
+	missTreeIds []int32
+	missMasks   []uint64
+
+	pool sync.Pool
+}
+
+type qsCondition struct {
+	feature   int
+	threshold float32
+	treeId    int32
+	mask      uint64
+	missRight bool
+}
+
+// NewQuickScorer compiles trees for QuickScorer evaluation. It returns false
+// when a tree has more than 64 leaves or a split without a comparable
+// threshold, in which case traversal must be used.
+func NewQuickScorer(trees []*RegTree) (*QuickScorer, bool) {
+	qs := new(QuickScorer)
+	qs.numTrees = len(trees)
+	qs.leafValues = make([]float32, len(trees)*QUICK_SCORER_MAX_LEAVES)
+
+	var conds []qsCondition
+	for tid, rt := range trees {
+		if rt.param.num_roots != 1 {
+			return nil, false
+		}
+		numLeaves := 0
+		var visit func(nid int) (uint64, bool)
+		visit = func(nid int) (uint64, bool) {
+			n := rt.nodes[nid]
+			if n._isLeaf {
+				if numLeaves >= QUICK_SCORER_MAX_LEAVES {
+					return 0, false
+				}
+				qs.leafValues[tid*QUICK_SCORER_MAX_LEAVES+numLeaves] = n.leaf_value
+				numLeaves++
+				return uint64(1) << uint(numLeaves-1), true
+			}
+			if n.split_cond != n.split_cond {
+				return 0, false
+			}
+			leftLeaves, ok := visit(n.cleft_)
+			if !ok {
+				return 0, false
+			}
+			rightLeaves, ok := visit(n.cright_)
+			if !ok {
+				return 0, false
+			}
+			conds = append(conds, qsCondition{
+				feature:   n._splitIndex,
+				threshold: n.split_cond,
+				treeId:    int32(tid),
+				mask:      ^leftLeaves,
+				missRight: !n.default_left(),
+			})
+			return leftLeaves | rightLeaves, true
+		}
+		if _, ok := visit(0); !ok {
+			return nil, false
+		}
+	}
+
+	sort.SliceStable(conds, func(i, j int) bool {
+		if conds[i].feature != conds[j].feature {
+			return conds[i].feature < conds[j].feature
+		}
+		return conds[i].threshold < conds[j].threshold
+	})
+	for i, cond := range conds {
+		if i == 0 || cond.feature != conds[i-1].feature {
+			qs.features = append(qs.features, cond.feature)
+			qs.offsets = append(qs.offsets, len(qs.thresholds))
+			qs.missOffsets = append(qs.missOffsets, len(qs.missTreeIds))
+		}
+		qs.thresholds = append(qs.thresholds, cond.threshold)
+		qs.treeIds = append(qs.treeIds, cond.treeId)
+		qs.masks = append(qs.masks, cond.mask)
+		if cond.missRight {
+			qs.missTreeIds = append(qs.missTreeIds, cond.treeId)
+			qs.missMasks = append(qs.missMasks, cond.mask)
+		}
+	}
+	qs.offsets = append(qs.offsets, len(qs.thresholds))
+	qs.missOffsets = append(qs.missOffsets, len(qs.missTreeIds))
+
+	numTrees := len(trees)
+	qs.pool.New = func() interface{} {
+		bitvectors := make([]uint64, numTrees)
+		return &bitvectors
+	}
+	return qs, true
+}
+
+func (qs *QuickScorer) NumTrees() int {
+	return qs.numTrees
+}
+
+// SizeBytes estimates the memory held by the leaf values and conditions.
+func (qs *QuickScorer) SizeBytes() int {
+	return 4*len(qs.leafValues) + 8*(len(qs.features)+len(qs.offsets)+len(qs.missOffsets)) +
+		16*len(qs.thresholds) + 12*len(qs.missTreeIds)
+}
+
+// AcquireBitvectors returns a scratch buffer for the Eval methods; it should
+// be handed back with ReleaseBitvectors. The buffer is passed by pointer so
+// that pooling it does not allocate.
+func (qs *QuickScorer) AcquireBitvectors() *[]uint64 {
+	return qs.pool.Get().(*[]uint64)
+}
+
+func (qs *QuickScorer) ReleaseBitvectors(bitvectors *[]uint64) {
+	qs.pool.Put(bitvectors)
+}
+
+func (qs *QuickScorer) EvalArray(values []float32, missing float32, bitvectors []uint64) {
+	qs.reset(bitvectors)
+	for k, fid := range qs.features {
+		if fid >= len(values) {
+			qs.applyMissing(k, bitvectors)
+			continue
+		}
+		value := values[fid]
+		if value != value || value == missing {
+			qs.applyMissing(k, bitvectors)
+		} else {
+			qs.applyValue(k, value, bitvectors)
+		}
+	}
+}
+
+func (qs *QuickScorer) EvalMap(values map[int]float32, bitvectors []uint64) {
+	qs.reset(bitvectors)
+	for k, fid := range qs.features {
+		value, ok := values[fid]
+		if !ok || value != value {
+			qs.applyMissing(k, bitvectors)
+		} else {
+			qs.applyValue(k, value, bitvectors)
+		}
+	}
+}
+
+// LeafValue returns the exit leaf value of tree tid after an Eval call.
+func (qs *QuickScorer) LeafValue(tid int, bitvectors []uint64) float32 {
+	return qs.leafValues[tid*QUICK_SCORER_MAX_LEAVES+bits.TrailingZeros64(bitvectors[tid])]
+}
+
+func (qs *QuickScorer) reset(bitvectors []uint64) {
+	for i := range bitvectors {
+		bitvectors[i] = ^uint64(0)
+	}
+}
+
+func (qs *QuickScorer) applyValue(k int, value float32, bitvectors []uint64) {
+	end := qs.offsets[k+1]
+	for i := qs.offsets[k]; i < end && qs.thresholds[i] <= value; i++ {
+		bitvectors[qs.treeIds[i]] &= qs.masks[i]
+	}
+}
+
+func (qs *QuickScorer) applyMissing(k int, bitvectors []uint64) {
+	end := qs.missOffsets[k+1]
+	for i := qs.missOffsets[k]; i < end; i++ {
+		bitvectors[qs.missTreeIds[i]] &= qs.missMasks[i]
+	}
+}
@@ -0,0 +1,65 @@
+package tree
+
+import (
+	"math"
+	"testing"
+)
+
+// setDefaults sends every missing value of the trees to one side.
+func setDefaults(trees []*RegTree, left bool) {
+	for _, rt := range trees {
+		for _, n := range rt.nodes {
+			if n._isLeaf {
+				continue
+			}
+			if left {
+				n.sindex_ = n._splitIndex | 1<<31
+				n._defaultNext = n.cleft_
+			} else {
+				n.sindex_ = n._splitIndex
+				n._defaultNext = n.cright_
+			}
+		}
+	}
+}
+
+func TestQuickScorerMatchesTraversal(t *testing.T) {
+	const num_feature = 10
+	for _, defaults := range []string{"random", "left", "right"} {
+		trees := loadTestTrees(t, 1, 200, num_feature, 6)
+		if defaults != "random" {
+			setDefaults(trees, defaults == "left")
+		}
+		qs, ok := NewQuickScorer(trees)
+		if !ok {
+			t.Fatal("trees are not eligible for QuickScorer")
+		}
+		bitvectors := make([]uint64, qs.NumTrees())
+		for _, missing := range []float32{float32(math.NaN()), 0} {
+			for i, row := range testRows(2, 200, num_feature) {
+				qs.EvalArray(row, missing, bitvectors)
+				var want, got float32
+				for tid, rt := range trees {
+					leaf := rt.GetLeafByArray(row, missing)
+					if math.Float32bits(qs.LeafValue(tid, bitvectors)) != math.Float32bits(leaf) {
+						t.Fatalf("%s defaults, missing %v, row %d, tree %d: leaf %v, traversal %v", defaults, missing, i, tid, qs.LeafValue(tid, bitvectors), leaf)
+					}
+					want += leaf
+					got += qs.LeafValue(tid, bitvectors)
+				}
+				if math.Float32bits(got) != math.Float32bits(want) {
+					t.Fatalf("%s defaults, missing %v, row %d: margin %v, traversal %v", defaults, missing, i, got, want)
+				}
+
+				values := mapRow(row, missing)
+				qs.EvalMap(values, bitvectors)
+				for tid, rt := range trees {
+					leaf := rt.GetLeafByMap(values, 0)
+					if math.Float32bits(qs.LeafValue(tid, bitvectors)) != math.Float32bits(leaf) {
+						t.Fatalf("%s defaults, missing %v, map row %d, tree %d: leaf %v, traversal %v", defaults, missing, i, tid, qs.LeafValue(tid, bitvectors), leaf)
+					}
+				}
+			}
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package tree
 
 import (
+	"fmt"
+	"unsafe"
+
 	"xgboost4go-predictor/util"
 	"xgboost4go-predictor/math"
 )
@@ -11,11 +14,11 @@ type RegTree struct {
 	stats []*RTreeNodeStat
 }
 
-func (rt *RegTree) GetLeafIndexByArray(values []float32, treatsZeroAsNA bool, root_id int) int {
+func (rt *RegTree) GetLeafIndexByArray(values []float32, missing float32, root_id int) int {
 	pid := root_id
 	n := rt.nodes[pid]
 	for !n._isLeaf {
-		pid = n.nextFromArray(values, treatsZeroAsNA)
+		pid = n.nextFromArray(values, missing)
 		n = rt.nodes[pid]
 	}
 
@@ -42,15 +45,53 @@ func (rt *RegTree) GetLeafByMap(values map[int]float32, root_id int) float32 {
 	return n.leaf_value
 }
 
-func (rt *RegTree) GetLeafByArray(values []float32, treatsZeroAsNA bool) float32 {
+func (rt *RegTree) GetLeafByArray(values []float32, missing float32) float32 {
 	n := rt.nodes[0]
 	for !n._isLeaf {
-		n = rt.nodes[n.nextFromArray(values, treatsZeroAsNA)]
+		n = rt.nodes[n.nextFromArray(values, missing)]
 	}
 
 	return n.leaf_value
 }
 
+func (rt *RegTree) NumRoots() int {
+	return rt.param.num_roots
+}
+
+func (rt *RegTree) NumNodes() int {
+	return len(rt.nodes)
+}
+
+func (rt *RegTree) Node(nid int) *Node {
+	return rt.nodes[nid]
+}
+
+func (rt *RegTree) Stat(nid int) *RTreeNodeStat {
+	return rt.stats[nid]
+}
+
+// Walk calls visit for every node reachable from the root, parents before
+// children, left before right.
+func (rt *RegTree) Walk(visit func(nid, depth int)) {
+	var walk func(nid, depth int)
+	walk = func(nid, depth int) {
+		visit(nid, depth)
+		n := rt.nodes[nid]
+		if !n._isLeaf {
+			walk(n.cleft_, depth+1)
+			walk(n.cright_, depth+1)
+		}
+	}
+	walk(0, 0)
+}
+
+// SizeBytes estimates the memory held by the nodes and statistics.
+func (rt *RegTree) SizeBytes() int {
+	nodeSize := int(unsafe.Sizeof(Node{})) + int(unsafe.Sizeof(&Node{}))
+	statSize := int(unsafe.Sizeof(RTreeNodeStat{})) + int(unsafe.Sizeof(&RTreeNodeStat{}))
+	return len(rt.nodes)*nodeSize + len(rt.stats)*statSize
+}
+
 type Param struct {
 	num_roots        int
 	num_nodes        int
@@ -86,24 +127,25 @@ func (rt *RegTree) LoadModel(reader *util.ModelReader) error {
 		return err
 	}
 	rt.param = param
-	rt.nodes = make([]*Node, param.num_nodes)
-
+	// Nodes are appended as they are read, so a corrupt num_nodes runs out
+	// of input instead of allocating memory up front.
 	for i := 0; i < param.num_nodes; i++ {
-		rt.nodes[i], err = newNode(reader)
+		node, err := newNode(reader)
 		if err != nil {
-			return err
+			return util.WithinField(fmt.Sprintf("node %d", i), err)
 		}
+		rt.nodes = append(rt.nodes, node)
 	}
 
-	rt.stats = make([]*RTreeNodeStat, param.num_nodes)
-
+	rt.stats = make([]*RTreeNodeStat, 0, len(rt.nodes))
 	for i := 0; i < param.num_nodes; i++ {
-		rt.stats[i], err = newRTreeNodeStat(reader)
+		stat, err := newRTreeNodeStat(reader)
 		if err != nil {
-			return err
+			return util.WithinField(fmt.Sprintf("node %d", i), err)
 		}
+		rt.stats = append(rt.stats, stat)
 	}
-	return err
+	return nil
 }
 
 func newParam(reader *util.ModelReader) (*Param, error) {
@@ -111,30 +153,33 @@ func newParam(reader *util.ModelReader) (*Param, error) {
 	var err error
 	param.num_roots, err = reader.ReadInt()
 	if err != nil {
-		return param, err
+		return param, reader.FieldError("num_roots", err)
 	}
 	param.num_nodes, err = reader.ReadInt()
 	if err != nil {
-		return param, err
+		return param, reader.FieldError("num_nodes", err)
+	}
+	if param.num_nodes < 1 {
+		return param, reader.FieldError("num_nodes", fmt.Errorf("Invalid num_nodes: %d", param.num_nodes))
 	}
 	param.num_deleted, err = reader.ReadInt()
 	if err != nil {
-		return param, err
+		return param, reader.FieldError("num_deleted", err)
 	}
 	param.max_depth, err = reader.ReadInt()
 	if err != nil {
-		return param, err
+		return param, reader.FieldError("max_depth", err)
 	}
 	param.num_feature, err = reader.ReadInt()
 	if err != nil {
-		return param, err
+		return param, reader.FieldError("num_feature", err)
 	}
 	param.size_leaf_vector, err = reader.ReadInt()
 	if err != nil {
-		return param, err
+		return param, reader.FieldError("size_leaf_vector", err)
 	}
 	param.reserved, err = reader.ReadIntArray(31)
-	return param, err
+	return param, reader.FieldError("reserved", err)
 }
 
 func newNode(reader *util.ModelReader) (*Node, error) {
@@ -142,25 +187,31 @@ func newNode(reader *util.ModelReader) (*Node, error) {
 	var err error
 	node.parent_, err = reader.ReadInt()
 	if err != nil {
-		return node, err
+		return node, reader.FieldError("parent", err)
 	}
 	node.cleft_, err = reader.ReadInt()
 	if err != nil {
-		return node, err
+		return node, reader.FieldError("cleft", err)
 	}
 	node.cright_, err = reader.ReadInt()
 	if err != nil {
-		return node, err
+		return node, reader.FieldError("cright", err)
 	}
 	node.sindex_, err = reader.ReadInt()
 	if err != nil {
-		return node, err
+		return node, reader.FieldError("sindex", err)
 	}
 	if node.is_leaf() {
 		node.leaf_value, err = reader.ReadFloat()
+		if err != nil {
+			return node, reader.FieldError("leaf_value", err)
+		}
 		node.split_cond = math.NaN()
 	} else {
 		node.split_cond, err = reader.ReadFloat()
+		if err != nil {
+			return node, reader.FieldError("split_cond", err)
+		}
 		node.leaf_value = math.NaN()
 	}
 
@@ -175,18 +226,18 @@ func newRTreeNodeStat(reader *util.ModelReader) (*RTreeNodeStat, error) {
 	var err error
 	rTreeNodeStat.Loss_chg, err = reader.ReadFloat()
 	if err != nil {
-		return rTreeNodeStat, err
+		return rTreeNodeStat, reader.FieldError("loss_chg", err)
 	}
 	rTreeNodeStat.Sum_hess, err = reader.ReadFloat()
 	if err != nil {
-		return rTreeNodeStat, err
+		return rTreeNodeStat, reader.FieldError("sum_hess", err)
 	}
 	rTreeNodeStat.Base_weight, err = reader.ReadFloat()
 	if err != nil {
-		return rTreeNodeStat, err
+		return rTreeNodeStat, reader.FieldError("base_weight", err)
 	}
 	rTreeNodeStat.Leaf_child_cnt, err = reader.ReadInt()
-	return rTreeNodeStat, err
+	return rTreeNodeStat, reader.FieldError("leaf_child_cnt", err)
 }
 
 func (n *Node) is_leaf() bool {
@@ -209,12 +260,12 @@ func (n *Node) default_left() bool {
 	return n.sindex_>>31 != 0
 }
 
-func (n *Node) nextFromArray(values []float32, treatsZeroAsNA bool) int {
+func (n *Node) nextFromArray(values []float32, missing float32) int {
 	if len(values) <= n._splitIndex {
 		return n._defaultNext
 	} else {
 		result := values[n._splitIndex]
-		if treatsZeroAsNA && result == 0.0 {
+		if result != result || result == missing {
 			return n._defaultNext
 		} else {
 			if result < n.split_cond {
@@ -238,3 +289,39 @@ func (n *Node) nextFromMap(values map[int]float32) int {
 		}
 	}
 }
+
+func (n *Node) Parent() int {
+	return n.parent_
+}
+
+func (n *Node) IsLeaf() bool {
+	return n._isLeaf
+}
+
+func (n *Node) LeftChild() int {
+	return n.cleft_
+}
+
+func (n *Node) RightChild() int {
+	return n.cright_
+}
+
+func (n *Node) DefaultChild() int {
+	return n._defaultNext
+}
+
+func (n *Node) DefaultLeft() bool {
+	return n.default_left()
+}
+
+func (n *Node) SplitIndex() int {
+	return n._splitIndex
+}
+
+func (n *Node) SplitCond() float32 {
+	return n.split_cond
+}
+
+func (n *Node) LeafValue() float32 {
+	return n.leaf_value
+}
@@ -0,0 +1,165 @@
+package tree
+
+// TreeSHAP feature contributions (Lundberg et al., "Consistent Individualized
+// Feature Attribution for Tree Ensembles"), following XGBoost's
+// RegTree::CalculateContributions. Node covers are taken from Sum_hess.
+
+type pathElement struct {
+	featureIndex int
+	zeroFraction float32
+	oneFraction  float32
+	pweight      float32
+}
+
+// NodeMeanValues returns the expected leaf value below every node, weighting
+// children by their cover.
+func (rt *RegTree) NodeMeanValues() []float32 {
+	means := make([]float32, len(rt.nodes))
+	var fill func(nid int) float32
+	fill = func(nid int) float32 {
+		n := rt.nodes[nid]
+		if n._isLeaf {
+			means[nid] = n.leaf_value
+		} else {
+			left := fill(n.cleft_) * rt.stats[n.cleft_].Sum_hess
+			right := fill(n.cright_) * rt.stats[n.cright_].Sum_hess
+			means[nid] = (left + right) / rt.stats[nid].Sum_hess
+		}
+		return means[nid]
+	}
+	fill(0)
+	return means
+}
+
+// MaxDepth returns the depth of the deepest leaf below nid.
+func (rt *RegTree) MaxDepth(nid int) int {
+	n := rt.nodes[nid]
+	if n._isLeaf {
+		return 0
+	}
+	left, right := rt.MaxDepth(n.cleft_), rt.MaxDepth(n.cright_)
+	if left > right {
+		return left + 1
+	}
+	return right + 1
+}
+
+// CalculateContributions adds the contribution of every feature of values to
+// phi[fid], and the expected value of the tree to phi[len(phi)-1]. means
+// must come from NodeMeanValues. A value is missing when it is NaN, equal to
+// missing, or beyond the end of values.
+func (rt *RegTree) CalculateContributions(values []float32, missing float32, means []float32, phi []float32) {
+	phi[len(phi)-1] += means[0]
+	maxd := rt.MaxDepth(0) + 2
+	path := make([]pathElement, maxd*(maxd+1)/2)
+	rt.treeShap(values, missing, phi, 0, 0, path, 1, 1, -1)
+}
+
+func (rt *RegTree) treeShap(values []float32, missing float32, phi []float32, nid int, uniqueDepth int,
+	parentPath []pathElement, parentZeroFraction, parentOneFraction float32, parentFeatureIndex int) {
+	n := rt.nodes[nid]
+
+	path := parentPath[uniqueDepth+1:]
+	copy(path, parentPath[:uniqueDepth+1])
+	extendPath(path, uniqueDepth, parentZeroFraction, parentOneFraction, parentFeatureIndex)
+
+	if n._isLeaf {
+		for i := 1; i <= uniqueDepth; i++ {
+			w := unwoundPathSum(path, uniqueDepth, i)
+			el := path[i]
+			phi[el.featureIndex] += w * (el.oneFraction - el.zeroFraction) * n.leaf_value
+		}
+		return
+	}
+
+	splitIndex := n._splitIndex
+	var hot int
+	if splitIndex >= len(values) {
+		hot = n._defaultNext
+	} else if value := values[splitIndex]; value != value || value == missing {
+		hot = n._defaultNext
+	} else if value < n.split_cond {
+		hot = n.cleft_
+	} else {
+		hot = n.cright_
+	}
+	cold := n.cleft_
+	if hot == n.cleft_ {
+		cold = n.cright_
+	}
+	w := rt.stats[nid].Sum_hess
+	hotZeroFraction := rt.stats[hot].Sum_hess / w
+	coldZeroFraction := rt.stats[cold].Sum_hess / w
+	incomingZeroFraction := float32(1)
+	incomingOneFraction := float32(1)
+
+	// If the feature was split on before, undo that split so that it can be
+	// redone for this node.
+	pathIndex := 0
+	for ; pathIndex <= uniqueDepth; pathIndex++ {
+		if path[pathIndex].featureIndex == splitIndex {
+			break
+		}
+	}
+	if pathIndex != uniqueDepth+1 {
+		incomingZeroFraction = path[pathIndex].zeroFraction
+		incomingOneFraction = path[pathIndex].oneFraction
+		unwindPath(path, uniqueDepth, pathIndex)
+		uniqueDepth--
+	}
+
+	rt.treeShap(values, missing, phi, hot, uniqueDepth+1, path, hotZeroFraction*incomingZeroFraction, incomingOneFraction, splitIndex)
+	rt.treeShap(values, missing, phi, cold, uniqueDepth+1, path, coldZeroFraction*incomingZeroFraction, 0, splitIndex)
+}
+
+func extendPath(path []pathElement, uniqueDepth int, zeroFraction, oneFraction float32, featureIndex int) {
+	path[uniqueDepth].featureIndex = featureIndex
+	path[uniqueDepth].zeroFraction = zeroFraction
+	path[uniqueDepth].oneFraction = oneFraction
+	if uniqueDepth == 0 {
+		path[uniqueDepth].pweight = 1
+	} else {
+		path[uniqueDepth].pweight = 0
+	}
+	for i := uniqueDepth - 1; i >= 0; i-- {
+		path[i+1].pweight += oneFraction * path[i].pweight * float32(i+1) / float32(uniqueDepth+1)
+		path[i].pweight = zeroFraction * path[i].pweight * float32(uniqueDepth-i) / float32(uniqueDepth+1)
+	}
+}
+
+func unwindPath(path []pathElement, uniqueDepth int, pathIndex int) {
+	oneFraction := path[pathIndex].oneFraction
+	zeroFraction := path[pathIndex].zeroFraction
+	nextOnePortion := path[uniqueDepth].pweight
+	for i := uniqueDepth - 1; i >= 0; i-- {
+		if oneFraction != 0 {
+			tmp := path[i].pweight
+			path[i].pweight = nextOnePortion * float32(uniqueDepth+1) / (float32(i+1) * oneFraction)
+			nextOnePortion = tmp - path[i].pweight*zeroFraction*float32(uniqueDepth-i)/float32(uniqueDepth+1)
+		} else {
+			path[i].pweight = path[i].pweight * float32(uniqueDepth+1) / (zeroFraction * float32(uniqueDepth-i))
+		}
+	}
+	for i := pathIndex; i < uniqueDepth; i++ {
+		path[i].featureIndex = path[i+1].featureIndex
+		path[i].zeroFraction = path[i+1].zeroFraction
+		path[i].oneFraction = path[i+1].oneFraction
+	}
+}
+
+func unwoundPathSum(path []pathElement, uniqueDepth int, pathIndex int) float32 {
+	oneFraction := path[pathIndex].oneFraction
+	zeroFraction := path[pathIndex].zeroFraction
+	nextOnePortion := path[uniqueDepth].pweight
+	total := float32(0)
+	for i := uniqueDepth - 1; i >= 0; i-- {
+		if oneFraction != 0 {
+			tmp := nextOnePortion * float32(uniqueDepth+1) / (float32(i+1) * oneFraction)
+			total += tmp
+			nextOnePortion = path[i].pweight - tmp*zeroFraction*(float32(uniqueDepth-i)/float32(uniqueDepth+1))
+		} else if zeroFraction != 0 {
+			total += (path[i].pweight / zeroFraction) / (float32(uniqueDepth-i) / float32(uniqueDepth+1))
+		}
+	}
+	return total
+}
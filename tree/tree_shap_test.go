@@ -0,0 +1,57 @@
+package tree
+
+import (
+	"math"
+	"testing"
+
+	"xgboost4go-predictor/internal/testmodel"
+)
+
+// TestCalculateContributions checks TreeSHAP against Shapley values worked
+// out by hand for this tree, with covers in brackets:
+//
+//	0: f0 < 0.5 [10]
+//	1:   f1 < 0.5 [4]
+//	3:     leaf 1 [1]
+//	4:     leaf 2 [3]
+//	2:   leaf 4 [6]
+//
+// The expected value is (1*1 + 3*2 + 6*4) / 10 = 3.1. For the row (0, 0),
+// E[f | f0] = 1.75 and E[f | f1] = 0.4*1 + 0.6*4 = 2.8, so
+// phi0 = ((1.75 - 3.1) + (1 - 2.8)) / 2 = -1.575 and
+// phi1 = ((2.8 - 3.1) + (1 - 1.75)) / 2 = -0.525.
+func TestCalculateContributions(t *testing.T) {
+	spec := testmodel.Tree{Depth: 2, Nodes: []testmodel.Node{
+		{Parent: -1, Left: 1, Right: 2, Feature: 0, Value: 0.5, Cover: 10},
+		{Parent: 0, Left: 3, Right: 4, Feature: 1, Value: 0.5, Cover: 4},
+		{Parent: 0, Left: -1, Right: -1, Value: 4, Cover: 6},
+		{Parent: 1, Left: -1, Right: -1, Value: 1, Cover: 1},
+		{Parent: 1, Left: -1, Right: -1, Value: 2, Cover: 3},
+	}}
+	rt := loadTrees(t, testmodel.EncodeTrees(3, spec), 1)[0]
+	means := rt.NodeMeanValues()
+	nan := float32(math.NaN())
+	cases := []struct {
+		row      []float32
+		expected []float32
+	}{
+		{[]float32{0, 0, 7}, []float32{-1.575, -0.525, 0, 3.1}},
+		{[]float32{0, 1, 7}, []float32{-1.275, 0.175, 0, 3.1}},
+		{[]float32{1, 0, 7}, []float32{1.05, -0.15, 0, 3.1}},
+		// Missing values follow the default child, the right one here,
+		// and are attributed like present values: f1 missing is worth
+		// 0.4*2 + 0.6*4 = 3.2.
+		{[]float32{nan, 0}, []float32{1.05, -0.15, 0, 3.1}},
+		{[]float32{1}, []float32{0.85, 0.05, 0, 3.1}},
+	}
+	for _, c := range cases {
+		phi := make([]float32, 4)
+		rt.CalculateContributions(c.row, nan, means, phi)
+		for k := range phi {
+			if math.Abs(float64(phi[k]-c.expected[k])) > 1e-6 {
+				t.Errorf("row %v: phi = %v, want %v", c.row, phi, c.expected)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package tree
+
+import (
+	"fmt"
+)
+
+// Validate checks that the nodes reachable from the roots form trees: child
+// indices are in range and point back to their parent, no node is reached
+// twice, leaves have no children, and split indices are below num_feature
+// when it is positive. Deleted nodes, which are unreachable, are ignored.
+func (rt *RegTree) Validate(num_feature int) error {
+	num_nodes := len(rt.nodes)
+	if num_nodes == 0 {
+		return fmt.Errorf("Tree has no nodes.")
+	}
+	num_roots := 1
+	if rt.param != nil {
+		num_roots = rt.param.num_roots
+	}
+	if num_roots < 1 || num_roots > num_nodes {
+		return fmt.Errorf("Invalid num_roots %d for %d nodes.", num_roots, num_nodes)
+	}
+
+	visited := make([]bool, num_nodes)
+	stack := make([]int, 0, num_roots)
+	for root := num_roots - 1; root >= 0; root-- {
+		stack = append(stack, root)
+	}
+	for len(stack) > 0 {
+		nid := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[nid] {
+			return fmt.Errorf("Node %d is reached twice, the tree has a cycle.", nid)
+		}
+		visited[nid] = true
+
+		n := rt.nodes[nid]
+		if n._isLeaf {
+			if n.cright_ != -1 {
+				return fmt.Errorf("Leaf node %d has a right child %d.", nid, n.cright_)
+			}
+			continue
+		}
+		for _, child := range []int{n.cleft_, n.cright_} {
+			if child < 0 || child >= num_nodes {
+				return fmt.Errorf("Node %d has child %d, out of range [0, %d).", nid, child, num_nodes)
+			}
+			if child < num_roots {
+				return fmt.Errorf("Node %d has root %d as a child.", nid, child)
+			}
+			// The parent index of a left child has its sign bit set.
+			if parent := rt.nodes[child].parent_ & 0x7fffffff; parent != nid {
+				return fmt.Errorf("Node %d has parent %d, expected %d.", child, parent, nid)
+			}
+		}
+		if n.cleft_ == n.cright_ {
+			return fmt.Errorf("Node %d has the same left and right child %d.", nid, n.cleft_)
+		}
+		if num_feature > 0 && n._splitIndex >= num_feature {
+			return fmt.Errorf("Node %d splits on feature %d, num_feature is %d.", nid, n._splitIndex, num_feature)
+		}
+		stack = append(stack, n.cright_, n.cleft_)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	FEATURE_TYPE_INDICATOR    = "i"
+	FEATURE_TYPE_QUANTITATIVE = "q"
+	FEATURE_TYPE_INTEGER      = "int"
+	FEATURE_TYPE_FLOAT        = "float"
+)
+
+// FeatureMap maps XGBoost feature indices to names, as in the fmap files
+// accepted by xgboost's dump_model ("<fid>\t<name>\t<type>" per line). Names
+// may contain spaces: the id is the first field of a line and the type the
+// last.
+type FeatureMap struct {
+	names []string
+	types []string
+	index map[string]int
+}
+
+func NewFeatureMapByFile(fileName string) (*FeatureMap, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewFeatureMapByReader(file)
+}
+
+func NewFeatureMapByReader(reader io.Reader) (*FeatureMap, error) {
+	featureMap := newFeatureMap()
+	scanner := bufio.NewScanner(reader)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		first := strings.IndexAny(line, " \t")
+		last := strings.LastIndexAny(line, " \t")
+		if first < 0 {
+			return nil, fmt.Errorf("Invalid feature map line %d: %q", lineNo, line)
+		}
+		name := strings.TrimSpace(line[first:last])
+		if name == "" {
+			return nil, fmt.Errorf("Invalid feature map line %d: %q", lineNo, line)
+		}
+		fid, err := strconv.Atoi(line[:first])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid feature id at line %d: %s", lineNo, line[:first])
+		}
+		if fid != len(featureMap.names) {
+			return nil, fmt.Errorf("Feature ids must be consecutive from 0: expected = %d, actual = %d", len(featureMap.names), fid)
+		}
+		err = featureMap.add(name, line[last+1:])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return featureMap, nil
+}
+
+func NewFeatureMapByNames(names []string) (*FeatureMap, error) {
+	featureMap := newFeatureMap()
+	for _, name := range names {
+		err := featureMap.add(name, FEATURE_TYPE_QUANTITATIVE)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return featureMap, nil
+}
+
+func newFeatureMap() *FeatureMap {
+	featureMap := new(FeatureMap)
+	featureMap.index = make(map[string]int)
+	return featureMap
+}
+
+func (fm *FeatureMap) add(name, featureType string) error {
+	switch featureType {
+	case FEATURE_TYPE_INDICATOR, FEATURE_TYPE_QUANTITATIVE, FEATURE_TYPE_INTEGER, FEATURE_TYPE_FLOAT:
+	default:
+		return fmt.Errorf("%s is not supported feature type.", featureType)
+	}
+	if _, ok := fm.index[name]; ok {
+		return fmt.Errorf("Duplicate feature name: %s", name)
+	}
+	fm.index[name] = len(fm.names)
+	fm.names = append(fm.names, name)
+	fm.types = append(fm.types, featureType)
+	return nil
+}
+
+func (fm *FeatureMap) NumFeature() int {
+	return len(fm.names)
+}
+
+func (fm *FeatureMap) Name(fid int) string {
+	return fm.names[fid]
+}
+
+func (fm *FeatureMap) Type(fid int) string {
+	return fm.types[fid]
+}
+
+func (fm *FeatureMap) Index(name string) (int, bool) {
+	fid, ok := fm.index[name]
+	return fid, ok
+}
+
+// Resolve returns the feature index of every name, or -1 for unknown names.
+// In strict mode an unknown name is an error instead.
+func (fm *FeatureMap) Resolve(names []string, strict bool) ([]int, error) {
+	fids := make([]int, len(names))
+	for i, name := range names {
+		fid, ok := fm.index[name]
+		if !ok {
+			if strict {
+				return nil, fmt.Errorf("Unknown feature name: %s", name)
+			}
+			fid = -1
+		}
+		fids[i] = fid
+	}
+	return fids, nil
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFeatureMapNamesWithSpaces(t *testing.T) {
+	featureMap, err := NewFeatureMapByReader(strings.NewReader("0\tage\tint\n1\tcity name\ti\n2  two  spaces  q\n\n3 last\tfloat\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		name        string
+		featureType string
+	}{
+		{"age", FEATURE_TYPE_INTEGER},
+		{"city name", FEATURE_TYPE_INDICATOR},
+		{"two  spaces", FEATURE_TYPE_QUANTITATIVE},
+		{"last", FEATURE_TYPE_FLOAT},
+	}
+	if featureMap.NumFeature() != len(expected) {
+		t.Fatalf("NumFeature = %d, want %d", featureMap.NumFeature(), len(expected))
+	}
+	for fid, e := range expected {
+		if featureMap.Name(fid) != e.name || featureMap.Type(fid) != e.featureType {
+			t.Errorf("feature %d = %q %q, want %q %q", fid, featureMap.Name(fid), featureMap.Type(fid), e.name, e.featureType)
+		}
+		if index, ok := featureMap.Index(e.name); !ok || index != fid {
+			t.Errorf("Index(%q) = %d, %v", e.name, index, ok)
+		}
+	}
+}
+
+func TestFeatureMapInvalidLines(t *testing.T) {
+	for _, text := range []string{
+		"0\n",
+		"0 q\n",
+		"0\t \tq\n",
+		"x a q\n",
+		"1 a q\n",
+		"0 a unknown\n",
+		"0 a q\n1 a q\n",
+	} {
+		_, err := NewFeatureMapByReader(strings.NewReader(text))
+		if err == nil {
+			t.Errorf("%q was accepted", text)
+		}
+	}
+}
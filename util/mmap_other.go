@@ -0,0 +1,36 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+// MappedFile holds a whole file in memory on platforms without mmap support.
+type MappedFile struct {
+	data []byte
+}
+
+func MapFile(fileName string) (*MappedFile, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("Cannot map empty file %s", fileName)
+	}
+	mappedFile := new(MappedFile)
+	mappedFile.data = data
+	return mappedFile, nil
+}
+
+func (mf *MappedFile) Bytes() []byte {
+	return mf.data
+}
+
+func (mf *MappedFile) Close() error {
+	mf.data = nil
+	return nil
+}
@@ -0,0 +1,53 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// MappedFile is a read-only, shared memory mapping of a whole file. Pages
+// are shared between every process mapping the same file.
+type MappedFile struct {
+	data []byte
+}
+
+func MapFile(fileName string) (*MappedFile, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size <= 0 || int64(int(size)) != size {
+		return nil, fmt.Errorf("Cannot map file %s of size %d", fileName, size)
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	mappedFile := new(MappedFile)
+	mappedFile.data = data
+	return mappedFile, nil
+}
+
+func (mf *MappedFile) Bytes() []byte {
+	return mf.data
+}
+
+func (mf *MappedFile) Close() error {
+	if mf.data == nil {
+		return nil
+	}
+	data := mf.data
+	mf.data = nil
+	return syscall.Munmap(data)
+}
@@ -4,13 +4,64 @@ import (
 	"bufio"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 	"os"
 )
 
+// READ_CHUNK_SIZE bounds how much buffer is allocated ahead of the data
+// actually read, so that a corrupt length cannot cause a huge allocation
+// before the input runs out.
+const READ_CHUNK_SIZE = 64 * 1024
+
+// MAX_ARRAY_LENGTH is the largest number of values read into one array.
+const MAX_ARRAY_LENGTH = math.MaxInt32
+
+// ModelReader reads the little-endian fields of a model file and keeps
+// track of the offset. Read errors are returned, never panicked, and
+// io.EOF in the middle of a value is reported as io.ErrUnexpectedEOF.
 type ModelReader struct {
 	buffer     []byte
 	byteReader bufio.Reader
+	offset     int64
+	start      int64
+
+	// MaxBytes limits the number of bytes read from the model, 0 for no
+	// limit.
+	MaxBytes int64
+}
+
+// ReadError is returned by the model loaders when a field cannot be read or
+// has an invalid value. Field names the field, such as "tree 12 node 5
+// split_cond", and Offset is its byte offset in the model, or -1 when the
+// error is not about a single field.
+type ReadError struct {
+	Field  string
+	Offset int64
+	Err    error
+}
+
+func (e *ReadError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("%s: %v", e.Field, e.Err)
+	}
+	return fmt.Sprintf("%s at offset %d: %v", e.Field, e.Offset, e.Err)
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// WithinField prefixes the field of a ReadError with scope, e.g. "tree 12",
+// or wraps other errors in a ReadError without an offset.
+func WithinField(scope string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if readError, ok := err.(*ReadError); ok {
+		return &ReadError{Field: scope + " " + readError.Field, Offset: readError.Offset, Err: readError.Err}
+	}
+	return &ReadError{Field: scope, Offset: -1, Err: err}
 }
 
 func NewModelReaderByFile(fileName string) (*ModelReader, *os.File, error) {
@@ -28,44 +79,94 @@ func NewModelReaderByReader(reader bufio.Reader) *ModelReader {
 	return modelReader
 }
 
-func (mr *ModelReader) fillBuffer(numBytes int) (int, error) {
-	if mr.buffer == nil || len(mr.buffer) < numBytes {
-		mr.buffer = make([]byte, numBytes)
+// Offset returns the number of bytes read so far.
+func (mr *ModelReader) Offset() int64 {
+	return mr.offset
+}
+
+// FieldError wraps err, returned by the last read or about the value it
+// returned, in a ReadError for field at the offset of that read.
+func (mr *ModelReader) FieldError(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ReadError{Field: field, Offset: mr.start, Err: err}
+}
+
+// begin starts reading a value of numBytes bytes.
+func (mr *ModelReader) begin(numBytes int) error {
+	mr.start = mr.offset
+	if numBytes < 0 {
+		return fmt.Errorf("Invalid length: %d", numBytes)
+	}
+	if mr.MaxBytes > 0 && int64(numBytes) > mr.MaxBytes-mr.offset {
+		return fmt.Errorf("Model is larger than %d bytes.", mr.MaxBytes)
 	}
+	return nil
+}
 
-	count := 0
+// fillBuffer reads numBytes bytes into mr.buffer. The buffer grows by at
+// most READ_CHUNK_SIZE bytes past the data read.
+func (mr *ModelReader) fillBuffer(numBytes int) error {
+	err := mr.begin(numBytes)
+	if err != nil {
+		return err
+	}
 	numBytesRead := 0
-	var err error
-	for ; numBytesRead < numBytes; numBytesRead += count {
-		count, err = mr.byteReader.Read(mr.buffer[numBytesRead:numBytes])
-		if err != nil {
-			panic(err)
+	for numBytesRead < numBytes {
+		chunk := numBytes - numBytesRead
+		if chunk > READ_CHUNK_SIZE {
+			chunk = READ_CHUNK_SIZE
 		}
-		if count < 0 {
-			return numBytesRead, err
+		if len(mr.buffer) < numBytesRead+chunk {
+			size := 2 * len(mr.buffer)
+			if size < numBytesRead+chunk {
+				size = numBytesRead + chunk
+			}
+			if size > numBytes {
+				size = numBytes
+			}
+			buffer := make([]byte, size)
+			copy(buffer, mr.buffer[:numBytesRead])
+			mr.buffer = buffer
+		}
+		count, err := io.ReadFull(&mr.byteReader, mr.buffer[numBytesRead:numBytesRead+chunk])
+		numBytesRead += count
+		mr.offset += int64(count)
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
 		}
 	}
-
-	return numBytesRead, nil
+	return nil
 }
 
 func (mr *ModelReader) ReadByteAsInt() (int, error) {
+	err := mr.begin(1)
+	if err != nil {
+		return 0, err
+	}
 	b, err := mr.byteReader.ReadByte()
-	return int(b), err
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, err
+	}
+	mr.offset++
+	return int(b), nil
 }
 
 func (mr *ModelReader) ReadByteArray(numBytes int) ([]byte, error) {
-	numBytesRead, err := mr.fillBuffer(numBytes)
+	err := mr.fillBuffer(numBytes)
 	if err != nil {
-		return nil, nil
-	}
-	if numBytesRead < numBytes {
-		return nil, fmt.Errorf("Cannot read byte array (shortage): expected = %d, actual = %d", numBytes, numBytesRead)
-	} else {
-		result := make([]byte, numBytes)
-		copy(mr.buffer[0:numBytes], result)
-		return result, nil
+		return nil, err
 	}
+	result := make([]byte, numBytes)
+	copy(result, mr.buffer[0:numBytes])
+	return result, nil
 }
 
 func (mr *ModelReader) ReadInt() (int, error) {
@@ -77,32 +178,27 @@ func (mr *ModelReader) ReadIntBE() (int, error) {
 }
 
 func (mr *ModelReader) ReadIntByteOrder(order binary.ByteOrder) (int, error) {
-	numBytesRead, err := mr.fillBuffer(4)
+	err := mr.fillBuffer(4)
 	if err != nil {
 		return 0, err
 	}
-	if numBytesRead < 4 {
-		return 0, fmt.Errorf("Cannot read int value (shortage): %d", numBytesRead)
-	} else {
-		return int(int32(order.Uint32(mr.buffer[0:4]))), nil
-	}
+	return int(int32(order.Uint32(mr.buffer[0:4]))), nil
 }
 
 func (mr *ModelReader) ReadIntArray(numValues int) ([]int, error) {
-	numBytesRead, err := mr.fillBuffer(numValues * 4)
+	if numValues < 0 || numValues > MAX_ARRAY_LENGTH {
+		mr.start = mr.offset
+		return nil, fmt.Errorf("Invalid int array length: %d", numValues)
+	}
+	err := mr.fillBuffer(numValues * 4)
 	if err != nil {
 		return nil, err
 	}
-	if numBytesRead < numValues*4 {
-		return nil, fmt.Errorf("Cannot read int array (shortage): expected = %d, actual = %d", numValues*4, numBytesRead)
-	} else {
-		res := make([]int, numValues)
-		for i := 0; i < numValues; i++ {
-			res[i] = int(int32(binary.LittleEndian.Uint32(mr.buffer[i*4:(i+1)*4])))
-		}
-
-		return res, nil
+	res := make([]int, numValues)
+	for i := 0; i < numValues; i++ {
+		res[i] = int(int32(binary.LittleEndian.Uint32(mr.buffer[i*4 : (i+1)*4])))
 	}
+	return res, nil
 }
 
 func (mr *ModelReader) ReadUnsignedInt() (int, error) {
@@ -110,7 +206,7 @@ func (mr *ModelReader) ReadUnsignedInt() (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	if (result < 0) {
+	if result < 0 {
 		return 0, fmt.Errorf("Cannot read unsigned int (overflow): %d", result)
 	} else {
 		return result, nil
@@ -118,19 +214,15 @@ func (mr *ModelReader) ReadUnsignedInt() (int, error) {
 }
 
 func (mr *ModelReader) ReadInt64() (int64, error) {
-	numBytesRead, err := mr.fillBuffer(8)
+	err := mr.fillBuffer(8)
 	if err != nil {
 		return 0, err
 	}
-	if numBytesRead < 8 {
-		return 0, fmt.Errorf("Cannot read long value (shortage): %d", numBytesRead)
-	} else {
-		return int64(binary.LittleEndian.Uint64(mr.buffer[0:8])), nil
-	}
+	return int64(binary.LittleEndian.Uint64(mr.buffer[0:8])), nil
 }
 
 func (mr *ModelReader) AsFloat(bytes []byte) float32 {
-	return float32(binary.LittleEndian.Uint32(mr.buffer[0:4]))
+	return math.Float32frombits(binary.LittleEndian.Uint32(bytes))
 }
 
 func (mr *ModelReader) AsUnsignedInt(bytes []byte) (int, error) {
@@ -143,60 +235,59 @@ func (mr *ModelReader) AsUnsignedInt(bytes []byte) (int, error) {
 }
 
 func (mr *ModelReader) ReadFloat() (float32, error) {
-	numBytesRead, err := mr.fillBuffer(4)
+	err := mr.fillBuffer(4)
 	if err != nil {
 		return 0, err
 	}
-	if numBytesRead < 4 {
-		return 0, fmt.Errorf("Cannot read float value (shortage): %d", numBytesRead)
-	} else {
-		return math.Float32frombits(binary.LittleEndian.Uint32(mr.buffer[0:4])), nil
-	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(mr.buffer[0:4])), nil
 }
 
 func (mr *ModelReader) ReadFloatArray(numValues int) ([]float32, error) {
-	numBytesRead, err := mr.fillBuffer(numValues * 4)
+	if numValues < 0 || numValues > MAX_ARRAY_LENGTH {
+		mr.start = mr.offset
+		return nil, fmt.Errorf("Invalid float array length: %d", numValues)
+	}
+	err := mr.fillBuffer(numValues * 4)
 	if err != nil {
 		return nil, err
 	}
-	if numBytesRead < numValues*4 {
-		return nil, fmt.Errorf("Cannot read float array (shortage): expected = %d, actual = %d", numValues*4, numBytesRead)
-	} else {
-		res := make([]float32, numValues)
-		for i := 0; i < numValues; i++ {
-			res[i] = math.Float32frombits(binary.LittleEndian.Uint32(mr.buffer[i*4:(i+1)*4]))
-		}
-
-		return res, nil
+	res := make([]float32, numValues)
+	for i := 0; i < numValues; i++ {
+		res[i] = math.Float32frombits(binary.LittleEndian.Uint32(mr.buffer[i*4 : (i+1)*4]))
 	}
+	return res, nil
 }
 
 func (mr *ModelReader) ReadDoubleArrayBE(numValues int) ([]float64, error) {
-	numBytesRead, err := mr.fillBuffer(numValues * 8)
+	if numValues < 0 || numValues > MAX_ARRAY_LENGTH {
+		mr.start = mr.offset
+		return nil, fmt.Errorf("Invalid double array length: %d", numValues)
+	}
+	err := mr.fillBuffer(numValues * 8)
 	if err != nil {
 		return nil, err
 	}
-	if numBytesRead < numValues*8 {
-		return nil, fmt.Errorf("Cannot read double array (shortage): expected = %d, actual = %d", numValues*8, numBytesRead)
-	} else {
-		res := make([]float64, numValues)
-		for i := 0; i < numValues; i++ {
-			res[i] = math.Float64frombits(binary.LittleEndian.Uint64(mr.buffer[i*8:(i+1)*8]))
-		}
-
-		return res, nil
+	res := make([]float64, numValues)
+	for i := 0; i < numValues; i++ {
+		res[i] = math.Float64frombits(binary.LittleEndian.Uint64(mr.buffer[i*8 : (i+1)*8]))
 	}
+	return res, nil
 }
 
-func (mr *ModelReader) Skip(numBytes int) error {
-	numBytesRead, err := mr.byteReader.Discard(numBytes)
-	if err != nil {
-		return err
+func (mr *ModelReader) Skip(numBytes int64) error {
+	mr.start = mr.offset
+	if numBytes < 0 || numBytes > 8*MAX_ARRAY_LENGTH {
+		return fmt.Errorf("Invalid number of bytes to skip: %d", numBytes)
 	}
-	if numBytesRead < numBytes {
-		return fmt.Errorf("Cannot skip bytes: %d", numBytesRead)
+	if mr.MaxBytes > 0 && numBytes > mr.MaxBytes-mr.offset {
+		return fmt.Errorf("Model is larger than %d bytes.", mr.MaxBytes)
 	}
-	return nil
+	numBytesRead, err := mr.byteReader.Discard(int(numBytes))
+	mr.offset += int64(numBytesRead)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
 }
 
 func (mr *ModelReader) ReadString() (string, error) {
@@ -204,21 +295,16 @@ func (mr *ModelReader) ReadString() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if length > 2147483647 {
-		return "", fmt.Errorf("Too long string: %d", length)
-	} else {
-		return mr.ReadFixedString(int(length))
+	if length < 0 || length > MAX_ARRAY_LENGTH {
+		return "", fmt.Errorf("Invalid string length: %d", length)
 	}
+	return mr.ReadFixedString(int(length))
 }
 
 func (mr *ModelReader) ReadFixedString(numBytes int) (string, error) {
-	numBytesRead, err := mr.fillBuffer(numBytes)
+	err := mr.fillBuffer(numBytes)
 	if err != nil {
 		return "", err
 	}
-	if numBytesRead < numBytes {
-		return "", fmt.Errorf("Cannot read string(%d) (shortage): %d", numBytes, numBytesRead)
-	} else {
-		return string(mr.buffer[0:numBytes]), nil
-	}
+	return string(mr.buffer[0:numBytes]), nil
 }